@@ -3,12 +3,212 @@ package main
 import (
 	"flag"
 	"log"
+	"log/slog"
+	"os"
 
+	"github.com/kylerisse/go-signs/pkg/discovery"
+	"github.com/kylerisse/go-signs/pkg/fleet"
+	"github.com/kylerisse/go-signs/pkg/logging"
 	"github.com/kylerisse/go-signs/pkg/server"
 )
 
-func run(c server.Config) error {
+// options holds the raw values of the go-signs flags
+type options struct {
+	listenPort       string
+	listen           server.StringList
+	tlsCert          string
+	tlsKey           string
+	redirectHTTP     string
+	jsonEndpoint     string
+	jsonFallback     server.StringList
+	refreshInterval  int
+	mirror           bool
+	sponsors         string
+	sponsorImages    string
+	strictSponsors   bool
+	impressionsDB    string
+	adminToken       string
+	displayDir       string
+	branding         string
+	brandingAssets   string
+	signID           string
+	fleetToken       string
+	fleetAdminToken  string
+	controllerURL    string
+	heartbeat        int
+	controller       bool
+	staleAfter       int
+	profiles         string
+	room             string
+	layout           string
+	sponsorTiers     server.StringList
+	scheduleRotation int
+	sponsorRotation  int
+	mdns             bool
+	mdnsBrowse       bool
+	mdnsHubs         server.StringList
+	mdnsInterface    string
+	logLevel         string
+	logFormat        string
+}
+
+// newFlagSet defines the go-signs flags on a fresh FlagSet bound to o
+func newFlagSet(o *options) *flag.FlagSet {
+	fs := flag.NewFlagSet("go-signs", flag.ExitOnError)
+	fs.StringVar(&o.listenPort, "port", "2017", "Port to listen on (1-65535)")
+	fs.Var(&o.listen, "listen", "Address to listen on as host:port, unix:/path or systemd[:name], repeatable (overrides -port)")
+	fs.StringVar(&o.tlsCert, "tls-cert", "", "TLS certificate file, enables HTTPS with -tls-key")
+	fs.StringVar(&o.tlsKey, "tls-key", "", "TLS private key file")
+	fs.StringVar(&o.redirectHTTP, "redirect-http", "", "Address for a plain HTTP listener redirecting to HTTPS")
+	fs.StringVar(&o.jsonEndpoint, "json", "https://www.socallinuxexpo.org/scale/23x/signs", "URL to Drupal JSON endpoint (must be http or https)")
+	fs.Var(&o.jsonFallback, "json-fallback", "Feed URL to try when -json fails, http, https or file://, repeatable in priority order")
+	fs.IntVar(&o.refreshInterval, "refresh", 5, "Schedule refresh interval in minutes (minimum 1)")
+	fs.BoolVar(&o.mirror, "mirror", false, "Serve the last fetched feed at /sign.json for other signs to use as -json")
+	fs.StringVar(&o.sponsors, "sponsors", "", "JSON manifest of sponsor tiers, replacing the embedded one")
+	fs.StringVar(&o.sponsorImages, "sponsor-images", "", "Directory of sponsor images served over the embedded ones, watched for changes")
+	fs.BoolVar(&o.strictSponsors, "strict-sponsors", false, "Refuse to start with, or reload to, sponsor tiers whose images are missing, broken or not 220x220")
+	fs.StringVar(&o.impressionsDB, "impressions-db", "", "BoltDB file to count sponsor impressions reported by the display in, served at /sponsors/report")
+	fs.StringVar(&o.adminToken, "admin-token", "", "Secret for the sponsor logo and tier admin API under /admin/sponsors (minimum 16 characters, requires -sponsors and -sponsor-images)")
+	fs.StringVar(&o.displayDir, "display-dir", "", "Serve the display built into this directory instead of the embedded one, for development with npm run watch")
+	fs.StringVar(&o.branding, "branding", "", "TOML file with the event name, logo, Wi-Fi details and colors for the display, re-read when it changes")
+	fs.StringVar(&o.brandingAssets, "branding-assets", "", "Directory of the images named in -branding (default the file's directory)")
+	fs.StringVar(&o.signID, "sign-id", defaultSignID(), "ID this sign reports to the fleet controller")
+	fs.StringVar(&o.fleetToken, "fleet-token", "", "Shared secret for fleet heartbeats (minimum 16 characters)")
+	fs.StringVar(&o.fleetAdminToken, "fleet-admin-token", "", "Secret for listing signs and sending commands on the controller, unlike -fleet-token (minimum 16 characters, requires -controller)")
+	fs.StringVar(&o.controllerURL, "controller-url", "", "URL of the fleet controller to send heartbeats to (must be http or https)")
+	fs.IntVar(&o.heartbeat, "heartbeat", 60, "Heartbeat interval in seconds (minimum 5)")
+	fs.BoolVar(&o.controller, "controller", false, "Run as fleet controller accepting heartbeats from other signs")
+	fs.IntVar(&o.staleAfter, "stale-after", 180, "Seconds without a heartbeat before the controller flags a sign as stale")
+	fs.StringVar(&o.profiles, "profiles", "", "TOML file of per-sign display profiles served by the controller")
+	fs.StringVar(&o.room, "room", "", "Pin the display to this room (overrides the controller profile)")
+	fs.StringVar(&o.layout, "layout", "", "Display layout, hallway or room (overrides the controller profile)")
+	fs.Var(&o.sponsorTiers, "sponsor-tiers", "Sponsor tiers to display, repeatable (overrides the controller profile)")
+	fs.IntVar(&o.scheduleRotation, "schedule-rotation", 0, "Seconds between schedule pages, 0 for the controller profile or default")
+	fs.IntVar(&o.sponsorRotation, "sponsor-rotation", 0, "Seconds between sponsor logos, 0 for the controller profile or default")
+	fs.BoolVar(&o.mdns, "mdns", false, "Advertise this instance on the local network as "+discovery.ServiceType+" over mDNS")
+	fs.BoolVar(&o.mdnsBrowse, "mdns-browse", false, "Find other instances over mDNS and list them at "+discovery.PeersPath)
+	fs.Var(&o.mdnsHubs, "mdns-hub", "Hub found over mDNS to use as a feed fallback, by name or address, repeatable (requires -mdns-browse)")
+	fs.StringVar(&o.mdnsInterface, "mdns-interface", "", "Network interface for mDNS, all of them when empty")
+	fs.StringVar(&o.logLevel, "log-level", "info", "Log level (debug, info, warn or error)")
+	fs.StringVar(&o.logFormat, "log-format", "text", "Log format (text or json)")
+	return fs
+}
+
+// defaultSignID returns the hostname, which is unique per Pi
+func defaultSignID() string {
+	host, err := os.Hostname()
+	if err != nil {
+		return "go-signs"
+	}
+	return host
+}
+
+// loadOptions reads the flags from the command line, GO_SIGNS_* env and
+// config file
+func loadOptions() (options, *flag.FlagSet, *server.Settings, error) {
+	var o options
+	fs := newFlagSet(&o)
+	settings, err := server.LoadSettings(fs, os.Args[1:], os.Getenv)
+	return o, fs, settings, err
+}
+
+// reloadConfig re-reads all settings for SIGHUP, applying log settings
+// directly and returning the server configuration
+func reloadConfig(current *options) server.ReloadFunc {
+	return func() (server.Config, error) {
+		o, _, _, err := loadOptions()
+		if err != nil {
+			return server.Config{}, err
+		}
+
+		conf, err := newConfig(o)
+		if err != nil {
+			return server.Config{}, err
+		}
+
+		if o.logLevel != current.logLevel || o.logFormat != current.logFormat {
+			if err := logging.Setup(os.Stderr, o.logLevel, o.logFormat); err != nil {
+				return server.Config{}, err
+			}
+			slog.Info("log settings changed", "component", "server", "level", o.logLevel, "format", o.logFormat)
+		}
+
+		*current = o
+		return conf, nil
+	}
+}
+
+// newConfig validates the options into a server configuration
+func newConfig(o options) (server.Config, error) {
+	conf, err := server.NewConfig(o.listenPort, o.jsonEndpoint, o.refreshInterval)
+	if err != nil {
+		return server.Config{}, err
+	}
+	conf.Mirror = o.mirror
+	conf.StrictSponsors = o.strictSponsors
+	if err := conf.SetFeedFallbacks(o.jsonFallback); err != nil {
+		return server.Config{}, err
+	}
+	if err := conf.SetSponsorsFile(o.sponsors); err != nil {
+		return server.Config{}, err
+	}
+	if err := conf.SetSponsorImagesDir(o.sponsorImages); err != nil {
+		return server.Config{}, err
+	}
+	if err := conf.SetImpressionsDB(o.impressionsDB); err != nil {
+		return server.Config{}, err
+	}
+	if err := conf.SetAdminToken(o.adminToken); err != nil {
+		return server.Config{}, err
+	}
+	if err := conf.SetDisplayDir(o.displayDir); err != nil {
+		return server.Config{}, err
+	}
+	if err := conf.SetBranding(o.branding, o.brandingAssets); err != nil {
+		return server.Config{}, err
+	}
+	if err := conf.SetListenAddresses(o.listen); err != nil {
+		return server.Config{}, err
+	}
+	if err := conf.SetTLS(o.tlsCert, o.tlsKey); err != nil {
+		return server.Config{}, err
+	}
+	if err := conf.SetRedirectAddress(o.redirectHTTP); err != nil {
+		return server.Config{}, err
+	}
+	if err := conf.SetReporter(o.controllerURL, o.signID, o.fleetToken, o.heartbeat); err != nil {
+		return server.Config{}, err
+	}
+	if err := conf.SetController(o.controller, o.fleetToken, o.staleAfter); err != nil {
+		return server.Config{}, err
+	}
+	if err := conf.SetFleetAdminToken(o.fleetAdminToken); err != nil {
+		return server.Config{}, err
+	}
+	if err := conf.SetProfilesFile(o.profiles); err != nil {
+		return server.Config{}, err
+	}
+	if err := conf.SetProfile(o.signID, fleet.Profile{
+		Room:                    o.room,
+		Layout:                  o.layout,
+		SponsorTiers:            o.sponsorTiers,
+		ScheduleRotationSeconds: o.scheduleRotation,
+		SponsorRotationSeconds:  o.sponsorRotation,
+	}); err != nil {
+		return server.Config{}, err
+	}
+	if err := conf.SetDiscovery(o.mdns, o.mdnsBrowse, o.mdnsInterface); err != nil {
+		return server.Config{}, err
+	}
+	if err := conf.SetTrustedHubs(o.mdnsHubs); err != nil {
+		return server.Config{}, err
+	}
+	return conf, nil
+}
+
+func run(c server.Config, reload server.ReloadFunc) error {
 	server := server.NewServer(c)
+	server.SetReloadFunc(reload)
 	err := server.ListenAndServe()
 	if err != nil {
 		return err
@@ -17,21 +217,49 @@ func run(c server.Config) error {
 }
 
 func main() {
-	listenPort := flag.String("port", "2017", "Port to listen on (1-65535)")
-	jsonEndpoint := flag.String("json", "https://www.socallinuxexpo.org/scale/23x/signs", "URL to Drupal JSON endpoint (must be http or https)")
-	refreshInterval := flag.Int("refresh", 5, "Schedule refresh interval in minutes (minimum 1)")
-	flag.Parse()
+	// Subcommands take over before any server flags are parsed
+	if len(os.Args) > 1 && os.Args[1] == "sponsors" {
+		if err := runSponsors(os.Args[2:], os.Stdout); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
+	// Fill in flags from the command line, GO_SIGNS_* env and config file
+	o, fs, settings, err := loadOptions()
+	if err != nil {
+		fs.Usage()
+		log.Fatal(err)
+	}
+
+	if settings.Print {
+		if err := settings.Write(os.Stdout); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
+	// Set up logging before anything else logs
+	if err := logging.Setup(os.Stderr, o.logLevel, o.logFormat); err != nil {
+		fs.Usage()
+		log.Fatal(err)
+	}
 
 	// Create config with validation
-	conf, err := server.NewConfig(*listenPort, *jsonEndpoint, *refreshInterval)
+	conf, err := newConfig(o)
 	if err != nil {
 		// Show usage on validation error
-		flag.Usage()
-		log.Fatal(err)
+		fs.Usage()
+		slog.Error("invalid configuration", "err", err)
+		os.Exit(1)
+	}
+	if settings.ConfigFile != "" {
+		slog.Info("loaded config file", "component", "server", "path", settings.ConfigFile)
 	}
 
-	err = run(conf)
+	err = run(conf, reloadConfig(&o))
 	if err != nil {
-		log.Fatal(err)
+		slog.Error("server exited", "err", err)
+		os.Exit(1)
 	}
 }
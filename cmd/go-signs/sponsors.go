@@ -0,0 +1,68 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/kylerisse/go-signs/pkg/sponsor"
+)
+
+// runSponsors runs the sponsors subcommand with the arguments after it
+func runSponsors(args []string, out io.Writer) error {
+	if len(args) == 0 || args[0] != "import" {
+		return fmt.Errorf("usage: go-signs sponsors import [flags] <event-id>")
+	}
+	return runSponsorsImport(args[1:], out)
+}
+
+// runSponsorsImport imports the sponsors of an event from the SCaLE site and
+// prints a summary to out
+func runSponsorsImport(args []string, out io.Writer) error {
+	fs := flag.NewFlagSet("go-signs sponsors import", flag.ContinueOnError)
+	baseURL := fs.String("base-url", sponsor.DefaultImportBaseURL, "Site serving the sponsor feed and logos")
+	imagesDir := fs.String("images", "pkg/sponsor/images", "Directory to write the logos to, replacing the images in it")
+	manifest := fs.String("manifest", "pkg/sponsor/sponsors.json", "File to write the sponsor tiers to")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: go-signs sponsors import [flags] <event-id>")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return nil
+		}
+		return err
+	}
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return fmt.Errorf("expected one event ID, such as 23x")
+	}
+
+	importer := &sponsor.Importer{BaseURL: *baseURL}
+	result, err := importer.Import(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	if err := result.Write(*imagesDir, *manifest); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(out, "Imported %d sponsors for %s into %s\n", len(result.Images), fs.Arg(0), *imagesDir)
+	for _, t := range result.Manifest.Tiers {
+		names := make([]string, len(t.Sponsors))
+		for i, s := range t.Sponsors {
+			names[i] = s.Name
+		}
+		fmt.Fprintf(out, "  %s (%d): %s\n", t.Name, len(t.Sponsors), strings.Join(names, ", "))
+	}
+	if len(result.Skipped) > 0 {
+		fmt.Fprintf(out, "Skipped %d sponsors:\n", len(result.Skipped))
+		for _, s := range result.Skipped {
+			fmt.Fprintf(out, "  %s: %s\n", s.Sponsor, s.Reason)
+		}
+	}
+	fmt.Fprintf(out, "Wrote tiers to %s\n", *manifest)
+	return nil
+}
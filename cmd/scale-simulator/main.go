@@ -3,9 +3,11 @@ package main
 import (
 	"flag"
 	"log"
+	"log/slog"
 	"os"
 	"path/filepath"
 
+	"github.com/kylerisse/go-signs/pkg/logging"
 	"github.com/kylerisse/go-signs/pkg/simulator"
 )
 
@@ -13,24 +15,35 @@ func main() {
 	// Parse command line arguments
 	dbPath := flag.String("db", "./data/simulator.db", "Path to BoltDB database file")
 	port := flag.String("port", "2018", "Port to listen on")
+	logLevel := flag.String("log-level", "info", "Log level (debug, info, warn or error)")
+	logFormat := flag.String("log-format", "text", "Log format (text or json)")
 	flag.Parse()
 
+	// Set up logging before anything else logs
+	if err := logging.Setup(os.Stderr, *logLevel, *logFormat); err != nil {
+		flag.Usage()
+		log.Fatal(err)
+	}
+
 	// Ensure data directory exists
 	dataDir := filepath.Dir(*dbPath)
 	if _, err := os.Stat(dataDir); os.IsNotExist(err) {
 		if err := os.MkdirAll(dataDir, 0755); err != nil {
-			log.Fatalf("Failed to create data directory: %v", err)
+			slog.Error("failed to create data directory", "path", dataDir, "err", err)
+			os.Exit(1)
 		}
 	}
 
 	// Create and start the simulator server
 	server, err := simulator.NewServer(*dbPath, *port)
 	if err != nil {
-		log.Fatalf("Failed to create server: %v", err)
+		slog.Error("failed to create server", "err", err)
+		os.Exit(1)
 	}
 
-	log.Printf("simulator started with database %s on port %s", *dbPath, *port)
+	slog.Info("simulator started", "component", "simulator", "db", *dbPath, "port", *port)
 	if err := server.ListenAndServe(); err != nil {
-		log.Fatalf("Server error: %v", err)
+		slog.Error("server error", "err", err)
+		os.Exit(1)
 	}
 }
@@ -0,0 +1,269 @@
+package branding
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"image"
+	_ "image/gif"  // Register GIF format
+	_ "image/jpeg" // Register JPEG format
+	_ "image/png"  // Register PNG format
+	"log/slog"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pelletier/go-toml/v2"
+)
+
+// Path serves the branding as JSON, and AssetsPath/<file> the images it names
+const (
+	Path       = "/branding"
+	AssetsPath = "/branding/assets"
+)
+
+// logger returns the default logger tagged for this package
+func logger() *slog.Logger {
+	return slog.Default().With("component", "branding")
+}
+
+// Wifi is the network shown in the header
+type Wifi struct {
+	SSID     string `json:"ssid,omitempty" toml:"ssid"`
+	Password string `json:"password,omitempty" toml:"password"`
+	Image    string `json:"image,omitempty" toml:"image"` // Shown instead of the SSID and password, such as a QR code
+	ImageURL string `json:"imageUrl,omitempty" toml:"-"`
+}
+
+// Colors re-skin the display. Each is a #rgb or #rrggbb hex color.
+type Colors struct {
+	Primary    string `json:"primary" toml:"primary"`       // Room names and other highlights
+	Text       string `json:"text" toml:"text"`             // Text on light backgrounds
+	Panel      string `json:"panel" toml:"panel"`           // Behind the schedule, sponsors and clock
+	Accent     string `json:"accent" toml:"accent"`         // Session topics
+	Background string `json:"background" toml:"background"` // Behind the panels and header
+}
+
+// Branding is the event a sign is dressed for. Zero values mean "not set" so
+// a file only needs what differs from Default. Without a logo or Wi-Fi image
+// the display shows the ones it was built with.
+type Branding struct {
+	EventName string `json:"eventName" toml:"event-name"`
+	Logo      string `json:"logo,omitempty" toml:"logo"` // File in the assets directory
+	LogoURL   string `json:"logoUrl,omitempty" toml:"-"`
+	Wifi      Wifi   `json:"wifi" toml:"wifi"`
+	Colors    Colors `json:"colors" toml:"colors"`
+}
+
+// Default is the SCaLE branding the display was designed with
+func Default() Branding {
+	return Branding{
+		EventName: "SCaLE",
+		Colors: Colors{
+			Primary:    "#205493",
+			Text:       "#212121",
+			Panel:      "#aeb0b5",
+			Accent:     "#02bfe7",
+			Background: "#ffffff",
+		},
+	}
+}
+
+// Merge returns b with every field that is set in over replaced
+func (b Branding) Merge(over Branding) Branding {
+	set := func(dst *string, v string) {
+		if v != "" {
+			*dst = v
+		}
+	}
+	set(&b.EventName, over.EventName)
+	set(&b.Logo, over.Logo)
+	set(&b.Wifi.SSID, over.Wifi.SSID)
+	set(&b.Wifi.Password, over.Wifi.Password)
+	set(&b.Wifi.Image, over.Wifi.Image)
+	set(&b.Colors.Primary, over.Colors.Primary)
+	set(&b.Colors.Text, over.Colors.Text)
+	set(&b.Colors.Panel, over.Colors.Panel)
+	set(&b.Colors.Accent, over.Colors.Accent)
+	set(&b.Colors.Background, over.Colors.Background)
+	return b
+}
+
+// hexColor matches the colors the display accepts, which keeps anything but a
+// color out of its stylesheet
+var hexColor = regexp.MustCompile(`^#([0-9a-fA-F]{3}|[0-9a-fA-F]{6})$`)
+
+// Validate checks the colors and that any images are files in assetsDir that
+// decode as PNG, JPEG or GIF
+func (b Branding) Validate(assetsDir string) error {
+	for name, c := range map[string]string{
+		"primary":    b.Colors.Primary,
+		"text":       b.Colors.Text,
+		"panel":      b.Colors.Panel,
+		"accent":     b.Colors.Accent,
+		"background": b.Colors.Background,
+	} {
+		if c != "" && !hexColor.MatchString(c) {
+			return fmt.Errorf("color %s must be #rgb or #rrggbb, got %q", name, c)
+		}
+	}
+
+	for _, name := range []string{b.Logo, b.Wifi.Image} {
+		if name == "" {
+			continue
+		}
+		if name != path.Base(name) || strings.HasPrefix(name, ".") {
+			return fmt.Errorf("image %q must be a file name in the assets directory", name)
+		}
+		if err := validateImage(filepath.Join(assetsDir, name)); err != nil {
+			return fmt.Errorf("image %s: %w", name, err)
+		}
+	}
+
+	return nil
+}
+
+// validateImage checks that the file at path decodes as an image
+func validateImage(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	if _, _, err := image.DecodeConfig(f); err != nil {
+		return fmt.Errorf("not a PNG, JPEG or GIF image: %w", err)
+	}
+	return nil
+}
+
+// Store serves the branding from a TOML file, re-reading it whenever it
+// changes so a sign can be re-skinned without a restart, and the images it
+// names from an assets directory:
+//
+//	event-name = "SCaLE 23x"
+//	logo = "logo.png"
+//
+//	[wifi]
+//	ssid = "SCaLE"
+//
+//	[colors]
+//	primary = "#205493"
+type Store struct {
+	path      string
+	assetsDir string
+
+	mutex    sync.Mutex
+	branding Branding
+	modTime  time.Time
+}
+
+// Load reads the branding file at path, with images in assetsDir. An empty
+// path serves Default.
+func Load(path, assetsDir string) (*Store, error) {
+	s := &Store{path: path, assetsDir: assetsDir, branding: Default()}
+	if path == "" {
+		return s, nil
+	}
+	if err := s.load(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// load reads and validates the branding file
+func (s *Store) load() error {
+	info, err := os.Stat(s.path)
+	if err != nil {
+		return fmt.Errorf("unable to stat branding file: %w", err)
+	}
+
+	data, err := os.ReadFile(s.path)
+	if err != nil {
+		return fmt.Errorf("unable to read branding file: %w", err)
+	}
+
+	var over Branding
+	decoder := toml.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&over); err != nil {
+		return fmt.Errorf("unable to parse branding file %s: %w", s.path, err)
+	}
+
+	b := Default().Merge(over)
+	if err := b.Validate(s.assetsDir); err != nil {
+		return fmt.Errorf("invalid branding file %s: %w", s.path, err)
+	}
+
+	s.branding = b
+	s.modTime = info.ModTime()
+	return nil
+}
+
+// Branding returns the current branding with the URLs of its images
+func (s *Store) Branding() Branding {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.path != "" {
+		if info, err := os.Stat(s.path); err == nil && !info.ModTime().Equal(s.modTime) {
+			if err := s.load(); err != nil {
+				// Not retried until the file changes again, so each bad edit
+				// is logged once
+				s.modTime = info.ModTime()
+				logger().Error("unable to reload branding, keeping current", "path", s.path, "err", err)
+			} else {
+				logger().Info("reloaded branding", "path", s.path, "event", s.branding.EventName)
+			}
+		}
+	}
+
+	b := s.branding
+	if b.Logo != "" {
+		b.LogoURL = s.assetURL(b.Logo)
+	}
+	if b.Wifi.Image != "" {
+		b.Wifi.ImageURL = s.assetURL(b.Wifi.Image)
+	}
+	return b
+}
+
+// assetURL returns the URL of an image with its modification time, so the
+// display fetches it again when the file is replaced under the same name
+func (s *Store) assetURL(name string) string {
+	u := AssetsPath + "/" + name
+	if info, err := os.Stat(filepath.Join(s.assetsDir, name)); err == nil {
+		u += "?v=" + strconv.FormatInt(info.ModTime().UnixNano(), 36)
+	}
+	return u
+}
+
+// HandleBranding serves the current branding as JSON
+func (s *Store) HandleBranding(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-cache")
+	if err := json.NewEncoder(w).Encode(s.Branding()); err != nil {
+		logger().Error("unable to encode branding", "err", err)
+	}
+}
+
+// HandleAsset serves an image the branding names, by the last path element.
+// Nothing else in the assets directory is served.
+func (s *Store) HandleAsset(w http.ResponseWriter, r *http.Request) {
+	name := path.Base(r.URL.Path)
+	b := s.Branding()
+	if name != b.Logo && name != b.Wifi.Image {
+		http.NotFound(w, r)
+		return
+	}
+
+	// Revalidated so a replaced image shows on the next load
+	w.Header().Set("Cache-Control", "no-cache")
+	http.ServeFile(w, r, filepath.Join(s.assetsDir, name))
+}
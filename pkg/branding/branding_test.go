@@ -0,0 +1,221 @@
+package branding
+
+import (
+	"encoding/json"
+	"image"
+	"image/png"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+const testBranding = `
+event-name = "SCaLE 23x"
+logo = "logo.png"
+
+[wifi]
+ssid = "SCaLE"
+password = "linux"
+
+[colors]
+primary = "#123456"
+`
+
+// writeAssets writes a branding file and a logo to a temp directory
+func writeAssets(t *testing.T, content string) (string, string) {
+	t.Helper()
+	dir := t.TempDir()
+	path := filepath.Join(dir, "branding.toml")
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatalf("❌ unable to write branding: %v", err)
+	}
+
+	f, err := os.Create(filepath.Join(dir, "logo.png"))
+	if err != nil {
+		t.Fatalf("❌ unable to create logo: %v", err)
+	}
+	defer f.Close()
+	if err := png.Encode(f, image.NewRGBA(image.Rect(0, 0, 4, 4))); err != nil {
+		t.Fatalf("❌ unable to encode logo: %v", err)
+	}
+	return path, dir
+}
+
+func TestBrandingMerge(t *testing.T) {
+	base := Default()
+	merged := base.Merge(Branding{EventName: "Texas Linux Fest", Colors: Colors{Accent: "#ff0000"}})
+
+	if merged.EventName != "Texas Linux Fest" || merged.Colors.Accent != "#ff0000" {
+		t.Errorf("❌ Merge() did not apply set fields: %+v", merged)
+	}
+	if merged.Colors.Primary != base.Colors.Primary || merged.Colors.Panel != base.Colors.Panel {
+		t.Errorf("❌ Merge() replaced unset fields: %+v", merged)
+	} else {
+		t.Logf("✅ Merge() = %+v", merged)
+	}
+}
+
+func TestBrandingValidate(t *testing.T) {
+	_, dir := writeAssets(t, "")
+	if err := os.WriteFile(filepath.Join(dir, "notes.txt"), []byte("not an image"), 0o644); err != nil {
+		t.Fatalf("❌ unable to write notes: %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		branding Branding
+		wantErr  bool
+	}{
+		{"Default", Default(), false},
+		{"ShortColor", Branding{Colors: Colors{Text: "#fff"}}, false},
+		{"NamedColor", Branding{Colors: Colors{Text: "red"}}, true},
+		{"InjectedColor", Branding{Colors: Colors{Panel: "#fff;background:url(x)"}}, true},
+		{"Logo", Branding{Logo: "logo.png"}, false},
+		{"MissingLogo", Branding{Logo: "missing.png"}, true},
+		{"NotAnImage", Branding{Wifi: Wifi{Image: "notes.txt"}}, true},
+		{"Traversal", Branding{Logo: "../logo.png"}, true},
+		{"Hidden", Branding{Logo: ".logo.png"}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.branding.Validate(dir)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("❌ Validate() error = %v, wantErr %v", err, tt.wantErr)
+			} else {
+				t.Logf("✅ Validate() error = %v", err)
+			}
+		})
+	}
+}
+
+func TestLoad(t *testing.T) {
+	path, dir := writeAssets(t, testBranding)
+	store, err := Load(path, dir)
+	if err != nil {
+		t.Fatalf("❌ Load() unexpected error: %v", err)
+	}
+
+	b := store.Branding()
+	if b.EventName != "SCaLE 23x" || b.Wifi.SSID != "SCaLE" || b.Colors.Primary != "#123456" {
+		t.Errorf("❌ Branding() = %+v, want the file's settings", b)
+	}
+	if b.Colors.Text != Default().Colors.Text {
+		t.Errorf("❌ Branding() text color = %s, want default %s", b.Colors.Text, Default().Colors.Text)
+	}
+	if !strings.HasPrefix(b.LogoURL, AssetsPath+"/logo.png?v=") || b.Wifi.ImageURL != "" {
+		t.Errorf("❌ Branding() logo URL = %q, Wi-Fi image URL = %q", b.LogoURL, b.Wifi.ImageURL)
+	} else {
+		t.Logf("✅ Branding() = %+v", b)
+	}
+
+	if store, err := Load("", ""); err != nil || store.Branding() != Default() {
+		t.Errorf("❌ Load(\"\") = %v, want the default branding", err)
+	}
+
+	for name, content := range map[string]string{
+		"UnknownKey": "evnt-name = \"SCaLE\"\n",
+		"BadColor":   "[colors]\nprimary = \"blue\"\n",
+		"Malformed":  "event-name = \n",
+	} {
+		path, dir := writeAssets(t, content)
+		if _, err := Load(path, dir); err == nil {
+			t.Errorf("❌ Load() %s expected an error", name)
+		}
+	}
+}
+
+func TestReload(t *testing.T) {
+	path, dir := writeAssets(t, testBranding)
+	store, err := Load(path, dir)
+	if err != nil {
+		t.Fatalf("❌ Load() unexpected error: %v", err)
+	}
+
+	// A replaced logo gets a new URL so the display fetches it again
+	logoURL := store.Branding().LogoURL
+	later := time.Now().Add(time.Minute)
+	if err := os.Chtimes(filepath.Join(dir, "logo.png"), later, later); err != nil {
+		t.Fatalf("❌ unable to set modification time: %v", err)
+	}
+	if b := store.Branding(); b.LogoURL == logoURL {
+		t.Errorf("❌ Branding() logo URL after a replaced logo = %q, want a new one", b.LogoURL)
+	}
+
+	// A broken edit keeps the last good branding and is only tried once
+	if err := os.WriteFile(path, []byte("[colors]\nprimary = \"blue\"\n"), 0o644); err != nil {
+		t.Fatalf("❌ unable to write branding: %v", err)
+	}
+	broken := time.Now().Add(time.Second)
+	if err := os.Chtimes(path, time.Now(), broken); err != nil {
+		t.Fatalf("❌ unable to set modification time: %v", err)
+	}
+	if b := store.Branding(); b.EventName != "SCaLE 23x" {
+		t.Errorf("❌ Branding() after a broken edit = %q, want SCaLE 23x", b.EventName)
+	}
+	if !store.modTime.Equal(broken) {
+		t.Errorf("❌ broken edit at %s not remembered, got %s", broken, store.modTime)
+	}
+
+	if err := os.WriteFile(path, []byte("event-name = \"SCaLE 24x\"\n"), 0o644); err != nil {
+		t.Fatalf("❌ unable to write branding: %v", err)
+	}
+	if err := os.Chtimes(path, time.Now(), time.Now().Add(2*time.Second)); err != nil {
+		t.Fatalf("❌ unable to set modification time: %v", err)
+	}
+	if b := store.Branding(); b.EventName != "SCaLE 24x" || b.Logo != "" {
+		t.Errorf("❌ Branding() after an edit = %+v, want SCaLE 24x without a logo", b)
+	} else {
+		t.Logf("✅ Branding() reloaded %q", b.EventName)
+	}
+}
+
+func TestHandlers(t *testing.T) {
+	path, dir := writeAssets(t, testBranding)
+	if err := os.WriteFile(filepath.Join(dir, "secret.png"), []byte("not served"), 0o644); err != nil {
+		t.Fatalf("❌ unable to write image: %v", err)
+	}
+	store, err := Load(path, dir)
+	if err != nil {
+		t.Fatalf("❌ Load() unexpected error: %v", err)
+	}
+
+	rec := httptest.NewRecorder()
+	store.HandleBranding(rec, httptest.NewRequest(http.MethodGet, Path, nil))
+	var b Branding
+	if err := json.NewDecoder(rec.Body).Decode(&b); err != nil || b.EventName != "SCaLE 23x" {
+		t.Errorf("❌ HandleBranding() = %+v, %v", b, err)
+	}
+	if cc := rec.Header().Get("Cache-Control"); cc != "no-cache" {
+		t.Errorf("❌ HandleBranding() Cache-Control = %q, want no-cache", cc)
+	}
+
+	tests := []struct {
+		name string
+		path string
+		want int
+	}{
+		{"Logo", AssetsPath + "/logo.png", http.StatusOK},
+		{"NotNamed", AssetsPath + "/secret.png", http.StatusNotFound},
+		{"BrandingFile", AssetsPath + "/branding.toml", http.StatusNotFound},
+		{"Missing", AssetsPath + "/missing.png", http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			store.HandleAsset(rec, httptest.NewRequest(http.MethodGet, tt.path, nil))
+			if rec.Code != tt.want {
+				t.Errorf("❌ HandleAsset(%s) status = %d, want %d", tt.path, rec.Code, tt.want)
+			} else if tt.want == http.StatusOK && !strings.HasPrefix(rec.Header().Get("Content-Type"), "image/png") {
+				t.Errorf("❌ HandleAsset(%s) Content-Type = %q", tt.path, rec.Header().Get("Content-Type"))
+			} else {
+				t.Logf("✅ HandleAsset(%s) status = %d", tt.path, rec.Code)
+			}
+		})
+	}
+}
@@ -0,0 +1,80 @@
+package discovery
+
+import (
+	"html/template"
+	"net/http"
+	"time"
+)
+
+// AdminPath is where the server shows the peers it has found to people
+const AdminPath = "/admin/peers"
+
+var adminPage = template.Must(template.New("peers").Funcs(template.FuncMap{
+	"short": func(hash string) string {
+		if len(hash) > 12 {
+			return hash[:12]
+		}
+		return hash
+	},
+	"ago": func(t time.Time) string {
+		return time.Since(t).Round(time.Second).String()
+	},
+}).Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<meta http-equiv="refresh" content="10">
+<title>go-signs peers</title>
+<style>
+body { font-family: sans-serif; margin: 2em; color: #222; }
+table { border-collapse: collapse; }
+th, td { text-align: left; padding: 0.3em 1em; border-bottom: 1px solid #ddd; }
+th { background: #205493; color: #fff; }
+code { font-size: 0.9em; }
+.mismatch { color: #b00; }
+</style>
+</head>
+<body>
+<h1>go-signs peers</h1>
+{{with .Self}}<p>This instance is <b>{{.Instance}}</b> ({{.Role}}), serving schedule <code>{{short .ScheduleHash}}</code>.</p>{{end}}
+{{if .Peers}}
+<table>
+<tr><th>Instance</th><th>Role</th><th>Sign ID</th><th>Schedule</th><th>URL</th><th>Last seen</th></tr>
+{{range .Peers}}
+<tr>
+<td>{{.Instance}}</td>
+<td>{{.Role}}</td>
+<td>{{.SignID}}</td>
+<td><code{{if and $.Self (ne .ScheduleHash $.Self.ScheduleHash)}} class="mismatch"{{end}}>{{short .ScheduleHash}}</code></td>
+<td><a href="{{.URL}}">{{.URL}}</a></td>
+<td>{{ago .LastSeen}} ago</td>
+</tr>
+{{end}}
+</table>
+{{else}}
+<p>No peers found yet.{{if not .Browsing}} Browsing is disabled, start go-signs with -mdns-browse to look for peers.{{end}}</p>
+{{end}}
+</body>
+</html>
+`))
+
+// HandleAdmin shows this instance and the peers found on the network as a page
+// that refreshes itself
+func (r *Responder) HandleAdmin(w http.ResponseWriter, req *http.Request) {
+	data := struct {
+		Self     *Announcement
+		Peers    []Peer
+		Browsing bool
+	}{
+		Peers:    r.Peers(time.Now()),
+		Browsing: r.onChange != nil,
+	}
+	if a := r.currentAnnouncement(); a.Instance != "" {
+		data.Self = &a
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if err := adminPage.Execute(w, data); err != nil {
+		logger().Error("unable to render peers page", "err", err)
+	}
+}
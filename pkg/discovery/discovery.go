@@ -0,0 +1,465 @@
+package discovery
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"os"
+	"reflect"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"golang.org/x/net/ipv4"
+	"golang.org/x/sys/unix"
+)
+
+// DefaultGroup is the mDNS multicast group and port
+const DefaultGroup = "224.0.0.251:5353"
+
+// PeersPath is where the server lists the peers it has found
+const PeersPath = "/discovery/peers"
+
+// Roles an instance can advertise
+const (
+	RoleSign       = "sign"       // Displays the schedule
+	RoleHub        = "hub"        // Mirrors the feed for other signs
+	RoleController = "controller" // Collects heartbeats from other signs
+)
+
+// Peer is a go-signs instance found on the local network
+type Peer struct {
+	Instance     string    `json:"instance"`
+	SignID       string    `json:"signId"`
+	Role         string    `json:"role"`
+	ScheduleHash string    `json:"scheduleHash"`
+	Host         string    `json:"host"`
+	Addrs        []string  `json:"addrs"`
+	Port         int       `json:"port"`
+	TLS          bool      `json:"tls"`
+	URL          string    `json:"url"` // Base URL, at the address the peer answered from if it advertises it
+	LastSeen     time.Time `json:"lastSeen"`
+	Expires      time.Time `json:"expires"` // Forgotten after this unless it answers again
+}
+
+// Responder advertises this instance over multicast DNS and optionally
+// browses for other instances
+type Responder struct {
+	conn     *ipv4.PacketConn
+	group    *net.UDPAddr
+	ifi      *net.Interface
+	announce func() Announcement
+	onChange func([]Peer)
+
+	mutex   sync.Mutex
+	current Announcement // What we answer queries with, empty until Run
+	peers   map[string]Peer
+
+	checkInterval time.Duration // How often announce is polled for changes
+	queryInterval time.Duration // How often browsing asks for peers
+}
+
+// logger returns the default logger tagged for this package
+func logger() *slog.Logger {
+	return slog.Default().With("component", "discovery")
+}
+
+// Listen joins the multicast group, DefaultGroup unless testing, on the named
+// interface or on every multicast capable interface when ifname is empty
+func Listen(group string, ifname string) (*Responder, error) {
+	addr, err := net.ResolveUDPAddr("udp4", group)
+	if err != nil {
+		return nil, fmt.Errorf("invalid multicast group: %w", err)
+	}
+	if !addr.IP.IsMulticast() {
+		return nil, fmt.Errorf("invalid multicast group: %s is not a multicast address", addr.IP)
+	}
+
+	var ifi *net.Interface
+	if ifname != "" {
+		if ifi, err = net.InterfaceByName(ifname); err != nil {
+			return nil, fmt.Errorf("invalid interface: %w", err)
+		}
+	}
+
+	lc := net.ListenConfig{Control: reuseAddr}
+	c, err := lc.ListenPacket(context.Background(), "udp4", addr.String())
+	if err != nil {
+		return nil, fmt.Errorf("unable to listen on %s: %w", addr, err)
+	}
+
+	conn := ipv4.NewPacketConn(c)
+	if err := joinGroup(conn, ifi, addr); err != nil {
+		c.Close()
+		return nil, err
+	}
+	// Loopback lets instances on the same host find each other
+	conn.SetMulticastLoopback(true)
+	conn.SetMulticastTTL(255)
+
+	return &Responder{
+		conn:          conn,
+		group:         addr,
+		ifi:           ifi,
+		peers:         make(map[string]Peer),
+		checkInterval: 5 * time.Second,
+		queryInterval: 30 * time.Second,
+	}, nil
+}
+
+// reuseAddr lets other mDNS responders on this host share the port
+func reuseAddr(network string, address string, c syscall.RawConn) error {
+	var sockErr error
+	err := c.Control(func(fd uintptr) {
+		sockErr = unix.SetsockoptInt(int(fd), unix.SOL_SOCKET, unix.SO_REUSEADDR, 1)
+		if sockErr == nil {
+			sockErr = unix.SetsockoptInt(int(fd), unix.SOL_SOCKET, unix.SO_REUSEPORT, 1)
+		}
+	})
+	if err != nil {
+		return err
+	}
+	return sockErr
+}
+
+// joinGroup joins group on ifi, or on every multicast capable interface that is
+// up when ifi is nil
+func joinGroup(conn *ipv4.PacketConn, ifi *net.Interface, group *net.UDPAddr) error {
+	if ifi != nil {
+		if err := conn.JoinGroup(ifi, group); err != nil {
+			return fmt.Errorf("unable to join %s on %s: %w", group.IP, ifi.Name, err)
+		}
+		if err := conn.SetMulticastInterface(ifi); err != nil {
+			return fmt.Errorf("unable to send on %s: %w", ifi.Name, err)
+		}
+		return nil
+	}
+
+	ifaces, err := net.Interfaces()
+	if err != nil {
+		return fmt.Errorf("unable to list interfaces: %w", err)
+	}
+	joined := 0
+	for _, iface := range ifaces {
+		if iface.Flags&net.FlagUp == 0 || iface.Flags&net.FlagMulticast == 0 {
+			continue
+		}
+		if err := conn.JoinGroup(&iface, group); err != nil {
+			logger().Warn("unable to join multicast group", "interface", iface.Name, "err", err)
+			continue
+		}
+		joined++
+	}
+	if joined == 0 {
+		return fmt.Errorf("unable to join %s on any interface", group.IP)
+	}
+	return nil
+}
+
+// Advertise answers queries for this instance with what announce returns. It
+// is polled for changes, such as a new schedule hash, which are announced
+// straight away. Call it before Run.
+func (r *Responder) Advertise(announce func() Announcement) {
+	r.announce = announce
+}
+
+// Browse looks for other instances, calling onChange, if not nil, with every
+// known peer whenever one appears, changes or goes away. Call it before Run.
+func (r *Responder) Browse(onChange func([]Peer)) {
+	if onChange == nil {
+		onChange = func([]Peer) {}
+	}
+	r.onChange = onChange
+}
+
+// Run answers queries and browses until stop is closed, then says goodbye
+func (r *Responder) Run(stop <-chan struct{}) {
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		r.read()
+	}()
+
+	if r.announce != nil {
+		r.send(r.update(), recordTTL, r.group)
+	}
+	if r.onChange != nil {
+		r.query()
+	}
+
+	check := time.NewTicker(r.checkInterval)
+	defer check.Stop()
+	query := time.NewTicker(r.queryInterval)
+	defer query.Stop()
+
+	for {
+		select {
+		case <-stop:
+			if r.announce != nil {
+				r.send(r.currentAnnouncement(), 0, r.group)
+			}
+			r.conn.Close()
+			<-done
+			return
+		case <-check.C:
+			if r.announce != nil {
+				old := r.currentAnnouncement()
+				if a := r.update(); !reflect.DeepEqual(a, old) {
+					logger().Debug("announcement changed", "hash", a.ScheduleHash)
+					r.send(a, recordTTL, r.group)
+				}
+			}
+			if r.onChange != nil {
+				r.expire(time.Now())
+			}
+		case <-query.C:
+			if r.onChange != nil {
+				r.query()
+			}
+		}
+	}
+}
+
+// update refreshes the current announcement, filling in the host name and
+// addresses when announce leaves them out
+func (r *Responder) update() Announcement {
+	a := r.announce()
+	if a.Host == "" {
+		a.Host, _ = os.Hostname()
+		a.Host, _, _ = strings.Cut(a.Host, ".")
+	}
+	if a.Host == "" {
+		a.Host = a.Instance
+	}
+	if len(a.Addrs) == 0 {
+		a.Addrs = interfaceAddrs(r.ifi)
+	}
+
+	r.mutex.Lock()
+	r.current = a
+	r.mutex.Unlock()
+	return a
+}
+
+// currentAnnouncement returns what queries are being answered with
+func (r *Responder) currentAnnouncement() Announcement {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	return r.current
+}
+
+// interfaceAddrs returns the IPv4 addresses of ifi, or of every interface that
+// is up when ifi is nil, preferring addresses other than loopback
+func interfaceAddrs(ifi *net.Interface) []net.IP {
+	var ifaces []net.Interface
+	if ifi != nil {
+		ifaces = []net.Interface{*ifi}
+	} else {
+		ifaces, _ = net.Interfaces()
+	}
+
+	var addrs, loopback []net.IP
+	for _, iface := range ifaces {
+		if iface.Flags&net.FlagUp == 0 {
+			continue
+		}
+		ifAddrs, err := iface.Addrs()
+		if err != nil {
+			continue
+		}
+		for _, a := range ifAddrs {
+			ipnet, ok := a.(*net.IPNet)
+			if !ok || ipnet.IP.To4() == nil {
+				continue
+			}
+			if ipnet.IP.IsLoopback() {
+				loopback = append(loopback, ipnet.IP)
+			} else {
+				addrs = append(addrs, ipnet.IP)
+			}
+		}
+	}
+
+	if len(addrs) == 0 {
+		return loopback
+	}
+	return addrs
+}
+
+// send multicasts or unicasts the records for a
+func (r *Responder) send(a Announcement, ttl uint32, dst net.Addr) {
+	msg, err := buildResponse(a, ttl)
+	if err != nil {
+		logger().Error("unable to build mdns response", "err", err)
+		return
+	}
+	if _, err := r.conn.WriteTo(msg, nil, dst); err != nil {
+		logger().Warn("unable to send mdns response", "dst", dst.String(), "err", err)
+	}
+}
+
+// query asks every instance on the network to announce itself
+func (r *Responder) query() {
+	msg, err := buildQuery()
+	if err != nil {
+		logger().Error("unable to build mdns query", "err", err)
+		return
+	}
+	if _, err := r.conn.WriteTo(msg, nil, r.group); err != nil {
+		logger().Warn("unable to send mdns query", "err", err)
+	}
+}
+
+// read handles packets until the connection is closed
+func (r *Responder) read() {
+	buf := make([]byte, 9000)
+	for {
+		n, _, src, err := r.conn.ReadFrom(buf)
+		if err != nil {
+			return
+		}
+		udp, ok := src.(*net.UDPAddr)
+		if !ok {
+			continue
+		}
+		r.handle(buf[:n], udp, time.Now())
+	}
+}
+
+// handle answers a query about this instance or records peers from a response
+func (r *Responder) handle(msg []byte, src *net.UDPAddr, now time.Time) {
+	a := r.currentAnnouncement()
+
+	questions, err := parseQuestions(msg)
+	if err != nil {
+		logger().Debug("ignoring malformed mdns packet", "src", src.String(), "err", err)
+		return
+	}
+	if len(questions) > 0 {
+		if r.announce == nil || a.Instance == "" {
+			return
+		}
+		for _, q := range questions {
+			if a.answers(q.Name.String(), q.Type) {
+				// Queries from other ports are one-shot resolvers expecting a
+				// unicast answer, RFC 6762 6.7
+				dst := net.Addr(r.group)
+				if src.Port != r.group.Port {
+					dst = src
+				}
+				r.send(a, recordTTL, dst)
+				return
+			}
+		}
+		return
+	}
+
+	if r.onChange == nil {
+		return
+	}
+	peers, err := parseResponse(msg, now)
+	if err != nil {
+		logger().Debug("ignoring malformed mdns response", "src", src.String(), "err", err)
+		return
+	}
+	self := strings.ToLower(instanceLabel(a.Instance))
+	for _, p := range peers {
+		if r.announce != nil && strings.ToLower(p.Instance) == self {
+			continue
+		}
+		if p.Port == 0 {
+			continue
+		}
+		scheme := "http"
+		if p.TLS {
+			scheme = "https"
+		}
+		// Prefer the address the answer came from, unless the peer says it
+		// lives elsewhere
+		host := src.IP.String()
+		if len(p.Addrs) > 0 && !slices.Contains(p.Addrs, host) {
+			host = p.Addrs[0]
+		}
+		p.URL = scheme + "://" + net.JoinHostPort(host, strconv.Itoa(p.Port))
+		r.record(p, now)
+	}
+}
+
+// record stores a peer, or forgets it when it said goodbye
+func (r *Responder) record(p Peer, now time.Time) {
+	key := strings.ToLower(p.Instance)
+
+	r.mutex.Lock()
+	old, known := r.peers[key]
+	changed := false
+	switch {
+	case !p.Expires.After(now):
+		if known {
+			delete(r.peers, key)
+			logger().Info("peer left", "instance", p.Instance, "url", old.URL)
+			changed = true
+		}
+	default:
+		r.peers[key] = p
+		if !known {
+			logger().Info("peer found", "instance", p.Instance, "role", p.Role, "url", p.URL)
+		}
+		changed = !known || old.URL != p.URL || old.Role != p.Role ||
+			old.SignID != p.SignID || old.ScheduleHash != p.ScheduleHash
+	}
+	r.mutex.Unlock()
+
+	if changed {
+		r.onChange(r.Peers(now))
+	}
+}
+
+// expire forgets peers that have not answered within their TTL
+func (r *Responder) expire(now time.Time) {
+	r.mutex.Lock()
+	changed := false
+	for key, p := range r.peers {
+		if !p.Expires.After(now) {
+			delete(r.peers, key)
+			logger().Info("peer expired", "instance", p.Instance, "url", p.URL)
+			changed = true
+		}
+	}
+	r.mutex.Unlock()
+
+	if changed {
+		r.onChange(r.Peers(now))
+	}
+}
+
+// Peers returns the peers known as of now, sorted by instance name
+func (r *Responder) Peers(now time.Time) []Peer {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	peers := make([]Peer, 0, len(r.peers))
+	for _, p := range r.peers {
+		if p.Expires.After(now) {
+			peers = append(peers, p)
+		}
+	}
+	sort.Slice(peers, func(i, j int) bool {
+		return peers[i].Instance < peers[j].Instance
+	})
+	return peers
+}
+
+// HandlePeers lists the peers found on the network as JSON
+func (r *Responder) HandlePeers(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(r.Peers(time.Now())); err != nil {
+		logger().Error("unable to encode peers", "err", err)
+	}
+}
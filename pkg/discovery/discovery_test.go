@@ -0,0 +1,181 @@
+package discovery
+
+import (
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+func TestMessageRoundTrip(t *testing.T) {
+	now := time.Date(2025, 3, 6, 10, 0, 0, 0, time.UTC)
+	a := Announcement{
+		Instance:     "ballroom.a",
+		Host:         "sign-7",
+		Addrs:        []net.IP{net.ParseIP("10.0.0.7")},
+		Port:         2017,
+		TLS:          true,
+		SignID:       "ballroom.a",
+		Role:         RoleHub,
+		ScheduleHash: "abc123",
+	}
+
+	msg, err := buildResponse(a, recordTTL)
+	if err != nil {
+		t.Fatalf("❌ buildResponse() unexpected error: %v", err)
+	}
+	peers, err := parseResponse(msg, now)
+	if err != nil {
+		t.Fatalf("❌ parseResponse() unexpected error: %v", err)
+	}
+	if len(peers) != 1 {
+		t.Fatalf("❌ parseResponse() returned %d peers, want 1", len(peers))
+	}
+
+	p := peers[0]
+	if p.Instance != "ballroom-a" || p.SignID != "ballroom.a" || p.Role != RoleHub || p.ScheduleHash != "abc123" ||
+		p.Host != "sign-7.local" || p.Port != 2017 || !p.TLS || len(p.Addrs) != 1 || p.Addrs[0] != "10.0.0.7" {
+		t.Errorf("❌ unexpected peer: %+v", p)
+	} else {
+		t.Logf("✅ parsed %s at %s:%d", p.Instance, p.Addrs[0], p.Port)
+	}
+	if want := now.Add(recordTTL * time.Second); !p.Expires.Equal(want) {
+		t.Errorf("❌ Expires = %v, want %v", p.Expires, want)
+	}
+
+	// A goodbye expires straight away
+	msg, _ = buildResponse(a, 0)
+	peers, _ = parseResponse(msg, now)
+	if len(peers) != 1 || peers[0].Expires.After(now) {
+		t.Errorf("❌ goodbye should expire now, got %+v", peers)
+	}
+
+	// Queries carry questions and no peers
+	query, err := buildQuery()
+	if err != nil {
+		t.Fatalf("❌ buildQuery() unexpected error: %v", err)
+	}
+	if peers, _ := parseResponse(query, now); len(peers) != 0 {
+		t.Errorf("❌ parseResponse() of a query returned %d peers", len(peers))
+	}
+	questions, err := parseQuestions(query)
+	if err != nil || len(questions) != 1 {
+		t.Fatalf("❌ parseQuestions() = %v, %v, want one question", questions, err)
+	}
+	if !a.answers(questions[0].Name.String(), questions[0].Type) {
+		t.Errorf("❌ announcement does not answer %s", questions[0].Name)
+	}
+	if a.answers("_http._tcp.local.", dnsmessage.TypePTR) {
+		t.Errorf("❌ announcement answers another service")
+	}
+}
+
+// listenLoopback returns a responder on loopback multicast, skipping the test
+// where the loopback interface cannot do multicast
+func listenLoopback(t *testing.T, group string) *Responder {
+	t.Helper()
+	lo, err := net.InterfaceByName("lo")
+	if err != nil {
+		t.Skipf("no loopback interface: %v", err)
+	}
+	if lo.Flags&net.FlagMulticast == 0 {
+		t.Skip("loopback interface has multicast disabled, enable it with: ip link set lo multicast on")
+	}
+	r, err := Listen(group, lo.Name)
+	if err != nil {
+		t.Skipf("loopback multicast unavailable: %v", err)
+	}
+	r.checkInterval = 50 * time.Millisecond
+	r.queryInterval = 100 * time.Millisecond
+	return r
+}
+
+// waitForPeers polls r until it knows want peers or the deadline passes
+func waitForPeers(r *Responder, want int) []Peer {
+	deadline := time.Now().Add(3 * time.Second)
+	for {
+		peers := r.Peers(time.Now())
+		if len(peers) == want || time.Now().After(deadline) {
+			return peers
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+}
+
+func TestLoopbackDiscovery(t *testing.T) {
+	// A port of our own keeps the test away from any real mDNS responder
+	group := "224.0.0.251:25353"
+
+	var hash atomic.Value
+	hash.Store("aaa")
+	hub := listenLoopback(t, group)
+	hub.Advertise(func() Announcement {
+		return Announcement{Instance: "hub-1", SignID: "hub-1", Port: 2017, Role: RoleHub, ScheduleHash: hash.Load().(string)}
+	})
+	stopHub := make(chan struct{})
+	hubDone := make(chan struct{})
+	go func() {
+		hub.Run(stopHub)
+		close(hubDone)
+	}()
+
+	changes := make(chan []Peer, 16)
+	sign := listenLoopback(t, group)
+	sign.Advertise(func() Announcement {
+		return Announcement{Instance: "room-101", SignID: "room-101", Port: 2018, Role: RoleSign}
+	})
+	sign.Browse(func(peers []Peer) { changes <- peers })
+	stopSign := make(chan struct{})
+	defer close(stopSign)
+	go sign.Run(stopSign)
+
+	peers := waitForPeers(sign, 1)
+	if len(peers) != 1 {
+		t.Fatalf("❌ sign found %d peers, want the hub only", len(peers))
+	}
+	p := peers[0]
+	if p.Instance != "hub-1" || p.Role != RoleHub || p.ScheduleHash != "aaa" || p.URL != "http://127.0.0.1:2017" {
+		t.Errorf("❌ unexpected hub peer: %+v", p)
+	} else {
+		t.Logf("✅ sign found %s at %s", p.Instance, p.URL)
+	}
+
+	t.Run("AdminPage", func(t *testing.T) {
+		rr := httptest.NewRecorder()
+		sign.HandleAdmin(rr, httptest.NewRequest(http.MethodGet, AdminPath, nil))
+		if body := rr.Body.String(); !strings.Contains(body, "hub-1") || !strings.Contains(body, "room-101") {
+			t.Errorf("❌ admin page is missing the sign or the hub:\n%s", body)
+		}
+	})
+
+	t.Run("HashChangeAnnounced", func(t *testing.T) {
+		hash.Store("bbb")
+		deadline := time.After(3 * time.Second)
+		for {
+			select {
+			case peers := <-changes:
+				if len(peers) == 1 && peers[0].ScheduleHash == "bbb" {
+					t.Logf("✅ new schedule hash announced")
+					return
+				}
+			case <-deadline:
+				t.Fatalf("❌ sign never saw the new schedule hash")
+			}
+		}
+	})
+
+	t.Run("Goodbye", func(t *testing.T) {
+		close(stopHub)
+		<-hubDone
+		if peers := waitForPeers(sign, 0); len(peers) != 0 {
+			t.Errorf("❌ sign still knows %d peers after the hub said goodbye", len(peers))
+		} else {
+			t.Logf("✅ hub forgotten after goodbye")
+		}
+	})
+}
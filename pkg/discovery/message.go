@@ -0,0 +1,257 @@
+package discovery
+
+import (
+	"fmt"
+	"net"
+	"strings"
+	"time"
+
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+// ServiceType is the DNS-SD service type go-signs advertises
+const ServiceType = "_go-signs._tcp"
+
+// serviceName is the fully qualified name browsers query for
+const serviceName = ServiceType + ".local."
+
+// recordTTL is how long peers may cache our records, in seconds
+const recordTTL = 120
+
+// cacheFlush marks a record as replacing any cached copies, RFC 6762 10.2
+const cacheFlush = 1 << 15
+
+// TXT record keys
+const (
+	txtSignID = "id"
+	txtRole   = "role"
+	txtHash   = "hash"
+	txtTLS    = "tls"
+)
+
+// Announcement describes this instance on the local network
+type Announcement struct {
+	Instance     string // DNS-SD instance name, usually the sign ID
+	Host         string // Host name without .local
+	Addrs        []net.IP
+	Port         int
+	TLS          bool
+	SignID       string
+	Role         string
+	ScheduleHash string
+}
+
+// instanceLabel turns a name into a single DNS label
+func instanceLabel(name string) string {
+	label := strings.ReplaceAll(strings.TrimSpace(name), ".", "-")
+	if len(label) > 63 {
+		label = label[:63]
+	}
+	return label
+}
+
+// instanceName returns the fully qualified service instance name
+func (a Announcement) instanceName() string {
+	return instanceLabel(a.Instance) + "." + serviceName
+}
+
+// hostName returns the fully qualified host name
+func (a Announcement) hostName() string {
+	return instanceLabel(a.Host) + ".local."
+}
+
+// txt returns the TXT strings for the announcement
+func (a Announcement) txt() []string {
+	txt := []string{
+		txtSignID + "=" + a.SignID,
+		txtRole + "=" + a.Role,
+		txtHash + "=" + a.ScheduleHash,
+	}
+	if a.TLS {
+		txt = append(txt, txtTLS+"=1")
+	}
+	return txt
+}
+
+// answers reports whether a query for name and type is about this instance
+func (a Announcement) answers(name string, t dnsmessage.Type) bool {
+	switch {
+	case strings.EqualFold(name, serviceName):
+		return t == dnsmessage.TypePTR || t == dnsmessage.TypeALL
+	case strings.EqualFold(name, a.instanceName()):
+		return t == dnsmessage.TypeSRV || t == dnsmessage.TypeTXT || t == dnsmessage.TypeALL
+	case strings.EqualFold(name, a.hostName()):
+		return t == dnsmessage.TypeA || t == dnsmessage.TypeALL
+	}
+	return false
+}
+
+// buildQuery packs a query for every go-signs instance
+func buildQuery() ([]byte, error) {
+	b := dnsmessage.NewBuilder(nil, dnsmessage.Header{})
+	if err := b.StartQuestions(); err != nil {
+		return nil, err
+	}
+	if err := b.Question(dnsmessage.Question{
+		Name:  dnsmessage.MustNewName(serviceName),
+		Type:  dnsmessage.TypePTR,
+		Class: dnsmessage.ClassINET,
+	}); err != nil {
+		return nil, err
+	}
+	return b.Finish()
+}
+
+// buildResponse packs the PTR, SRV, TXT and A records for a. A ttl of zero
+// tells peers the instance is going away.
+func buildResponse(a Announcement, ttl uint32) ([]byte, error) {
+	instance, err := dnsmessage.NewName(a.instanceName())
+	if err != nil {
+		return nil, fmt.Errorf("invalid instance name %q: %w", a.Instance, err)
+	}
+	host, err := dnsmessage.NewName(a.hostName())
+	if err != nil {
+		return nil, fmt.Errorf("invalid host name %q: %w", a.Host, err)
+	}
+
+	unique := dnsmessage.ClassINET | cacheFlush
+	b := dnsmessage.NewBuilder(nil, dnsmessage.Header{Response: true, Authoritative: true})
+
+	if err := b.StartAnswers(); err != nil {
+		return nil, err
+	}
+	if err := b.PTRResource(
+		dnsmessage.ResourceHeader{Name: dnsmessage.MustNewName(serviceName), Class: dnsmessage.ClassINET, TTL: ttl},
+		dnsmessage.PTRResource{PTR: instance},
+	); err != nil {
+		return nil, err
+	}
+
+	if err := b.StartAdditionals(); err != nil {
+		return nil, err
+	}
+	if err := b.SRVResource(
+		dnsmessage.ResourceHeader{Name: instance, Class: unique, TTL: ttl},
+		dnsmessage.SRVResource{Port: uint16(a.Port), Target: host},
+	); err != nil {
+		return nil, err
+	}
+	if err := b.TXTResource(
+		dnsmessage.ResourceHeader{Name: instance, Class: unique, TTL: ttl},
+		dnsmessage.TXTResource{TXT: a.txt()},
+	); err != nil {
+		return nil, err
+	}
+	for _, ip := range a.Addrs {
+		ip4 := ip.To4()
+		if ip4 == nil {
+			continue
+		}
+		if err := b.AResource(
+			dnsmessage.ResourceHeader{Name: host, Class: unique, TTL: ttl},
+			dnsmessage.AResource{A: [4]byte(ip4)},
+		); err != nil {
+			return nil, err
+		}
+	}
+
+	return b.Finish()
+}
+
+// parseQuestions returns the questions of a query, or nil for a response
+func parseQuestions(msg []byte) ([]dnsmessage.Question, error) {
+	var p dnsmessage.Parser
+	h, err := p.Start(msg)
+	if err != nil {
+		return nil, err
+	}
+	if h.Response {
+		return nil, nil
+	}
+	return p.AllQuestions()
+}
+
+// parseResponse extracts the go-signs peers from a response received at now.
+// Peers with a zero TTL are returned with Expires set to now.
+func parseResponse(msg []byte, now time.Time) ([]Peer, error) {
+	var p dnsmessage.Parser
+	h, err := p.Start(msg)
+	if err != nil {
+		return nil, err
+	}
+	if !h.Response {
+		return nil, nil
+	}
+	if err := p.SkipAllQuestions(); err != nil {
+		return nil, err
+	}
+
+	var resources []dnsmessage.Resource
+	answers, err := p.AllAnswers()
+	if err != nil {
+		return nil, err
+	}
+	resources = append(resources, answers...)
+	if err := p.SkipAllAuthorities(); err != nil {
+		return nil, err
+	}
+	additionals, err := p.AllAdditionals()
+	if err != nil {
+		return nil, err
+	}
+	resources = append(resources, additionals...)
+
+	var instances []dnsmessage.Resource
+	srv := make(map[string]*dnsmessage.SRVResource)
+	txt := make(map[string]*dnsmessage.TXTResource)
+	addrs := make(map[string][]string)
+	for _, r := range resources {
+		name := strings.ToLower(r.Header.Name.String())
+		switch body := r.Body.(type) {
+		case *dnsmessage.PTRResource:
+			if name == strings.ToLower(serviceName) {
+				instances = append(instances, r)
+			}
+		case *dnsmessage.SRVResource:
+			srv[name] = body
+		case *dnsmessage.TXTResource:
+			txt[name] = body
+		case *dnsmessage.AResource:
+			addrs[name] = append(addrs[name], net.IP(body.A[:]).String())
+		}
+	}
+
+	peers := make([]Peer, 0, len(instances))
+	for _, r := range instances {
+		ptr := r.Body.(*dnsmessage.PTRResource)
+		name := strings.ToLower(ptr.PTR.String())
+		peer := Peer{
+			Instance: strings.TrimSuffix(ptr.PTR.String(), "."+serviceName),
+			LastSeen: now,
+			Expires:  now.Add(time.Duration(r.Header.TTL) * time.Second),
+		}
+		if s, ok := srv[name]; ok {
+			peer.Host = strings.TrimSuffix(s.Target.String(), ".")
+			peer.Port = int(s.Port)
+			peer.Addrs = addrs[strings.ToLower(s.Target.String())]
+		}
+		if t, ok := txt[name]; ok {
+			for _, kv := range t.TXT {
+				k, v, _ := strings.Cut(kv, "=")
+				switch k {
+				case txtSignID:
+					peer.SignID = v
+				case txtRole:
+					peer.Role = v
+				case txtHash:
+					peer.ScheduleHash = v
+				case txtTLS:
+					peer.TLS = v == "1"
+				}
+			}
+		}
+		peers = append(peers, peer)
+	}
+
+	return peers, nil
+}
@@ -1,10 +1,21 @@
 package display
 
 import (
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
 	"embed"
+	"encoding/hex"
+	"errors"
+	"fmt"
 	"io/fs"
-	"log"
+	"mime"
 	"net/http"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+	"time"
 )
 
 // Embed all files in the display folder
@@ -12,24 +23,218 @@ import (
 //go:embed dist/*
 var displayFS embed.FS
 
+// assetsDir holds the Vite build output whose file names carry a content hash
+const assetsDir = "assets/"
+
+// Cache-Control values. Hashed assets never change under the same name, while
+// everything else, index.html above all, must be revalidated after an upgrade.
+const (
+	immutableCache = "public, max-age=31536000, immutable"
+	revalidate     = "no-cache"
+)
+
+// compressible are the extensions worth gzipping
+var compressible = map[string]bool{
+	".html": true,
+	".js":   true,
+	".css":  true,
+	".svg":  true,
+	".json": true,
+	".map":  true,
+	".txt":  true,
+}
+
 // Handler returns an HTTP handler for serving display assets
-func Handler() http.Handler {
+func Handler() (http.Handler, error) {
 	// Create a sub filesystem rooted at "display"
 	displayDir, err := fs.Sub(displayFS, "dist")
 	if err != nil {
-		log.Fatal(err)
+		return nil, fmt.Errorf("unable to open embedded display: %w", err)
 	}
 
-	return http.FileServer(http.FS(displayDir))
+	h := newSPAHandler(displayDir)
+	// The embedded files never change, so their ETags and gzip variants are
+	// worked out once
+	if h.files, err = precompute(displayDir); err != nil {
+		return nil, err
+	}
+	return h, nil
+}
+
+// DirHandler returns an HTTP handler serving the display built into dir, such
+// as by npm run watch, instead of the embedded one. Files are read on every
+// request, so a rebuild shows on the next reload.
+func DirHandler(dir string) http.Handler {
+	return newSPAHandler(os.DirFS(dir))
 }
 
 // GetFS returns the display filesystem for use with web frameworks
-func GetFS() http.FileSystem {
+func GetFS() (http.FileSystem, error) {
 	// Create a sub filesystem rooted at "display"
 	displayDir, err := fs.Sub(displayFS, "dist")
 	if err != nil {
-		log.Fatal(err)
+		return nil, fmt.Errorf("unable to open embedded display: %w", err)
+	}
+
+	return http.FS(displayDir), nil
+}
+
+// file is what is served for one display file besides its content
+type file struct {
+	etag string
+	gz   []byte // Gzipped content, nil when it isn't worth compressing
+}
+
+// newFile hashes data for its ETag, gzipping it too when compress is set
+func newFile(name string, data []byte, compress bool) file {
+	sum := sha256.Sum256(data)
+	f := file{etag: `"` + hex.EncodeToString(sum[:8]) + `"`}
+	if !compress || !compressible[path.Ext(name)] {
+		return f
+	}
+
+	var buf bytes.Buffer
+	zw, _ := gzip.NewWriterLevel(&buf, gzip.BestCompression)
+	zw.Write(data)
+	zw.Close()
+	if buf.Len() < len(data) {
+		f.gz = buf.Bytes()
+	}
+	return f
+}
+
+// precompute returns the ETag and gzip variant of every file in fsys
+func precompute(fsys fs.FS) (map[string]file, error) {
+	files := make(map[string]file)
+	err := fs.WalkDir(fsys, ".", func(name string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		data, err := fs.ReadFile(fsys, name)
+		if err != nil {
+			return err
+		}
+		files[name] = newFile(name, data, true)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("unable to prepare display files: %w", err)
+	}
+	return files, nil
+}
+
+// spaHandler serves files, falling back to index.html for pages that aren't
+// files so client-side routes load the app rather than a 404
+type spaHandler struct {
+	fsys  fs.FS
+	files map[string]file // Precomputed for a file system that never changes, nil to read each request
+	dirs  http.Handler
+}
+
+// newSPAHandler serves the display in fsys
+func newSPAHandler(fsys fs.FS) *spaHandler {
+	return &spaHandler{fsys: fsys, dirs: http.FileServer(http.FS(fsys))}
+}
+
+// lookup returns the ETag and any gzip variant of name
+func (h *spaHandler) lookup(name string) (file, bool) {
+	if h.files != nil {
+		f, ok := h.files[name]
+		return f, ok
+	}
+	data, err := fs.ReadFile(h.fsys, name)
+	if err != nil {
+		return file{}, false
+	}
+	return newFile(name, data, false), true
+}
+
+// ServeHTTP implements http.Handler
+func (h *spaHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	name := strings.TrimPrefix(path.Clean("/"+r.URL.Path), "/")
+	if name == "" {
+		name = "index.html"
+	}
+
+	info, err := fs.Stat(h.fsys, name)
+	if errors.Is(err, fs.ErrNotExist) && isPageRequest(r, name) {
+		name = "index.html"
+	} else if err != nil || info.IsDir() {
+		h.dirs.ServeHTTP(w, r)
+		return
+	}
+
+	f, ok := h.lookup(name)
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+
+	header := w.Header()
+	if strings.HasPrefix(name, assetsDir) {
+		header.Set("Cache-Control", immutableCache)
+	} else {
+		header.Set("Cache-Control", revalidate)
+	}
+
+	if f.gz == nil {
+		header.Set("ETag", f.etag)
+		http.ServeFileFS(w, r, h.fsys, name)
+		return
 	}
 
-	return http.FS(displayDir)
+	// Caches must keep the plain and gzipped variants apart
+	header.Add("Vary", "Accept-Encoding")
+	if !acceptsGzip(r) {
+		header.Set("ETag", f.etag)
+		http.ServeFileFS(w, r, h.fsys, name)
+		return
+	}
+	header.Set("ETag", strings.TrimSuffix(f.etag, `"`)+`-gzip"`)
+	header.Set("Content-Encoding", "gzip")
+	if ctype := mime.TypeByExtension(path.Ext(name)); ctype != "" {
+		header.Set("Content-Type", ctype)
+	}
+	http.ServeContent(w, r, name, time.Time{}, bytes.NewReader(f.gz))
+}
+
+// acceptsGzip reports whether the client takes gzipped responses. An explicit
+// gzip entry wins over *, wherever either appears in the header.
+func acceptsGzip(r *http.Request) bool {
+	var gzipSeen, gzipOK, starSeen, starOK bool
+	for _, coding := range strings.Split(r.Header.Get("Accept-Encoding"), ",") {
+		name, params, _ := strings.Cut(coding, ";")
+		switch strings.ToLower(strings.TrimSpace(name)) {
+		case "gzip", "x-gzip":
+			gzipSeen, gzipOK = true, acceptable(params)
+		case "*":
+			starSeen, starOK = true, acceptable(params)
+		}
+	}
+	if gzipSeen {
+		return gzipOK
+	}
+	return starSeen && starOK
+}
+
+// acceptable reports whether the parameters of an Accept-Encoding entry leave
+// its quality above zero
+func acceptable(params string) bool {
+	for _, param := range strings.Split(params, ";") {
+		key, value, _ := strings.Cut(strings.TrimSpace(param), "=")
+		if strings.EqualFold(key, "q") {
+			q, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+			return err == nil && q > 0
+		}
+	}
+	return true
+}
+
+// isPageRequest reports whether the request is a browser loading a page, as
+// opposed to a script or image or an API call to a path that doesn't exist
+func isPageRequest(r *http.Request, name string) bool {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		return false
+	}
+	return path.Ext(name) == "" && strings.Contains(r.Header.Get("Accept"), "text/html")
 }
@@ -0,0 +1,186 @@
+package display
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func TestHandler(t *testing.T) {
+	handler, err := Handler()
+	if err != nil {
+		t.Fatalf("❌ Handler() unexpected error: %v", err)
+	}
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/", nil))
+	if rr.Code != http.StatusOK {
+		t.Errorf("❌ Embedded display = %d, want 200", rr.Code)
+	} else {
+		t.Logf("✅ Embedded display served")
+	}
+}
+
+func TestDirHandler(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "index.html"), []byte("<html>display</html>"), 0600); err != nil {
+		t.Fatalf("❌ Failed to write fixture: %v", err)
+	}
+	if err := os.Mkdir(filepath.Join(dir, "assets"), 0700); err != nil {
+		t.Fatalf("❌ Failed to create fixture directory: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "assets", "app.js"), []byte("console.log('display')"), 0600); err != nil {
+		t.Fatalf("❌ Failed to write fixture: %v", err)
+	}
+	handler := DirHandler(dir)
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		accept string
+		code   int
+		body   string
+	}{
+		{name: "Index", method: http.MethodGet, path: "/", accept: "text/html", code: http.StatusOK, body: "display</html>"},
+		{name: "Asset", method: http.MethodGet, path: "/assets/app.js", accept: "*/*", code: http.StatusOK, body: "console.log"},
+		{name: "Client-side route", method: http.MethodGet, path: "/room/101", accept: "text/html,application/xhtml+xml", code: http.StatusOK, body: "display</html>"},
+		{name: "Missing asset", method: http.MethodGet, path: "/assets/missing.js", accept: "text/html", code: http.StatusNotFound},
+		{name: "Missing API path", method: http.MethodGet, path: "/sponsors/gold/extra", accept: "*/*", code: http.StatusNotFound},
+		{name: "Post to a route", method: http.MethodPost, path: "/room/101", accept: "text/html", code: http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.path, nil)
+			req.Header.Set("Accept", tt.accept)
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+
+			if rr.Code != tt.code {
+				t.Fatalf("❌ %s %s = %d, want %d", tt.method, tt.path, rr.Code, tt.code)
+			}
+			if !strings.Contains(rr.Body.String(), tt.body) {
+				t.Errorf("❌ %s %s body = %q, want it to contain %q", tt.method, tt.path, rr.Body.String(), tt.body)
+			}
+		})
+	}
+
+	// A rebuild shows without a restart
+	if err := os.WriteFile(filepath.Join(dir, "index.html"), []byte("<html>rebuilt</html>"), 0600); err != nil {
+		t.Fatalf("❌ Failed to write fixture: %v", err)
+	}
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+	if !strings.Contains(rr.Body.String(), "rebuilt") {
+		t.Errorf("❌ Rebuilt display not served: %q", rr.Body.String())
+	} else {
+		t.Logf("✅ Display served from disk with client-side routes")
+	}
+}
+
+func TestCacheHeaders(t *testing.T) {
+	script := []byte(strings.Repeat("console.log('display');\n", 200))
+	fsys := fstest.MapFS{
+		"index.html":               {Data: []byte(`<html><script src="/assets/index-Ab12Cd34.js"></script></html>`)},
+		"assets/index-Ab12Cd34.js": {Data: script},
+		"assets/logo-Ef56Gh78.png": {Data: []byte("\x89PNG not really")},
+	}
+	h := newSPAHandler(fsys)
+	files, err := precompute(fsys)
+	if err != nil {
+		t.Fatalf("❌ precompute() unexpected error: %v", err)
+	}
+	h.files = files
+
+	get := func(path string, header http.Header) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		req.Header = header
+		rr := httptest.NewRecorder()
+		h.ServeHTTP(rr, req)
+		return rr
+	}
+
+	index := get("/", http.Header{"Accept": {"text/html"}})
+	etag := index.Header().Get("ETag")
+	if index.Code != http.StatusOK || index.Header().Get("Cache-Control") != revalidate || etag == "" {
+		t.Fatalf("❌ / = %d, Cache-Control %q, ETag %q, want 200, no-cache and an ETag", index.Code, index.Header().Get("Cache-Control"), etag)
+	}
+	if rr := get("/room/101", http.Header{"Accept": {"text/html"}}); rr.Header().Get("ETag") != etag || rr.Header().Get("Cache-Control") != revalidate {
+		t.Errorf("❌ Client-side route headers = %v, want those of index.html", rr.Header())
+	}
+	if rr := get("/", http.Header{"Accept": {"text/html"}, "If-None-Match": {etag}}); rr.Code != http.StatusNotModified {
+		t.Errorf("❌ Revalidated index.html = %d, want 304", rr.Code)
+	}
+
+	plain := get("/assets/index-Ab12Cd34.js", http.Header{})
+	if plain.Header().Get("Cache-Control") != immutableCache || plain.Header().Get("Content-Encoding") != "" || plain.Body.Len() != len(script) {
+		t.Errorf("❌ Plain asset headers = %v, %d bytes, want immutable and not encoded", plain.Header(), plain.Body.Len())
+	}
+	if plain.Header().Get("Vary") != "Accept-Encoding" {
+		t.Errorf("❌ Asset with a gzip variant doesn't vary on Accept-Encoding")
+	}
+
+	gz := get("/assets/index-Ab12Cd34.js", http.Header{"Accept-Encoding": {"br, gzip"}})
+	if gz.Header().Get("Content-Encoding") != "gzip" || gz.Header().Get("ETag") == plain.Header().Get("ETag") {
+		t.Fatalf("❌ Gzipped asset headers = %v, want gzip with its own ETag", gz.Header())
+	}
+	if !strings.HasPrefix(gz.Header().Get("Content-Type"), "text/javascript") {
+		t.Errorf("❌ Gzipped asset Content-Type = %q, want text/javascript", gz.Header().Get("Content-Type"))
+	}
+	zr, err := gzip.NewReader(gz.Body)
+	if err != nil {
+		t.Fatalf("❌ Gzipped asset doesn't decompress: %v", err)
+	}
+	if data, _ := io.ReadAll(zr); !bytes.Equal(data, script) {
+		t.Errorf("❌ Gzipped asset doesn't decompress to the script")
+	}
+	if rr := get("/assets/index-Ab12Cd34.js", http.Header{"Accept-Encoding": {"gzip;q=0"}}); rr.Header().Get("Content-Encoding") != "" {
+		t.Errorf("❌ Gzip refused with q=0 but sent anyway")
+	}
+
+	if rr := get("/assets/logo-Ef56Gh78.png", http.Header{"Accept-Encoding": {"gzip"}}); rr.Header().Get("Content-Encoding") != "" || rr.Header().Get("Cache-Control") != immutableCache {
+		t.Errorf("❌ Image headers = %v, want immutable and not encoded", rr.Header())
+	}
+	if rr := get("/assets/missing-00000000.js", http.Header{}); rr.Code != http.StatusNotFound || rr.Header().Get("Cache-Control") == immutableCache {
+		t.Errorf("❌ Missing asset = %d with %v, want an uncached 404", rr.Code, rr.Header())
+	} else {
+		t.Logf("✅ Hashed assets immutable and gzipped, index.html revalidated")
+	}
+}
+
+func TestAcceptsGzip(t *testing.T) {
+	tests := []struct {
+		header string
+		want   bool
+	}{
+		{"", false},
+		{"gzip", true},
+		{"br, gzip", true},
+		{"br", false},
+		{"*", true},
+		{"gzip;q=0", false},
+		{"gzip; q=0.000", false},
+		{"gzip;q=0.5", true},
+		{"*;q=0, gzip", true},
+		{"gzip, *;q=0", true},
+		{"*, gzip;q=0", false},
+		{"gzip;q=0, *", false},
+		{"br;q=1.0, *;q=0", false},
+	}
+
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set("Accept-Encoding", tt.header)
+		if got := acceptsGzip(req); got != tt.want {
+			t.Errorf("❌ acceptsGzip(%q) = %v, want %v", tt.header, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,203 @@
+package fleet
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+)
+
+// CommandsPath is where the controller accepts commands and serves the audit log
+const CommandsPath = "/fleet/commands"
+
+// Commands a sign understands. Refresh runs on the sign itself, the others are
+// passed on to its display.
+const (
+	CommandRefresh    = "refresh"     // Fetch the schedule now
+	CommandReload     = "reload"      // Reload the display page
+	CommandIdentify   = "identify"    // Show the sign ID over the display
+	CommandClearCache = "clear-cache" // Clear the display's caches and reload
+)
+
+// CommandNames lists every valid command
+var CommandNames = []string{CommandRefresh, CommandReload, CommandIdentify, CommandClearCache}
+
+// Command statuses, in the order a command moves through them
+const (
+	StatusPending   = "pending"   // Waiting for the sign's next heartbeat
+	StatusDelivered = "delivered" // Sent in a heartbeat reply, not yet acknowledged
+	StatusDone      = "done"      // Acknowledged as carried out
+	StatusFailed    = "failed"    // Acknowledged as failed
+	StatusExpired   = "expired"   // Not acknowledged in time
+)
+
+// Command is an instruction for one sign
+type Command struct {
+	ID      string    `json:"id"`
+	SignID  string    `json:"signId"`
+	Name    string    `json:"name"`
+	Created time.Time `json:"created"`
+}
+
+// Ack reports the outcome of a command back to the controller
+type Ack struct {
+	ID     string `json:"id"`
+	Status string `json:"status"` // StatusDone or StatusFailed
+	Error  string `json:"error,omitempty"`
+}
+
+// CommandRecord is the audit log entry for a command
+type CommandRecord struct {
+	Command
+	RequestedBy string     `json:"requestedBy"` // Client IP of the admin request
+	Status      string     `json:"status"`
+	Delivered   *time.Time `json:"delivered,omitempty"` // First delivery
+	Acked       *time.Time `json:"acked,omitempty"`
+	Error       string     `json:"error,omitempty"`
+}
+
+// CommandQueue holds commands until their sign acknowledges them, and keeps an
+// audit log of the most recent ones
+type CommandQueue struct {
+	mutex   sync.Mutex
+	records []*CommandRecord // Oldest first
+	ttl     time.Duration
+	limit   int
+}
+
+// NewCommandQueue produces a CommandQueue that expires commands not
+// acknowledged within ttl and keeps at most limit records
+func NewCommandQueue(ttl time.Duration, limit int) *CommandQueue {
+	return &CommandQueue{
+		ttl:   ttl,
+		limit: limit,
+	}
+}
+
+// newCommandID returns a random command ID
+func newCommandID() string {
+	b := make([]byte, 8)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// Enqueue adds a command for a sign
+func (q *CommandQueue) Enqueue(signID string, name string, requestedBy string, now time.Time) (Command, error) {
+	if signID == "" {
+		return Command{}, fmt.Errorf("command is missing signId")
+	}
+	if !slices.Contains(CommandNames, name) {
+		return Command{}, fmt.Errorf("command must be one of %v, got %q", CommandNames, name)
+	}
+
+	cmd := Command{
+		ID:      newCommandID(),
+		SignID:  signID,
+		Name:    name,
+		Created: now,
+	}
+
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	q.records = append(q.records, &CommandRecord{
+		Command:     cmd,
+		RequestedBy: requestedBy,
+		Status:      StatusPending,
+	})
+	if len(q.records) > q.limit {
+		q.records = slices.Clone(q.records[len(q.records)-q.limit:])
+	}
+
+	logger().Info("command queued", "id", cmd.ID, "sign", signID, "command", name, "client", requestedBy)
+	return cmd, nil
+}
+
+// Deliver returns every unacknowledged command for a sign, marking them
+// delivered. Commands are delivered again until acknowledged, so a lost reply
+// doesn't lose them; signs ignore repeats.
+func (q *CommandQueue) Deliver(signID string, now time.Time) []Command {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	var cmds []Command
+	for _, r := range q.records {
+		if r.SignID != signID || (r.Status != StatusPending && r.Status != StatusDelivered) {
+			continue
+		}
+
+		if now.Sub(r.Created) > q.ttl {
+			r.Status = StatusExpired
+			logger().Warn("command expired", "id", r.ID, "sign", r.SignID, "command", r.Name)
+			continue
+		}
+
+		if r.Status == StatusPending {
+			r.Status = StatusDelivered
+			delivered := now
+			r.Delivered = &delivered
+			logger().Info("command delivered", "id", r.ID, "sign", r.SignID, "command", r.Name)
+		}
+		cmds = append(cmds, r.Command)
+	}
+	return cmds
+}
+
+// Acknowledge records the outcome a sign reported for its commands
+func (q *CommandQueue) Acknowledge(signID string, acks []Ack, now time.Time) {
+	if len(acks) == 0 {
+		return
+	}
+
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	for _, ack := range acks {
+		i := slices.IndexFunc(q.records, func(r *CommandRecord) bool {
+			return r.ID == ack.ID && r.SignID == signID
+		})
+		if i < 0 {
+			logger().Warn("acknowledgement for unknown command", "id", ack.ID, "sign", signID)
+			continue
+		}
+
+		r := q.records[i]
+		if r.Acked != nil {
+			// Repeated acknowledgement
+			continue
+		}
+
+		r.Status = StatusDone
+		if ack.Status == StatusFailed {
+			r.Status = StatusFailed
+			r.Error = ack.Error
+		}
+		acked := now
+		r.Acked = &acked
+
+		if r.Status == StatusFailed {
+			logger().Warn("command failed", "id", r.ID, "sign", signID, "command", r.Name, "err", r.Error)
+		} else {
+			logger().Info("command acknowledged", "id", r.ID, "sign", signID, "command", r.Name)
+		}
+	}
+}
+
+// Log returns the audit log, oldest first, optionally only for one sign
+func (q *CommandQueue) Log(signID string, now time.Time) []CommandRecord {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	log := make([]CommandRecord, 0, len(q.records))
+	for _, r := range q.records {
+		if (r.Status == StatusPending || r.Status == StatusDelivered) && now.Sub(r.Created) > q.ttl {
+			r.Status = StatusExpired
+		}
+		if signID == "" || r.SignID == signID {
+			log = append(log, *r)
+		}
+	}
+	return log
+}
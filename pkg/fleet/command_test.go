@@ -0,0 +1,154 @@
+package fleet
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCommandQueue(t *testing.T) {
+	now := time.Date(2025, 3, 6, 10, 0, 0, 0, time.UTC)
+	q := NewCommandQueue(time.Hour, 3)
+
+	if _, err := q.Enqueue("ballroom-a", "reboot", "10.0.0.1", now); err == nil {
+		t.Errorf("❌ Enqueue(reboot) expected error, got nil")
+	}
+	if _, err := q.Enqueue("", CommandReload, "10.0.0.1", now); err == nil {
+		t.Errorf("❌ Enqueue() without sign ID expected error, got nil")
+	}
+
+	refresh, _ := q.Enqueue("ballroom-a", CommandRefresh, "10.0.0.1", now)
+	reload, _ := q.Enqueue("ballroom-a", CommandReload, "10.0.0.1", now)
+	q.Enqueue("room-101", CommandIdentify, "10.0.0.1", now)
+
+	// Delivered until acknowledged
+	for range 2 {
+		if cmds := q.Deliver("ballroom-a", now.Add(time.Minute)); len(cmds) != 2 {
+			t.Fatalf("❌ Deliver() returned %d commands, want 2", len(cmds))
+		}
+	}
+
+	q.Acknowledge("ballroom-a", []Ack{
+		{ID: refresh.ID, Status: StatusDone},
+		{ID: reload.ID, Status: StatusFailed, Error: "no display"},
+	}, now.Add(2*time.Minute))
+	// Acknowledgements for another sign's commands are ignored
+	q.Acknowledge("ballroom-b", []Ack{{ID: refresh.ID, Status: StatusFailed}}, now.Add(2*time.Minute))
+
+	if cmds := q.Deliver("ballroom-a", now.Add(3*time.Minute)); len(cmds) != 0 {
+		t.Errorf("❌ Deliver() after ack returned %d commands, want 0", len(cmds))
+	}
+
+	want := map[string]string{
+		CommandRefresh:  StatusDone,
+		CommandReload:   StatusFailed,
+		CommandIdentify: StatusExpired,
+	}
+	log := q.Log("", now.Add(2*time.Hour))
+	for _, r := range log {
+		if r.Status != want[r.Name] {
+			t.Errorf("❌ %s status = %s, want %s", r.Name, r.Status, want[r.Name])
+		} else {
+			t.Logf("✅ %s for %s is %s", r.Name, r.SignID, r.Status)
+		}
+	}
+	if log[0].Delivered == nil || log[0].Acked == nil || log[1].Error != "no display" || log[2].Delivered != nil {
+		t.Errorf("❌ unexpected audit log: %+v", log)
+	}
+
+	if got := q.Log("room-101", now); len(got) != 1 {
+		t.Errorf("❌ Log(room-101) returned %d records, want 1", len(got))
+	}
+
+	// The log is bounded
+	q.Enqueue("room-101", CommandReload, "10.0.0.1", now)
+	if got := q.Log("", now); len(got) != 3 || got[0].ID == refresh.ID {
+		t.Errorf("❌ Log() kept %d records, want the newest 3", len(got))
+	}
+}
+
+func TestControllerCommands(t *testing.T) {
+	controller := NewController(NewRegistry(time.Minute, nil), testToken)
+	controller.SetAdminToken(testAdminToken)
+	for _, id := range []string{"ballroom-a", "room-101"} {
+		if err := controller.registry.Record(Heartbeat{SignID: id}, time.Now()); err != nil {
+			t.Fatalf("❌ Record(%s) unexpected error: %v", id, err)
+		}
+	}
+
+	request := func(method string, token string, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, CommandsPath, strings.NewReader(body))
+		req.Header.Set("Authorization", "Bearer "+token)
+		rr := httptest.NewRecorder()
+		controller.HandleCommands(rr, req)
+		return rr
+	}
+
+	if rr := request(http.MethodPost, "wrong-token-value", `{"signId":"*","command":"reload"}`); rr.Code != http.StatusUnauthorized {
+		t.Errorf("❌ command with wrong token returned %d, want 401", rr.Code)
+	}
+	if rr := request(http.MethodPost, testToken, `{"signId":"*","command":"reload"}`); rr.Code != http.StatusUnauthorized {
+		t.Errorf("❌ command with the fleet token returned %d, want 401", rr.Code)
+	}
+	if rr := request(http.MethodPost, testAdminToken, `{"signId":"*","command":"reboot"}`); rr.Code != http.StatusBadRequest {
+		t.Errorf("❌ unknown command returned %d, want 400", rr.Code)
+	}
+
+	rr := request(http.MethodPost, testAdminToken, `{"signId":"*","command":"identify"}`)
+	if rr.Code != http.StatusAccepted {
+		t.Fatalf("❌ command for all signs returned %d, want 202", rr.Code)
+	}
+	var cmds []Command
+	if err := json.Unmarshal(rr.Body.Bytes(), &cmds); err != nil || len(cmds) != 2 {
+		t.Fatalf("❌ queued %d commands (%v), want 2", len(cmds), err)
+	}
+
+	// The next heartbeat carries the command and the one after acknowledges it
+	heartbeat := func(body string) HeartbeatReply {
+		req := httptest.NewRequest(http.MethodPost, HeartbeatPath, strings.NewReader(body))
+		req.Header.Set("Authorization", "Bearer "+testToken)
+		rr := httptest.NewRecorder()
+		controller.HandleHeartbeat(rr, req)
+
+		var reply HeartbeatReply
+		if rr.Code == http.StatusOK {
+			if err := json.Unmarshal(rr.Body.Bytes(), &reply); err != nil {
+				t.Fatalf("❌ unable to decode heartbeat reply: %v", err)
+			}
+		}
+		return reply
+	}
+	reply := heartbeat(`{"signId":"room-101"}`)
+	if len(reply.Commands) != 1 || reply.Commands[0].Name != CommandIdentify {
+		t.Fatalf("❌ heartbeat reply commands = %+v", reply.Commands)
+	}
+	ack := `{"signId":"room-101","acks":[{"id":"` + reply.Commands[0].ID + `","status":"done"}]}`
+	if reply := heartbeat(ack); len(reply.Commands) != 0 {
+		t.Errorf("❌ acknowledged command delivered again: %+v", reply.Commands)
+	}
+
+	rr = request(http.MethodGet, testAdminToken, "")
+	var log []CommandRecord
+	if err := json.Unmarshal(rr.Body.Bytes(), &log); err != nil {
+		t.Fatalf("❌ unable to decode command log: %v", err)
+	}
+	statuses := map[string]string{}
+	for _, r := range log {
+		statuses[r.SignID] = r.Status
+	}
+	if statuses["room-101"] != StatusDone || statuses["ballroom-a"] != StatusPending {
+		t.Errorf("❌ command statuses = %v", statuses)
+	} else {
+		t.Logf("✅ command statuses = %v", statuses)
+	}
+
+	// Heartbeats don't record acknowledgements with the sign
+	for _, s := range controller.registry.Signs(time.Now()) {
+		if len(s.Acks) != 0 {
+			t.Errorf("❌ sign %s recorded acks %+v", s.SignID, s.Acks)
+		}
+	}
+}
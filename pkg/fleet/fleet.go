@@ -0,0 +1,358 @@
+package fleet
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// HeartbeatPath is where the controller accepts heartbeats
+const HeartbeatPath = "/fleet/heartbeat"
+
+// SignsPath is where the controller lists known signs
+const SignsPath = "/fleet/signs"
+
+// Heartbeat is the status a sign reports to the controller
+type Heartbeat struct {
+	SignID         string `json:"signId"`
+	Version        string `json:"version"`
+	ScheduleHash   string `json:"scheduleHash"`
+	LastUpdateTime string `json:"lastUpdateTime"` // When the sign last changed its schedule
+	UptimeSeconds  int64  `json:"uptimeSeconds"`
+	ClientIP       string `json:"clientIp"`       // Filled in by the controller from the request
+	Acks           []Ack  `json:"acks,omitempty"` // Outcomes of commands since the last heartbeat
+
+	Impressions []Impression `json:"impressions,omitempty"` // Sponsor logos shown since the last heartbeat
+}
+
+// Impression is a sponsor logo shown on a sign, as reported by its display
+// and forwarded to the controller with the next heartbeat
+type Impression struct {
+	Image   string    `json:"image"`
+	Seconds float64   `json:"seconds"`
+	At      time.Time `json:"at,omitempty"` // When it was shown, the time of the report when zero
+}
+
+// maxHeartbeatSize bounds a heartbeat body, which may carry a backlog of
+// impressions after the controller was unreachable
+const maxHeartbeatSize = 1 << 20
+
+// HeartbeatReply is what the controller answers a heartbeat with
+type HeartbeatReply struct {
+	Profile  *Profile  `json:"profile,omitempty"`  // Set when the controller has profiles
+	Commands []Command `json:"commands,omitempty"` // Unacknowledged commands for the sign
+}
+
+// AllSigns addresses a command to every sign the controller knows
+const AllSigns = "*"
+
+// CommandRequest is the body of an admin request to queue a command
+type CommandRequest struct {
+	SignID  string `json:"signId"` // A sign ID or AllSigns
+	Command string `json:"command"`
+}
+
+// Sign is the controller's view of a sign built from its latest heartbeat
+type Sign struct {
+	Heartbeat
+	LastSeen  time.Time `json:"lastSeen"`
+	Stale     bool      `json:"stale"`     // No heartbeat within the stale threshold
+	OutOfSync bool      `json:"outOfSync"` // Serving a different schedule than expected
+}
+
+// Registry tracks the latest heartbeat from every sign
+type Registry struct {
+	mutex      sync.RWMutex
+	signs      map[string]Sign
+	staleAfter time.Duration
+	reference  func() string
+}
+
+// logger returns the default logger tagged for this package
+func logger() *slog.Logger {
+	return slog.Default().With("component", "fleet")
+}
+
+// NewRegistry produces a Registry that marks signs stale after staleAfter
+// without a heartbeat. reference returns the schedule hash signs are expected to
+// serve; when it is nil or returns "" the most common hash among fresh signs is
+// used instead.
+func NewRegistry(staleAfter time.Duration, reference func() string) *Registry {
+	return &Registry{
+		signs:      make(map[string]Sign),
+		staleAfter: staleAfter,
+		reference:  reference,
+	}
+}
+
+// Record stores a heartbeat received at now
+func (r *Registry) Record(hb Heartbeat, now time.Time) error {
+	if strings.TrimSpace(hb.SignID) == "" {
+		return errors.New("heartbeat is missing signId")
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if _, ok := r.signs[hb.SignID]; !ok {
+		logger().Info("new sign registered", "sign", hb.SignID, "client", hb.ClientIP, "version", hb.Version)
+	}
+	r.signs[hb.SignID] = Sign{
+		Heartbeat: hb,
+		LastSeen:  now,
+	}
+	return nil
+}
+
+// Signs returns every known sign as of now, sorted by ID, with its stale and
+// out of sync flags set
+func (r *Registry) Signs(now time.Time) []Sign {
+	r.mutex.RLock()
+	signs := make([]Sign, 0, len(r.signs))
+	for _, s := range r.signs {
+		s.Stale = now.Sub(s.LastSeen) > r.staleAfter
+		signs = append(signs, s)
+	}
+	r.mutex.RUnlock()
+
+	expected := ""
+	if r.reference != nil {
+		expected = r.reference()
+	}
+	if expected == "" {
+		expected = commonHash(signs)
+	}
+
+	for i := range signs {
+		signs[i].OutOfSync = expected != "" && signs[i].ScheduleHash != expected
+	}
+
+	sort.Slice(signs, func(i, j int) bool {
+		return signs[i].SignID < signs[j].SignID
+	})
+	return signs
+}
+
+// commonHash returns the schedule hash served by most fresh signs
+func commonHash(signs []Sign) string {
+	counts := make(map[string]int)
+	for _, s := range signs {
+		if !s.Stale && s.ScheduleHash != "" {
+			counts[s.ScheduleHash]++
+		}
+	}
+
+	best, bestCount := "", 0
+	for hash, n := range counts {
+		// Break ties deterministically
+		if n > bestCount || (n == bestCount && hash < best) {
+			best, bestCount = hash, n
+		}
+	}
+	return best
+}
+
+// Authorized reports whether the request carries the bearer token
+func Authorized(r *http.Request, token string) bool {
+	if token == "" {
+		return false
+	}
+	got, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !ok {
+		return false
+	}
+	return subtle.ConstantTimeCompare([]byte(got), []byte(token)) == 1
+}
+
+// Local reports whether the request came from the same machine, over loopback
+// or a Unix socket
+func Local(r *http.Request) bool {
+	if _, ok := r.Context().Value(http.LocalAddrContextKey).(*net.UnixAddr); ok {
+		return true
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return false
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+// Controller serves the fleet endpoints for a Registry. Signs send
+// heartbeats with the shared fleet token, while listing signs and queueing
+// commands take the admin token, so a token copied off one sign can't
+// command the rest.
+type Controller struct {
+	registry    *Registry
+	token       string
+	adminToken  string // Off when empty
+	profiles    *Profiles
+	commands    *CommandQueue
+	impressions func(signID string, impressions []Impression) error
+}
+
+// NewController produces a Controller accepting requests with token
+func NewController(registry *Registry, token string) *Controller {
+	return &Controller{
+		registry: registry,
+		token:    token,
+		commands: NewCommandQueue(time.Hour, 1000),
+	}
+}
+
+// SetAdminToken makes the controller list signs and queue commands for
+// requests carrying token
+func (c *Controller) SetAdminToken(token string) {
+	c.adminToken = token
+}
+
+// SetProfiles makes the controller answer each heartbeat with the sender's
+// profile
+func (c *Controller) SetProfiles(p *Profiles) {
+	c.profiles = p
+}
+
+// SetImpressions makes the controller pass the sponsor impressions each sign
+// forwards to record
+func (c *Controller) SetImpressions(record func(signID string, impressions []Impression) error) {
+	c.impressions = record
+}
+
+// HandleHeartbeat records a heartbeat POSTed by a sign along with any command
+// acknowledgements and impressions, and replies with its profile and pending
+// commands
+func (c *Controller) HandleHeartbeat(w http.ResponseWriter, r *http.Request) {
+	if !Authorized(r, c.token) {
+		logger().Warn("rejected unauthorized heartbeat", "client", clientIP(r))
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var hb Heartbeat
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxHeartbeatSize)).Decode(&hb); err != nil {
+		http.Error(w, "invalid heartbeat: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	hb.ClientIP = clientIP(r)
+	acks, impressions := hb.Acks, hb.Impressions
+	hb.Acks, hb.Impressions = nil, nil
+
+	now := time.Now()
+	if err := c.registry.Record(hb, now); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	c.commands.Acknowledge(hb.SignID, acks, now)
+	if len(impressions) > 0 {
+		c.recordImpressions(hb.SignID, impressions)
+	}
+
+	reply := HeartbeatReply{
+		Commands: c.commands.Deliver(hb.SignID, now),
+	}
+	if c.profiles != nil {
+		profile := c.profiles.For(hb.SignID)
+		reply.Profile = &profile
+	}
+
+	if reply.Profile == nil && len(reply.Commands) == 0 {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(reply); err != nil {
+		logger().Error("unable to encode heartbeat reply", "sign", hb.SignID, "err", err)
+	}
+}
+
+// recordImpressions passes impressions forwarded by a sign on, dropping them
+// when they can't be recorded since the sign doesn't keep them
+func (c *Controller) recordImpressions(signID string, impressions []Impression) {
+	if c.impressions == nil {
+		logger().Debug("dropping impressions, not counting them", "sign", signID, "count", len(impressions))
+		return
+	}
+	if err := c.impressions(signID, impressions); err != nil {
+		logger().Warn("unable to record forwarded impressions", "sign", signID, "count", len(impressions), "err", err)
+	}
+}
+
+// HandleSigns lists every known sign as JSON
+func (c *Controller) HandleSigns(w http.ResponseWriter, r *http.Request) {
+	if !Authorized(r, c.adminToken) {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(c.registry.Signs(time.Now())); err != nil {
+		logger().Error("unable to encode signs", "err", err)
+	}
+}
+
+// HandleCommands queues a command POSTed by an admin, for one sign or all of
+// them, or lists the command audit log on GET, optionally filtered by ?sign=
+func (c *Controller) HandleCommands(w http.ResponseWriter, r *http.Request) {
+	if !Authorized(r, c.adminToken) {
+		logger().Warn("rejected unauthorized command request", "client", clientIP(r))
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	now := time.Now()
+
+	if r.Method == http.MethodGet {
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(c.commands.Log(r.URL.Query().Get("sign"), now)); err != nil {
+			logger().Error("unable to encode command log", "err", err)
+		}
+		return
+	}
+
+	var req CommandRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 64<<10)).Decode(&req); err != nil {
+		http.Error(w, "invalid command: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	targets := []string{req.SignID}
+	if req.SignID == AllSigns {
+		targets = targets[:0]
+		for _, s := range c.registry.Signs(now) {
+			targets = append(targets, s.SignID)
+		}
+	}
+
+	cmds := make([]Command, 0, len(targets))
+	for _, signID := range targets {
+		cmd, err := c.commands.Enqueue(signID, req.Command, clientIP(r), now)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		cmds = append(cmds, cmd)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusAccepted)
+	if err := json.NewEncoder(w).Encode(cmds); err != nil {
+		logger().Error("unable to encode commands", "err", err)
+	}
+}
+
+// clientIP returns the IP address the request came from
+func clientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
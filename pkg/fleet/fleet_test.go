@@ -0,0 +1,215 @@
+package fleet
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+const testToken = "0123456789abcdef"
+
+// testAdminToken lists signs and queues commands
+const testAdminToken = "fedcba9876543210"
+
+func TestRegistrySigns(t *testing.T) {
+	now := time.Date(2025, 3, 6, 10, 0, 0, 0, time.UTC)
+	reference := ""
+	registry := NewRegistry(3*time.Minute, func() string { return reference })
+
+	heartbeats := []struct {
+		hb  Heartbeat
+		age time.Duration
+	}{
+		{Heartbeat{SignID: "ballroom-a", ScheduleHash: "aaa"}, 30 * time.Second},
+		{Heartbeat{SignID: "ballroom-b", ScheduleHash: "aaa"}, time.Minute},
+		{Heartbeat{SignID: "room-101", ScheduleHash: "bbb"}, 10 * time.Second},
+		{Heartbeat{SignID: "expo-hall", ScheduleHash: "bbb"}, 10 * time.Minute},
+		{Heartbeat{SignID: "expo-hall-2", ScheduleHash: "bbb"}, 20 * time.Minute},
+	}
+	for _, h := range heartbeats {
+		if err := registry.Record(h.hb, now.Add(-h.age)); err != nil {
+			t.Fatalf("❌ Record(%s) unexpected error: %v", h.hb.SignID, err)
+		}
+	}
+
+	t.Run("MajorityOfFreshSigns", func(t *testing.T) {
+		want := map[string][2]bool{ // stale, outOfSync
+			"ballroom-a":  {false, false},
+			"ballroom-b":  {false, false},
+			"expo-hall":   {true, true},
+			"expo-hall-2": {true, true},
+			"room-101":    {false, true},
+		}
+
+		signs := registry.Signs(now)
+		if len(signs) != len(want) {
+			t.Fatalf("❌ Signs() returned %d signs, want %d", len(signs), len(want))
+		}
+		if signs[0].SignID != "ballroom-a" || signs[4].SignID != "room-101" {
+			t.Errorf("❌ Signs() not sorted by ID: %s ... %s", signs[0].SignID, signs[4].SignID)
+		}
+		for _, s := range signs {
+			w := want[s.SignID]
+			if s.Stale != w[0] || s.OutOfSync != w[1] {
+				t.Errorf("❌ %s stale=%v outOfSync=%v, want stale=%v outOfSync=%v",
+					s.SignID, s.Stale, s.OutOfSync, w[0], w[1])
+			} else {
+				t.Logf("✅ %s stale=%v outOfSync=%v", s.SignID, s.Stale, s.OutOfSync)
+			}
+		}
+	})
+
+	t.Run("ReferenceHash", func(t *testing.T) {
+		reference = "bbb"
+		defer func() { reference = "" }()
+
+		for _, s := range registry.Signs(now) {
+			if want := s.ScheduleHash != "bbb"; s.OutOfSync != want {
+				t.Errorf("❌ %s outOfSync=%v, want %v against the controller hash", s.SignID, s.OutOfSync, want)
+			}
+		}
+	})
+
+	if err := registry.Record(Heartbeat{SignID: " "}, now); err == nil {
+		t.Errorf("❌ Record() without sign ID expected error, got nil")
+	}
+}
+
+func TestAuthorized(t *testing.T) {
+	tests := []struct {
+		name   string
+		header string
+		token  string
+		want   bool
+	}{
+		{"valid", "Bearer " + testToken, testToken, true},
+		{"wrong token", "Bearer nope", testToken, false},
+		{"missing header", "", testToken, false},
+		{"basic auth", "Basic " + testToken, testToken, false},
+		{"no token configured", "Bearer ", "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, SignsPath, nil)
+			if tt.header != "" {
+				req.Header.Set("Authorization", tt.header)
+			}
+			if got := Authorized(req, tt.token); got != tt.want {
+				t.Errorf("❌ Authorized() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLocal(t *testing.T) {
+	tests := []struct {
+		name       string
+		remoteAddr string
+		want       bool
+	}{
+		{"ipv4 loopback", "127.0.0.1:51000", true},
+		{"ipv6 loopback", "[::1]:51000", true},
+		{"lan", "10.0.0.5:51000", false},
+		{"unparseable", "somewhere", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, SignsPath, nil)
+			req.RemoteAddr = tt.remoteAddr
+			if got := Local(req); got != tt.want {
+				t.Errorf("❌ Local() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestControllerHandlers(t *testing.T) {
+	controller := NewController(NewRegistry(time.Minute, nil), testToken)
+	controller.SetAdminToken(testAdminToken)
+	forwarded := make(map[string][]Impression)
+	controller.SetImpressions(func(signID string, impressions []Impression) error {
+		forwarded[signID] = append(forwarded[signID], impressions...)
+		return nil
+	})
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST "+HeartbeatPath, controller.HandleHeartbeat)
+	mux.HandleFunc("GET "+SignsPath, controller.HandleSigns)
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	post := func(token string, body string) int {
+		req, _ := http.NewRequest(http.MethodPost, server.URL+HeartbeatPath, strings.NewReader(body))
+		req.Header.Set("Authorization", "Bearer "+token)
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("❌ heartbeat request failed: %v", err)
+		}
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+
+	if code := post("wrong-token-value", `{"signId":"room-101"}`); code != http.StatusUnauthorized {
+		t.Errorf("❌ heartbeat with wrong token returned %d, want 401", code)
+	}
+	if code := post(testToken, `{"signId":`); code != http.StatusBadRequest {
+		t.Errorf("❌ malformed heartbeat returned %d, want 400", code)
+	}
+	if code := post(testToken, `{"version":"0.3.0"}`); code != http.StatusBadRequest {
+		t.Errorf("❌ heartbeat without sign ID returned %d, want 400", code)
+	}
+	if code := post(testToken, `{"signId":"room-101","version":"0.3.0","scheduleHash":"aaa","uptimeSeconds":42,
+		"impressions":[{"image":"aws.png","seconds":10}]}`); code != http.StatusNoContent {
+		t.Fatalf("❌ valid heartbeat returned %d, want 204", code)
+	}
+	if imps := forwarded["room-101"]; len(imps) != 1 || imps[0].Image != "aws.png" || imps[0].Seconds != 10 {
+		t.Errorf("❌ forwarded impressions = %+v, want aws.png for 10 seconds", forwarded)
+	}
+
+	req, _ := http.NewRequest(http.MethodGet, server.URL+SignsPath, nil)
+	req.Header.Set("Authorization", "Bearer "+testAdminToken)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("❌ signs request failed: %v", err)
+	}
+	defer resp.Body.Close()
+
+	var signs []Sign
+	if err := json.NewDecoder(resp.Body).Decode(&signs); err != nil {
+		t.Fatalf("❌ Failed to decode signs: %v", err)
+	}
+	if len(signs) != 1 {
+		t.Fatalf("❌ got %d signs, want 1", len(signs))
+	}
+	s := signs[0]
+	if s.SignID != "room-101" || s.Version != "0.3.0" || s.UptimeSeconds != 42 || s.ClientIP != "127.0.0.1" || s.Impressions != nil {
+		t.Errorf("❌ unexpected sign record: %+v", s)
+	} else {
+		t.Logf("✅ controller recorded %s from %s", s.SignID, s.ClientIP)
+	}
+
+	// The fleet token every sign holds doesn't list the fleet
+	req.Header.Set("Authorization", "Bearer "+testToken)
+	fleetOnly, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("❌ signs request failed: %v", err)
+	}
+	fleetOnly.Body.Close()
+	if fleetOnly.StatusCode != http.StatusUnauthorized {
+		t.Errorf("❌ signs with the fleet token returned %d, want 401", fleetOnly.StatusCode)
+	}
+
+	unauth, err := http.Get(server.URL + SignsPath)
+	if err != nil {
+		t.Fatalf("❌ signs request failed: %v", err)
+	}
+	unauth.Body.Close()
+	if unauth.StatusCode != http.StatusUnauthorized {
+		t.Errorf("❌ signs without token returned %d, want 401", unauth.StatusCode)
+	}
+}
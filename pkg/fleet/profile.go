@@ -0,0 +1,162 @@
+package fleet
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/pelletier/go-toml/v2"
+)
+
+// Layouts the display knows how to render
+var Layouts = []string{"hallway", "room"}
+
+// Profile is the display configuration for a sign. Zero values mean "not set"
+// so profiles can be layered with Merge.
+type Profile struct {
+	SignID                  string   `json:"signId" toml:"-"`
+	Room                    string   `json:"room" toml:"room"`     // Pin the schedule to this room
+	Layout                  string   `json:"layout" toml:"layout"` // One of Layouts, room needs Room set
+	SponsorTiers            []string `json:"sponsorTiers" toml:"sponsor-tiers"`
+	ScheduleRotationSeconds int      `json:"scheduleRotationSeconds" toml:"schedule-rotation"`
+	SponsorRotationSeconds  int      `json:"sponsorRotationSeconds" toml:"sponsor-rotation"`
+}
+
+// DefaultProfile is used for anything no profile sets
+func DefaultProfile() Profile {
+	return Profile{
+		Layout:                  "hallway",
+		SponsorTiers:            []string{},
+		ScheduleRotationSeconds: 15,
+		SponsorRotationSeconds:  10,
+	}
+}
+
+// Merge returns p with every field that is set in over replaced
+func (p Profile) Merge(over Profile) Profile {
+	if over.SignID != "" {
+		p.SignID = over.SignID
+	}
+	if over.Room != "" {
+		p.Room = over.Room
+	}
+	if over.Layout != "" {
+		p.Layout = over.Layout
+	}
+	if len(over.SponsorTiers) > 0 {
+		p.SponsorTiers = slices.Clone(over.SponsorTiers)
+	}
+	if over.ScheduleRotationSeconds != 0 {
+		p.ScheduleRotationSeconds = over.ScheduleRotationSeconds
+	}
+	if over.SponsorRotationSeconds != 0 {
+		p.SponsorRotationSeconds = over.SponsorRotationSeconds
+	}
+	return p
+}
+
+// Validate checks the fields that are set
+func (p Profile) Validate() error {
+	if p.Layout != "" && !slices.Contains(Layouts, p.Layout) {
+		return fmt.Errorf("layout must be one of %v, got %q", Layouts, p.Layout)
+	}
+
+	for _, tier := range p.SponsorTiers {
+		if tier == "" {
+			return fmt.Errorf("sponsor tiers must not be empty")
+		}
+	}
+
+	if p.ScheduleRotationSeconds < 0 || p.SponsorRotationSeconds < 0 {
+		return fmt.Errorf("rotation intervals must not be negative")
+	}
+
+	return nil
+}
+
+// profilesFile is the layout of the controller's profiles TOML file
+type profilesFile struct {
+	Default Profile            `toml:"default"`
+	Signs   map[string]Profile `toml:"signs"`
+}
+
+// Profiles serves per-sign profiles from a TOML file, re-reading it whenever it
+// changes so edits apply without a restart:
+//
+//	[default]
+//	sponsor-tiers = ["diamond", "platinum", "gold"]
+//
+//	[signs.ballroom-a]
+//	layout = "room"
+//	room = "Ballroom A"
+type Profiles struct {
+	path string
+
+	mutex   sync.Mutex
+	file    profilesFile
+	modTime time.Time
+}
+
+// LoadProfiles reads the profiles file at path
+func LoadProfiles(path string) (*Profiles, error) {
+	p := &Profiles{path: path}
+	if err := p.load(); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// load reads and validates the profiles file
+func (p *Profiles) load() error {
+	info, err := os.Stat(p.path)
+	if err != nil {
+		return fmt.Errorf("unable to stat profiles file: %w", err)
+	}
+
+	b, err := os.ReadFile(p.path)
+	if err != nil {
+		return fmt.Errorf("unable to read profiles file: %w", err)
+	}
+
+	var f profilesFile
+	decoder := toml.NewDecoder(bytes.NewReader(b))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&f); err != nil {
+		return fmt.Errorf("unable to parse profiles file %s: %w", p.path, err)
+	}
+
+	if err := f.Default.Validate(); err != nil {
+		return fmt.Errorf("invalid default profile: %w", err)
+	}
+	for id, sp := range f.Signs {
+		if err := f.Default.Merge(sp).Validate(); err != nil {
+			return fmt.Errorf("invalid profile for sign %s: %w", id, err)
+		}
+	}
+
+	p.file = f
+	p.modTime = info.ModTime()
+	return nil
+}
+
+// For returns the controller's profile for a sign, the default profile merged
+// with any sign specific one
+func (p *Profiles) For(signID string) Profile {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	if info, err := os.Stat(p.path); err == nil && !info.ModTime().Equal(p.modTime) {
+		if err := p.load(); err != nil {
+			logger().Error("unable to reload profiles, keeping current", "path", p.path, "err", err)
+		} else {
+			logger().Info("reloaded profiles", "path", p.path, "signs", len(p.file.Signs))
+		}
+	}
+
+	profile := p.file.Default.Merge(p.file.Signs[signID])
+	profile.SignID = signID
+	return profile
+}
@@ -0,0 +1,156 @@
+package fleet
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+const testProfiles = `
+[default]
+sponsor-tiers = ["diamond", "platinum", "gold"]
+sponsor-rotation = 8
+
+[signs.ballroom-a]
+layout = "room"
+room = "Ballroom A"
+sponsor-tiers = ["diamond"]
+`
+
+// writeProfiles writes content to a profiles file in a temp directory
+func writeProfiles(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "profiles.toml")
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatalf("❌ unable to write profiles: %v", err)
+	}
+	return path
+}
+
+func TestProfileMerge(t *testing.T) {
+	base := DefaultProfile()
+	merged := base.Merge(Profile{Room: "Room 101", SponsorRotationSeconds: 20})
+
+	if merged.Room != "Room 101" || merged.SponsorRotationSeconds != 20 {
+		t.Errorf("❌ Merge() did not apply set fields: %+v", merged)
+	}
+	if merged.Layout != base.Layout || merged.ScheduleRotationSeconds != base.ScheduleRotationSeconds {
+		t.Errorf("❌ Merge() replaced unset fields: %+v", merged)
+	} else {
+		t.Logf("✅ Merge() = %+v", merged)
+	}
+}
+
+func TestProfileValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		profile Profile
+		wantErr bool
+	}{
+		{"Empty", Profile{}, false},
+		{"Default", DefaultProfile(), false},
+		{"RoomLayout", Profile{Layout: "room", Room: "Ballroom A"}, false},
+		{"UnknownLayout", Profile{Layout: "portrait"}, true},
+		{"EmptyTier", Profile{SponsorTiers: []string{"gold", ""}}, true},
+		{"NegativeRotation", Profile{ScheduleRotationSeconds: -1}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.profile.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("❌ Validate() error = %v, wantErr %v", err, tt.wantErr)
+			} else {
+				t.Logf("✅ Validate() error = %v", err)
+			}
+		})
+	}
+}
+
+func TestProfiles(t *testing.T) {
+	path := writeProfiles(t, testProfiles)
+	profiles, err := LoadProfiles(path)
+	if err != nil {
+		t.Fatalf("❌ LoadProfiles() unexpected error: %v", err)
+	}
+
+	p := profiles.For("ballroom-a")
+	if p.SignID != "ballroom-a" || p.Room != "Ballroom A" || p.Layout != "room" ||
+		!slices.Equal(p.SponsorTiers, []string{"diamond"}) || p.SponsorRotationSeconds != 8 {
+		t.Errorf("❌ For(ballroom-a) = %+v", p)
+	}
+
+	p = profiles.For("room-101")
+	if p.Room != "" || len(p.SponsorTiers) != 3 || p.SponsorRotationSeconds != 8 {
+		t.Errorf("❌ For(room-101) should get the default profile, got %+v", p)
+	}
+
+	// Moving a sign is an edit to the file, picked up on the next lookup
+	updated := strings.Replace(testProfiles, `"Ballroom A"`, `"Ballroom B"`, 1)
+	if err := os.WriteFile(path, []byte(updated), 0o644); err != nil {
+		t.Fatalf("❌ unable to update profiles: %v", err)
+	}
+	future := time.Now().Add(time.Minute)
+	if err := os.Chtimes(path, future, future); err != nil {
+		t.Fatalf("❌ unable to touch profiles: %v", err)
+	}
+	if p := profiles.For("ballroom-a"); p.Room != "Ballroom B" {
+		t.Errorf("❌ For(ballroom-a) after edit room = %q, want Ballroom B", p.Room)
+	} else {
+		t.Logf("✅ edit picked up, room = %q", p.Room)
+	}
+
+	// A broken edit keeps the last good profiles
+	if err := os.WriteFile(path, []byte("[signs.ballroom-a]\nlayout = \"portrait\""), 0o644); err != nil {
+		t.Fatalf("❌ unable to update profiles: %v", err)
+	}
+	future = future.Add(time.Minute)
+	if err := os.Chtimes(path, future, future); err != nil {
+		t.Fatalf("❌ unable to touch profiles: %v", err)
+	}
+	if p := profiles.For("ballroom-a"); p.Room != "Ballroom B" {
+		t.Errorf("❌ For(ballroom-a) after bad edit room = %q, want Ballroom B", p.Room)
+	}
+
+	for name, content := range map[string]string{
+		"UnknownKey":    "[default]\nlayot = \"room\"",
+		"InvalidLayout": "[signs.a]\nlayout = \"portrait\"",
+	} {
+		if _, err := LoadProfiles(writeProfiles(t, content)); err == nil {
+			t.Errorf("❌ LoadProfiles(%s) expected error, got nil", name)
+		}
+	}
+}
+
+func TestHeartbeatProfile(t *testing.T) {
+	profiles, err := LoadProfiles(writeProfiles(t, testProfiles))
+	if err != nil {
+		t.Fatalf("❌ LoadProfiles() unexpected error: %v", err)
+	}
+	controller := NewController(NewRegistry(time.Minute, nil), testToken)
+	controller.SetProfiles(profiles)
+
+	req := httptest.NewRequest(http.MethodPost, HeartbeatPath, strings.NewReader(`{"signId":"ballroom-a"}`))
+	req.Header.Set("Authorization", "Bearer "+testToken)
+	rr := httptest.NewRecorder()
+	controller.HandleHeartbeat(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("❌ heartbeat status = %d, want %d", rr.Code, http.StatusOK)
+	}
+	var reply HeartbeatReply
+	if err := json.Unmarshal(rr.Body.Bytes(), &reply); err != nil {
+		t.Fatalf("❌ unable to decode heartbeat reply: %v", err)
+	}
+	if p := reply.Profile; p == nil || p.SignID != "ballroom-a" || p.Room != "Ballroom A" {
+		t.Errorf("❌ heartbeat profile = %+v", p)
+	} else {
+		t.Logf("✅ heartbeat replied with profile for %s", p.SignID)
+	}
+}
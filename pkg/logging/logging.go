@@ -0,0 +1,82 @@
+package logging
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// NewLogger builds a slog.Logger writing to w at the given level ("debug",
+// "info", "warn" or "error") and format ("text" or "json")
+func NewLogger(w io.Writer, level string, format string) (*slog.Logger, error) {
+	var lvl slog.Level
+	if err := lvl.UnmarshalText([]byte(level)); err != nil {
+		return nil, fmt.Errorf("invalid log level %q: must be debug, info, warn or error", level)
+	}
+
+	opts := &slog.HandlerOptions{Level: lvl}
+
+	switch strings.ToLower(format) {
+	case "text":
+		return slog.New(slog.NewTextHandler(w, opts)), nil
+	case "json":
+		return slog.New(slog.NewJSONHandler(w, opts)), nil
+	default:
+		return nil, fmt.Errorf("invalid log format %q: must be text or json", format)
+	}
+}
+
+// Setup builds a logger with NewLogger and installs it as the slog default,
+// which also routes the standard library log package through it
+func Setup(w io.Writer, level string, format string) error {
+	logger, err := NewLogger(w, level, format)
+	if err != nil {
+		return err
+	}
+	slog.SetDefault(logger)
+	return nil
+}
+
+// GinMiddleware returns a gin middleware that logs each request through logger
+// in place of gin's default text logger. A nil logger uses slog.Default at the
+// time of each request, so the default can be swapped while running.
+func GinMiddleware(l *slog.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		logger := l
+		if logger == nil {
+			logger = slog.Default()
+		}
+		logger = logger.With("component", "http")
+
+		start := time.Now()
+		path := c.Request.URL.Path
+
+		c.Next()
+
+		status := c.Writer.Status()
+		attrs := []any{
+			"method", c.Request.Method,
+			"path", path,
+			"status", status,
+			"duration", time.Since(start),
+			"client", c.ClientIP(),
+			"size", c.Writer.Size(),
+		}
+		if len(c.Errors) > 0 {
+			attrs = append(attrs, "err", c.Errors.String())
+		}
+
+		switch {
+		case status >= 500:
+			logger.Error("request", attrs...)
+		case status >= 400:
+			logger.Warn("request", attrs...)
+		default:
+			logger.Info("request", attrs...)
+		}
+	}
+}
@@ -0,0 +1,92 @@
+package logging
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestNewLogger(t *testing.T) {
+	tests := []struct {
+		name    string
+		level   string
+		format  string
+		wantErr bool
+	}{
+		{"text info", "info", "text", false},
+		{"json debug", "debug", "json", false},
+		{"upper case", "WARN", "JSON", false},
+		{"invalid level", "loud", "text", true},
+		{"invalid format", "info", "xml", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewLogger(&bytes.Buffer{}, tt.level, tt.format)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("❌ NewLogger(%q, %q) error = %v, wantErr %v", tt.level, tt.format, err, tt.wantErr)
+			} else {
+				t.Logf("✅ NewLogger(%q, %q) returned expected result: %v", tt.level, tt.format, err)
+			}
+		})
+	}
+}
+
+func TestNewLoggerLevel(t *testing.T) {
+	var buf bytes.Buffer
+	logger, err := NewLogger(&buf, "warn", "text")
+	if err != nil {
+		t.Fatalf("❌ NewLogger() unexpected error: %v", err)
+	}
+
+	logger.Info("hidden")
+	logger.Warn("shown")
+
+	if strings.Contains(buf.String(), "hidden") {
+		t.Errorf("❌ info message logged at warn level: %s", buf.String())
+	}
+	if !strings.Contains(buf.String(), "shown") {
+		t.Errorf("❌ warn message missing at warn level: %s", buf.String())
+	}
+}
+
+func TestGinMiddleware(t *testing.T) {
+	var buf bytes.Buffer
+	logger, err := NewLogger(&buf, "info", "json")
+	if err != nil {
+		t.Fatalf("❌ NewLogger() unexpected error: %v", err)
+	}
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(GinMiddleware(logger))
+	router.GET("/schedule", func(c *gin.Context) {
+		c.String(http.StatusOK, "ok")
+	})
+
+	rr := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/schedule", nil)
+	router.ServeHTTP(rr, req)
+
+	var entry map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &entry); err != nil {
+		t.Fatalf("❌ request log is not valid JSON: %v (%s)", err, buf.String())
+	}
+
+	want := map[string]any{
+		"component": "http",
+		"method":    "GET",
+		"path":      "/schedule",
+		"status":    float64(http.StatusOK),
+	}
+	for k, v := range want {
+		if entry[k] != v {
+			t.Errorf("❌ %s = %v, want %v", k, entry[k], v)
+		}
+	}
+}
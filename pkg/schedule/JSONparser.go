@@ -4,7 +4,6 @@ import (
 	"encoding/json"
 	"errors"
 	"html"
-	"log"
 	"strings"
 	"time"
 )
@@ -30,7 +29,7 @@ func DrupalToPresentations(b []byte) ([]Presentation, error) {
 	for _, dn := range drupalNodes {
 		p, err := toPresentation(dn)
 		if err != nil {
-			log.Printf("Invalid DrupalNode %v (error: %v)", dn, err)
+			logger().Warn("skipping invalid session", "name", dn.Name, "location", dn.Location, "err", err)
 			continue
 		}
 		ps = append(ps, p)
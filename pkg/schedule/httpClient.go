@@ -1,8 +1,9 @@
 package schedule
 
 import (
+	"errors"
+	"fmt"
 	"io"
-	"log"
 	"net"
 	"net/http"
 	"time"
@@ -25,21 +26,47 @@ func newHTTPclient() *http.Client {
 	return httpClient
 }
 
-func fetch(url string) ([]byte, error) {
+// errNotModified is returned when the feed hasn't changed since it was last
+// fetched
+var errNotModified = errors.New("not modified")
+
+// fetch GETs url, sending etag as If-None-Match when set, and returns the body
+// along with the ETag and Last-Modified time of the response
+func fetch(url string, etag string) ([]byte, string, time.Time, error) {
 	c := newHTTPclient()
-	resp, err := c.Get(url)
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return nil, "", time.Time{}, err
+	}
+	if etag != "" {
+		req.Header.Set("If-None-Match", etag)
+	}
+
+	resp, err := c.Do(req)
 	if err != nil {
-		return nil, err
+		return nil, "", time.Time{}, err
 	}
 	defer func() {
 		err = resp.Body.Close()
 		if err != nil {
-			log.Printf("unable to close response body %v", err)
+			logger().Warn("unable to close response body", "url", url, "err", err)
 		}
 	}()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotModified:
+		return nil, etag, time.Time{}, errNotModified
+	default:
+		return nil, "", time.Time{}, fmt.Errorf("unexpected status %s", resp.Status)
+	}
+
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return nil, err
+		return nil, "", time.Time{}, err
 	}
-	return body, nil
+
+	// A missing or malformed Last-Modified leaves the time unknown
+	modified, _ := http.ParseTime(resp.Header.Get("Last-Modified"))
+	return body, resp.Header.Get("ETag"), modified, nil
 }
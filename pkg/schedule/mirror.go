@@ -0,0 +1,34 @@
+package schedule
+
+import (
+	"bytes"
+	"net/http"
+)
+
+// FeedPath is where a mirroring sign serves its feed, matching the simulator
+// so other signs can point -json at it
+const FeedPath = "/sign.json"
+
+// HandleFeed serves the raw feed the current schedule was parsed from, byte
+// for byte as Drupal sent it. The content hash is used as the ETag and sent as
+// X-Content-Hash, Last-Modified passes on the time reported by the upstream
+// source when known, and conditional and HEAD requests are supported.
+func (s *Schedule) HandleFeed(w http.ResponseWriter, req *http.Request) {
+	s.mutex.RLock()
+	raw, modified, hash := s.raw, s.modified, s.ContentHash
+	if modified.IsZero() {
+		modified = s.updated
+	}
+	s.mutex.RUnlock()
+
+	if raw == nil {
+		http.Error(w, "no schedule fetched yet", http.StatusServiceUnavailable)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("ETag", `"`+hash+`"`)
+	w.Header().Set("X-Content-Hash", hash)
+	w.Header().Set("Cache-Control", "no-cache")
+	http.ServeContent(w, req, "", modified, bytes.NewReader(raw))
+}
@@ -0,0 +1,129 @@
+package schedule
+
+import (
+	"encoding/json"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+)
+
+// RoomsPath lists the rooms in the schedule, and RoomsPath/<room> serves one
+const RoomsPath = "/schedule/rooms"
+
+// Sponsor is a sponsor tied to a session or a room
+type Sponsor struct {
+	Name     string    `json:"name"`
+	Tier     string    `json:"tier"`
+	ImageURL string    `json:"imageUrl"`
+	Website  string    `json:"website,omitempty"`
+	From     time.Time `json:"from,omitzero"`  // Start of the sponsor's time window, if it has one
+	Until    time.Time `json:"until,omitzero"` // End of the sponsor's time window, if it has one
+}
+
+// Sponsors finds the sponsors tied to sessions and rooms
+type Sponsors interface {
+	// SessionSponsors returns the sponsors of a session in room on topic
+	SessionSponsors(room, topic string, start, end time.Time) []Sponsor
+	// RoomSponsors returns the sponsors of room with their time windows
+	RoomSponsors(room string) []Sponsor
+}
+
+// Room is a room in the schedule with its sponsors and sessions
+type Room struct {
+	Name     string         `json:"name"`
+	Sessions int            `json:"sessions"`
+	Sponsors []Sponsor      `json:"sponsors"`
+	Schedule []Presentation `json:"schedule,omitempty"` // Only when serving one room
+}
+
+// SetSponsors attaches sponsors to sessions and rooms served from now on
+func (s *Schedule) SetSponsors(sponsors Sponsors) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.sponsors = sponsors
+}
+
+// withSponsors returns a copy of the presentations with the sponsors of each
+// attached. It must be called with the read lock held.
+func (s *Schedule) withSponsors(ps []Presentation) []Presentation {
+	if s.sponsors == nil {
+		return ps
+	}
+	out := make([]Presentation, len(ps))
+	for i, p := range ps {
+		p.Sponsors = s.sponsors.SessionSponsors(p.Location, p.Topic, p.StartTime, p.EndTime)
+		out[i] = p
+	}
+	return out
+}
+
+// roomSponsors returns the sponsors of room, never nil. It must be called
+// with the read lock held.
+func (s *Schedule) roomSponsors(room string) []Sponsor {
+	sponsors := []Sponsor{}
+	if s.sponsors != nil {
+		sponsors = append(sponsors, s.sponsors.RoomSponsors(room)...)
+	}
+	return sponsors
+}
+
+// HandleRooms lists each room in the schedule with its number of sessions and
+// its sponsors
+func (s *Schedule) HandleRooms(w http.ResponseWriter, req *http.Request) {
+	s.mutex.RLock()
+	counts := make(map[string]int)
+	for _, p := range s.Presentations {
+		counts[p.Location]++
+	}
+	rooms := make([]Room, 0, len(counts))
+	for name, n := range counts {
+		rooms = append(rooms, Room{Name: name, Sessions: n, Sponsors: s.roomSponsors(name)})
+	}
+	s.mutex.RUnlock()
+
+	slices.SortFunc(rooms, func(a, b Room) int { return strings.Compare(a.Name, b.Name) })
+	writeJSON(w, rooms)
+}
+
+// HandleRoom serves the sessions of one room, named in the path after
+// RoomsPath and matched ignoring case, along with the room's sponsors for its
+// door sign. A room that isn't in the schedule and has no sponsors is not
+// found.
+func (s *Schedule) HandleRoom(w http.ResponseWriter, req *http.Request) {
+	// Room names can have a slash in them, such as Room 106/107
+	name := strings.TrimPrefix(req.URL.Path, RoomsPath+"/")
+	if name == "" {
+		http.NotFound(w, req)
+		return
+	}
+
+	s.mutex.RLock()
+	room := Room{Name: name, Schedule: []Presentation{}}
+	for _, p := range s.Presentations {
+		if strings.EqualFold(p.Location, name) {
+			room.Name = p.Location
+			room.Schedule = append(room.Schedule, p)
+		}
+	}
+	room.Sessions = len(room.Schedule)
+	room.Schedule = s.withSponsors(room.Schedule)
+	room.Sponsors = s.roomSponsors(room.Name)
+	s.mutex.RUnlock()
+
+	if room.Sessions == 0 && len(room.Sponsors) == 0 {
+		http.Error(w, "unknown room "+name, http.StatusNotFound)
+		return
+	}
+	writeJSON(w, room)
+}
+
+// writeJSON encodes v as the response, leaving HTML in session text as is
+func writeJSON(w http.ResponseWriter, v any) {
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(false)
+	w.Header().Set("Content-Type", "application/json")
+	if err := enc.Encode(v); err != nil {
+		logger().Error("unable to encode rooms", "err", err)
+	}
+}
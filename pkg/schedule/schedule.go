@@ -4,7 +4,9 @@ import (
 	"crypto/sha256"
 	"encoding/hex"
 	"encoding/json"
-	"log"
+	"errors"
+	"fmt"
+	"log/slog"
 	"net/http"
 	"sync"
 	"time"
@@ -17,8 +19,15 @@ type Schedule struct {
 	LastRefreshTime string         `json:"lastRefreshTime"` // When we last checked for updates
 	ContentHash     string         `json:"contentHash"`     // SHA-256 hash of the raw Drupal content
 	SessionCount    int            `json:"sessionCount"`    // Number of presentations
+	Source          string         `json:"source"`          // URL of the source that supplied the current data
+	Sources         []*FeedSource  `json:"sources"`         // Every source in priority order with its health
 	mutex           *sync.RWMutex  `json:"-"`               // Don't include in JSON
-	jsonURL         string         `json:"-"`               // Don't include in JSON
+	update          sync.Mutex     `json:"-"`               // Serializes UpdateFromJSON
+	raw             []byte         `json:"-"`               // Feed the current schedule was parsed from
+	updated         time.Time      `json:"-"`               // When raw was last replaced
+	modified        time.Time      `json:"-"`               // When raw last changed according to its source, zero if unknown
+	refreshed       time.Time      `json:"-"`               // When we last checked for updates, zero before the first check
+	sponsors        Sponsors       `json:"-"`               // Attached to sessions when serving them, if set
 }
 
 // Event is basic scheduling primitive
@@ -33,20 +42,27 @@ type Event struct {
 // Presentation is an extension of event with speakers and a topic
 type Presentation struct {
 	Event
-	Speakers string `json:"Speakers"`
-	Topic    string `json:"Topic"`
+	Speakers string    `json:"Speakers"`
+	Topic    string    `json:"Topic"`
+	Sponsors []Sponsor `json:"Sponsors,omitempty"` // Sponsors tied to the session's room, topic or time
 }
 
-// NewSchedule produces a new Schedule
-func NewSchedule(jsonUrl string) *Schedule {
+// NewSchedule produces a new Schedule fetched from the given URLs in priority
+// order
+func NewSchedule(jsonURLs ...string) *Schedule {
 	sch := Schedule{
-		jsonURL:     jsonUrl,
+		Sources:     newFeedSources(jsonURLs, nil),
 		ContentHash: "",
 	}
 	sch.mutex = &sync.RWMutex{}
 	return &sch
 }
 
+// logger returns the default logger tagged for this package
+func logger() *slog.Logger {
+	return slog.Default().With("component", "schedule")
+}
+
 // calculateContentHash generates a SHA-256 hash of content
 func calculateContentHash(content []byte) string {
 	hash := sha256.Sum256(content)
@@ -69,22 +85,106 @@ func (s *Schedule) updateSchedule(ps []Presentation) {
 	s.SessionCount = len(ps)
 	s.LastUpdateTime = formatTime(time.Now())
 
-	log.Printf("Schedule updated with %d sessions, hash: %s", s.SessionCount, s.ContentHash)
+	logger().Info("schedule updated", "sessions", s.SessionCount, "hash", s.ContentHash)
+}
+
+// URLs returns the schedule sources in priority order
+func (s *Schedule) URLs() []string {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	urls := make([]string, 0, len(s.Sources))
+	for _, src := range s.Sources {
+		urls = append(urls, src.URL)
+	}
+	return urls
+}
+
+// State returns the content hash and last update time of the current schedule
+func (s *Schedule) State() (contentHash string, lastUpdateTime string) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	return s.ContentHash, s.LastUpdateTime
+}
+
+// LastRefresh returns when the schedule was last checked for updates, or the
+// zero time if it has never been
+func (s *Schedule) LastRefresh() time.Time {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	return s.refreshed
+}
+
+// SetURLs swaps the schedule sources used by future updates. The current
+// presentations keep being served until an update from the new sources
+// succeeds.
+func (s *Schedule) SetURLs(jsonURLs []string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.Sources = newFeedSources(jsonURLs, s.Sources)
 }
 
-// UpdateFromJSON fetches and processes the schedule JSON
-func (s *Schedule) UpdateFromJSON() {
-	log.Printf("Updating Schedule from %v", s.jsonURL)
+// errStale is returned for a source whose feed is older than the current one
+var errStale = errors.New("feed is older than the current schedule")
+
+// UpdateFromJSON fetches the schedule from each source in priority order
+// until one supplies valid content, skipping any fallback that is older than
+// the current schedule. Errors are logged and returned; the current schedule
+// is kept when every source fails.
+func (s *Schedule) UpdateFromJSON() error {
+	s.update.Lock()
+	defer s.update.Unlock()
 
 	// Always update the refresh time
 	s.mutex.Lock()
-	s.LastRefreshTime = formatTime(time.Now())
+	s.refreshed = time.Now()
+	s.LastRefreshTime = formatTime(s.refreshed)
+	sources := s.Sources
 	s.mutex.Unlock()
 
-	body, err := fetch(s.jsonURL)
-	if err != nil {
-		log.Printf("Error fetching schedule: %v", err)
-		return
+	var errs []error
+	stale := 0
+	for _, src := range sources {
+		err := s.updateFrom(src)
+		if err == nil {
+			return nil
+		}
+		if errors.Is(err, errStale) {
+			stale++
+		}
+		errs = append(errs, fmt.Errorf("%s: %w", src.URL, err))
+	}
+
+	// Every source answering with an older feed leaves nothing to fix
+	if stale == len(sources) {
+		logger().Warn("every schedule source is older than the current schedule, keeping it", "sources", len(sources))
+		return nil
+	}
+
+	err := errors.Join(errs...)
+	if len(sources) > 1 {
+		logger().Error("no schedule source supplied a current schedule, keeping existing schedule", "sources", len(sources))
+	}
+	return err
+}
+
+// updateFrom fetches from one source and adopts its content unless it is
+// invalid, or a fallback older than the current schedule
+func (s *Schedule) updateFrom(src *FeedSource) error {
+	s.mutex.RLock()
+	etag, modified, raw := src.etag, src.modified, src.raw
+	s.mutex.RUnlock()
+
+	logger().Debug("updating schedule", "url", src.URL)
+
+	body, newETag, newModified, err := fetchSource(src.URL, etag, modified)
+	if errors.Is(err, errNotModified) {
+		// Unchanged since the last valid fetch from this source
+		body, newETag, newModified = raw, etag, modified
+	} else if err != nil {
+		logger().Error("unable to fetch schedule", "url", src.URL, "err", err)
+		s.recordHealth(src, fmt.Errorf("unable to fetch schedule: %w", err))
+		return fmt.Errorf("unable to fetch schedule: %w", err)
 	}
 
 	// Calculate hash of the raw JSON content
@@ -92,44 +192,119 @@ func (s *Schedule) UpdateFromJSON() {
 
 	// Check if content has changed by comparing hashes
 	s.mutex.RLock()
-	currentHash := s.ContentHash
+	currentHash, currentModified := s.ContentHash, s.modified
 	s.mutex.RUnlock()
 
 	if currentHash == newContentHash && currentHash != "" {
-		log.Printf("No change to JSON schedule (hash: %s)", newContentHash)
-		return
+		logger().Debug("no change to schedule", "url", src.URL, "hash", newContentHash)
+		s.mutex.Lock()
+		src.raw, src.etag, src.modified = body, newETag, newModified
+		s.Source = src.URL
+		s.mutex.Unlock()
+		s.recordHealth(src, nil)
+		return nil
 	}
 
 	ps, err := DrupalToPresentations(body)
 	if err != nil {
-		log.Printf("Unmarshal error: %v", err)
-		return
+		logger().Error("unable to parse schedule", "url", src.URL, "err", err)
+		s.recordHealth(src, fmt.Errorf("unable to parse schedule: %w", err))
+		return fmt.Errorf("unable to parse schedule: %w", err)
 	}
 
 	// Only update the content hash and schedule if we have presentations
 	if len(ps) == 0 {
-		log.Printf("Parsed JSON resulted in 0 presentations, keeping existing schedule")
-		return
+		logger().Warn("parsed schedule has no sessions, keeping existing schedule", "url", src.URL)
+		s.recordHealth(src, errors.New("parsed schedule has no sessions"))
+		return errors.New("parsed schedule has no sessions")
+	}
+
+	s.mutex.Lock()
+	src.raw, src.etag, src.modified = body, newETag, newModified
+	s.mutex.Unlock()
+	s.recordHealth(src, nil)
+
+	// Don't let a fallback replace the schedule with an older copy, e.g. a
+	// stale USB stick. Clocks differ between sources, so the current source
+	// and those above it are always adopted.
+	s.mutex.RLock()
+	fallback := s.isFallback(src)
+	s.mutex.RUnlock()
+	if fallback && !newModified.IsZero() && !currentModified.IsZero() && newModified.Before(currentModified) {
+		logger().Warn("schedule source is older than the current schedule, skipping",
+			"url", src.URL, "modified", newModified.Format(time.RFC3339), "current", currentModified.Format(time.RFC3339))
+		return errStale
 	}
 
-	// Update the content hash
+	// Update the content hash and keep the raw feed for mirroring
 	s.mutex.Lock()
 	s.ContentHash = newContentHash
+	s.raw = body
+	s.updated = time.Now()
+	s.modified = newModified
+	s.Source = src.URL
 	s.mutex.Unlock()
 
 	s.updateSchedule(ps)
+	return nil
+}
+
+// isFallback reports whether src ranks below the source of the current
+// schedule. The caller holds s.mutex.
+func (s *Schedule) isFallback(src *FeedSource) bool {
+	for _, other := range s.Sources {
+		switch other.URL {
+		case src.URL:
+			return false
+		case s.Source:
+			return true
+		}
+	}
+	return false
+}
+
+// recordHealth updates a source after an attempt, logging when it goes down
+// or recovers
+func (s *Schedule) recordHealth(src *FeedSource, err error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	now := formatTime(time.Now())
+	src.LastChecked = now
+
+	if err != nil {
+		if src.Healthy || src.Failures == 0 {
+			logger().Warn("schedule source unhealthy", "url", src.URL, "err", err)
+		}
+		src.Healthy = false
+		src.Failures++
+		src.LastError = err.Error()
+		return
+	}
+
+	if !src.Healthy && src.Failures > 0 {
+		logger().Info("schedule source recovered", "url", src.URL, "failures", src.Failures)
+	}
+	src.Healthy = true
+	src.Failures = 0
+	src.LastSuccess = now
 }
 
-// HandleScheduleAll serves the complete schedule as JSON
+// HandleScheduleAll serves the complete schedule as JSON, with the sponsors
+// of each session when sponsors are set
 func (s *Schedule) HandleScheduleAll(w http.ResponseWriter, req *http.Request) {
 	enc := json.NewEncoder(w)
 	enc.SetEscapeHTML(false)
 	w.Header().Set("Content-Type", "application/json")
 
 	s.mutex.RLock()
-	err := enc.Encode(s)
+	// The outer Presentations hides the schedule's own when encoding
+	err := enc.Encode(struct {
+		*Schedule
+		Presentations []Presentation `json:"Presentations"`
+	}{s, s.withSponsors(s.Presentations)})
 	if err != nil {
-		log.Println("HandleScheduleAll cannot encode schedule")
+		logger().Error("unable to encode schedule", "err", err)
 	}
 	s.mutex.RUnlock()
 }
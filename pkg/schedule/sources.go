@@ -0,0 +1,71 @@
+package schedule
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"time"
+)
+
+// FeedSource is one place the schedule can be fetched from, with its health as
+// of the last attempt
+type FeedSource struct {
+	URL         string `json:"url"`
+	Healthy     bool   `json:"healthy"`
+	LastChecked string `json:"lastChecked,omitempty"`
+	LastSuccess string `json:"lastSuccess,omitempty"`
+	LastError   string `json:"lastError,omitempty"`
+	Failures    int    `json:"consecutiveFailures"`
+
+	raw      []byte    // Last valid feed from this source
+	etag     string    // ETag of raw, for conditional requests
+	modified time.Time // When raw last changed according to the source, zero if unknown
+}
+
+// newFeedSources builds sources for urls, keeping the state of any that are
+// already known
+func newFeedSources(urls []string, existing []*FeedSource) []*FeedSource {
+	sources := make([]*FeedSource, 0, len(urls))
+	for _, u := range urls {
+		var src *FeedSource
+		for _, e := range existing {
+			if e.URL == u {
+				src = e
+				break
+			}
+		}
+		if src == nil {
+			src = &FeedSource{URL: u}
+		}
+		sources = append(sources, src)
+	}
+	return sources
+}
+
+// fetchSource fetches from a source given its last ETag and modification
+// time. http and https URLs are fetched with a conditional GET, file URLs are
+// read from disk, e.g. a USB stick.
+func fetchSource(src string, etag string, modified time.Time) ([]byte, string, time.Time, error) {
+	u, err := url.Parse(src)
+	if err != nil {
+		return nil, "", time.Time{}, err
+	}
+
+	if u.Scheme != "file" {
+		return fetch(src, etag)
+	}
+
+	info, err := os.Stat(u.Path)
+	if err != nil {
+		return nil, "", time.Time{}, err
+	}
+	if !modified.IsZero() && info.ModTime().Equal(modified) {
+		return nil, "", modified, errNotModified
+	}
+
+	body, err := os.ReadFile(u.Path)
+	if err != nil {
+		return nil, "", time.Time{}, fmt.Errorf("unable to read %s: %w", u.Path, err)
+	}
+	return body, "", info.ModTime(), nil
+}
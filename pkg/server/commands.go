@@ -0,0 +1,205 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/kylerisse/go-signs/pkg/fleet"
+)
+
+// DisplayCommandsPath is where the display polls for commands to carry out
+const DisplayCommandsPath = "/display/commands"
+
+// displayCommandTTL is how long a command waits for the display before it is
+// reported as failed
+const displayCommandTTL = 10 * time.Minute
+
+// commandRunner carries out commands from the controller. Refresh runs here,
+// the rest are queued for the display, which acknowledges them once it has
+// picked them up. Outcomes are reported with the next heartbeat.
+type commandRunner struct {
+	mutex   sync.Mutex
+	refresh func() error
+	seen    map[string]bool // Commands already started, so repeats are ignored
+	results map[string]fleet.Ack
+	display []displayCommand // Waiting for the display
+	acks    []fleet.Ack      // Waiting for the next heartbeat
+	wake    chan struct{}    // Signals new acks to send
+}
+
+// displayCommand is a command waiting for the display since queued
+type displayCommand struct {
+	fleet.Command
+	queued time.Time
+}
+
+// newCommandRunner produces a commandRunner that refreshes the schedule with
+// refresh
+func newCommandRunner(refresh func() error) *commandRunner {
+	return &commandRunner{
+		refresh: refresh,
+		seen:    make(map[string]bool),
+		results: make(map[string]fleet.Ack),
+		wake:    make(chan struct{}, 1),
+	}
+}
+
+// run starts the commands delivered in a heartbeat reply. The controller keeps
+// delivering a command until it is acknowledged, so repeats re-send the
+// outcome if there is one.
+func (c *commandRunner) run(cmds []fleet.Command) {
+	delivered := make(map[string]bool, len(cmds))
+	for _, cmd := range cmds {
+		delivered[cmd.ID] = true
+
+		c.mutex.Lock()
+		seen := c.seen[cmd.ID]
+		c.seen[cmd.ID] = true
+		result, finished := c.results[cmd.ID]
+		c.mutex.Unlock()
+
+		if seen {
+			if finished {
+				c.acknowledge(result)
+			}
+			continue
+		}
+
+		logger().Info("running command", "id", cmd.ID, "command", cmd.Name)
+		switch cmd.Name {
+		case fleet.CommandRefresh:
+			ack := fleet.Ack{ID: cmd.ID, Status: fleet.StatusDone}
+			if err := c.refresh(); err != nil {
+				ack.Status, ack.Error = fleet.StatusFailed, err.Error()
+			}
+			c.acknowledge(ack)
+		case fleet.CommandReload, fleet.CommandIdentify, fleet.CommandClearCache:
+			c.mutex.Lock()
+			c.display = append(c.display, displayCommand{Command: cmd, queued: time.Now()})
+			c.mutex.Unlock()
+		default:
+			c.acknowledge(fleet.Ack{ID: cmd.ID, Status: fleet.StatusFailed, Error: "unknown command " + cmd.Name})
+		}
+	}
+
+	c.prune(delivered)
+}
+
+// prune fails display commands that have waited longer than displayCommandTTL
+// and, when delivered is not nil, forgets finished commands missing from it
+// since the controller no longer sends them
+func (c *commandRunner) prune(delivered map[string]bool) {
+	c.mutex.Lock()
+	var expired []string
+	c.display = slices.DeleteFunc(c.display, func(cmd displayCommand) bool {
+		if time.Since(cmd.queued) <= displayCommandTTL {
+			return false
+		}
+		expired = append(expired, cmd.ID)
+		return true
+	})
+	if delivered != nil {
+		for id := range c.seen {
+			if _, finished := c.results[id]; finished && !delivered[id] {
+				delete(c.seen, id)
+				delete(c.results, id)
+			}
+		}
+	}
+	c.mutex.Unlock()
+
+	for _, id := range expired {
+		logger().Warn("display did not pick up command", "id", id, "ttl", displayCommandTTL.String())
+		c.acknowledge(fleet.Ack{ID: id, Status: fleet.StatusFailed, Error: "not picked up by the display"})
+	}
+}
+
+// acknowledge records the outcome of a command for the next heartbeat
+func (c *commandRunner) acknowledge(ack fleet.Ack) {
+	c.mutex.Lock()
+	c.results[ack.ID] = ack
+	if !slices.ContainsFunc(c.acks, func(a fleet.Ack) bool { return a.ID == ack.ID }) {
+		c.acks = append(c.acks, ack)
+	}
+	c.mutex.Unlock()
+
+	select {
+	case c.wake <- struct{}{}:
+	default:
+	}
+}
+
+// takeAcks returns and clears the acks waiting to be sent
+func (c *commandRunner) takeAcks() []fleet.Ack {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	acks := c.acks
+	c.acks = nil
+	return acks
+}
+
+// displayOnly rejects requests that don't come from the display on this
+// machine, reporting whether to continue
+func displayOnly(w http.ResponseWriter, r *http.Request) bool {
+	if fleet.Local(r) {
+		return true
+	}
+	logger().Warn("rejected display command request from another host", "client", r.RemoteAddr, "path", r.URL.Path)
+	http.Error(w, "display commands are only served to this machine", http.StatusForbidden)
+	return false
+}
+
+// handleDisplayCommands lists the commands waiting for the display
+func (c *commandRunner) handleDisplayCommands(w http.ResponseWriter, r *http.Request) {
+	if !displayOnly(w, r) {
+		return
+	}
+	c.prune(nil)
+
+	c.mutex.Lock()
+	cmds := make([]fleet.Command, 0, len(c.display))
+	for _, cmd := range c.display {
+		cmds = append(cmds, cmd.Command)
+	}
+	c.mutex.Unlock()
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	if err := json.NewEncoder(w).Encode(cmds); err != nil {
+		logger().Error("unable to encode display commands", "err", err)
+	}
+}
+
+// handleDisplayAck removes a command the display has picked up and
+// acknowledges it to the controller
+func (c *commandRunner) handleDisplayAck(ctx *gin.Context) {
+	if !displayOnly(ctx.Writer, ctx.Request) {
+		return
+	}
+	c.prune(nil)
+	id := ctx.Param("id")
+
+	c.mutex.Lock()
+	i := slices.IndexFunc(c.display, func(cmd displayCommand) bool { return cmd.ID == id })
+	if i >= 0 {
+		c.display = slices.Delete(c.display, i, i+1)
+	}
+	c.mutex.Unlock()
+
+	if i < 0 {
+		ctx.String(http.StatusNotFound, "unknown command")
+		return
+	}
+
+	var ack fleet.Ack
+	if err := ctx.ShouldBindJSON(&ack); err != nil || ack.Status != fleet.StatusFailed {
+		ack = fleet.Ack{Status: fleet.StatusDone}
+	}
+	ack.ID = id
+	c.acknowledge(ack)
+	ctx.Status(http.StatusNoContent)
+}
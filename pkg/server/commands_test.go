@@ -0,0 +1,189 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"slices"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/kylerisse/go-signs/pkg/fleet"
+)
+
+// queueCommand POSTs a command to the controller at baseURL
+func queueCommand(t *testing.T, baseURL string, token string, signID string, name string) {
+	t.Helper()
+	body, _ := json.Marshal(fleet.CommandRequest{SignID: signID, Command: name})
+	req, _ := http.NewRequest(http.MethodPost, baseURL+fleet.CommandsPath, bytes.NewReader(body))
+	req.Header.Set("Authorization", "Bearer "+token)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("❌ command request failed: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusAccepted {
+		t.Fatalf("❌ command %s returned %d, want 202", name, resp.StatusCode)
+	}
+}
+
+// displayRequest returns a request from the display on the same machine
+func displayRequest(method string, target string) *http.Request {
+	req := httptest.NewRequest(method, target, nil)
+	req.RemoteAddr = "127.0.0.1:51000"
+	return req
+}
+
+func TestCommands(t *testing.T) {
+	const token = "0123456789abcdef"
+	const adminToken = "fedcba9876543210"
+
+	var fetches atomic.Int32
+	source := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fetches.Add(1)
+		http.ServeFile(w, r, filepath.Join("testdata", "sign.json"))
+	}))
+	defer source.Close()
+
+	controllerConf, err := NewConfig("2017", source.URL, 60)
+	if err != nil {
+		t.Fatalf("❌ Failed to create controller config (%v)", err)
+	}
+	if err := controllerConf.SetController(true, token, 60); err != nil {
+		t.Fatalf("❌ SetController() unexpected error: %v", err)
+	}
+	if err := controllerConf.SetFleetAdminToken(adminToken); err != nil {
+		t.Fatalf("❌ SetFleetAdminToken() unexpected error: %v", err)
+	}
+	controller := NewServer(controllerConf)
+	defer close(controller.stop)
+	controllerHTTP := httptest.NewServer(controller.httpd.Handler)
+	defer controllerHTTP.Close()
+
+	signConf, err := NewConfig("2017", source.URL, 60)
+	if err != nil {
+		t.Fatalf("❌ Failed to create sign config (%v)", err)
+	}
+	if err := signConf.SetReporter(controllerHTTP.URL, "ballroom-a", token, 5); err != nil {
+		t.Fatalf("❌ SetReporter() unexpected error: %v", err)
+	}
+	sign := NewServer(signConf)
+	defer close(sign.stop)
+	waitForSessionCount(t, sign, 2)
+
+	queueCommand(t, controllerHTTP.URL, adminToken, "ballroom-a", fleet.CommandRefresh)
+	queueCommand(t, controllerHTTP.URL, adminToken, "ballroom-a", fleet.CommandIdentify)
+
+	// The heartbeat reply carries both commands; refresh runs on the sign
+	r := newReporter(signConf, sign.schedule, sign.started, sign.profile.setRemote, sign.commands)
+	before := fetches.Load()
+	if err := r.send(); err != nil {
+		t.Fatalf("❌ send() unexpected error: %v", err)
+	}
+	if fetches.Load() <= before {
+		t.Errorf("❌ refresh command did not fetch the schedule")
+	}
+
+	// Only the display on the sign itself may see or acknowledge commands
+	rr := httptest.NewRecorder()
+	sign.httpd.Handler.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, DisplayCommandsPath, nil))
+	if rr.Code != http.StatusForbidden {
+		t.Errorf("❌ display commands from another host returned %d, want 403", rr.Code)
+	}
+
+	// Identify waits for the display to pick it up
+	rr = httptest.NewRecorder()
+	sign.httpd.Handler.ServeHTTP(rr, displayRequest(http.MethodGet, DisplayCommandsPath))
+	var display []fleet.Command
+	if err := json.Unmarshal(rr.Body.Bytes(), &display); err != nil || len(display) != 1 || display[0].Name != fleet.CommandIdentify {
+		t.Fatalf("❌ display commands = %+v (%v), want identify", display, err)
+	}
+
+	rr = httptest.NewRecorder()
+	sign.httpd.Handler.ServeHTTP(rr, httptest.NewRequest(http.MethodPost, DisplayCommandsPath+"/"+display[0].ID+"/ack", nil))
+	if rr.Code != http.StatusForbidden {
+		t.Errorf("❌ display ack from another host returned %d, want 403", rr.Code)
+	}
+
+	rr = httptest.NewRecorder()
+	sign.httpd.Handler.ServeHTTP(rr, displayRequest(http.MethodPost, DisplayCommandsPath+"/"+display[0].ID+"/ack"))
+	if rr.Code != http.StatusNoContent {
+		t.Errorf("❌ display ack returned %d, want 204", rr.Code)
+	}
+	rr = httptest.NewRecorder()
+	sign.httpd.Handler.ServeHTTP(rr, displayRequest(http.MethodPost, DisplayCommandsPath+"/"+display[0].ID+"/ack"))
+	if rr.Code != http.StatusNotFound {
+		t.Errorf("❌ repeated display ack returned %d, want 404", rr.Code)
+	}
+
+	// The next heartbeat reports both outcomes
+	if err := r.send(); err != nil {
+		t.Fatalf("❌ send() unexpected error: %v", err)
+	}
+
+	req, _ := http.NewRequest(http.MethodGet, controllerHTTP.URL+fleet.CommandsPath+"?sign=ballroom-a", nil)
+	req.Header.Set("Authorization", "Bearer "+adminToken)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("❌ command log request failed: %v", err)
+	}
+	defer resp.Body.Close()
+
+	var log []fleet.CommandRecord
+	if err := json.NewDecoder(resp.Body).Decode(&log); err != nil {
+		t.Fatalf("❌ Failed to decode command log: %v", err)
+	}
+	if len(log) != 2 {
+		t.Fatalf("❌ command log has %d records, want 2", len(log))
+	}
+	for _, rec := range log {
+		if rec.Status != fleet.StatusDone || rec.Delivered == nil || rec.Acked == nil {
+			t.Errorf("❌ %s: status %s, delivered %v, acked %v", rec.Name, rec.Status, rec.Delivered, rec.Acked)
+		} else {
+			t.Logf("✅ %s acknowledged", rec.Name)
+		}
+	}
+}
+
+func TestCommandRunnerPrune(t *testing.T) {
+	c := newCommandRunner(func() error { return nil })
+	c.run([]fleet.Command{
+		{ID: "old", Name: fleet.CommandIdentify},
+		{ID: "new", Name: fleet.CommandReload},
+		{ID: "refresh", Name: fleet.CommandRefresh},
+	})
+
+	// The display never picked up the first command
+	c.mutex.Lock()
+	c.display[0].queued = time.Now().Add(-displayCommandTTL - time.Minute)
+	c.mutex.Unlock()
+
+	rr := httptest.NewRecorder()
+	c.handleDisplayCommands(rr, displayRequest(http.MethodGet, DisplayCommandsPath))
+	var display []fleet.Command
+	if err := json.Unmarshal(rr.Body.Bytes(), &display); err != nil || len(display) != 1 || display[0].ID != "new" {
+		t.Fatalf("❌ display commands = %+v (%v), want only new", display, err)
+	}
+
+	acks := c.takeAcks()
+	i := slices.IndexFunc(acks, func(a fleet.Ack) bool { return a.ID == "old" })
+	if i < 0 || acks[i].Status != fleet.StatusFailed {
+		t.Errorf("❌ acks = %+v, want old failed", acks)
+	} else {
+		t.Logf("✅ expired command reported as %s: %s", acks[i].Status, acks[i].Error)
+	}
+
+	// Once the controller stops sending finished commands they are forgotten
+	c.run(nil)
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if len(c.results) != 0 || c.seen["old"] || c.seen["refresh"] {
+		t.Errorf("❌ after prune results = %v, seen = %v, want finished commands gone", c.results, c.seen)
+	}
+	if !c.seen["new"] {
+		t.Errorf("❌ pending command new was forgotten")
+	}
+}
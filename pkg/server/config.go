@@ -2,17 +2,55 @@ package server
 
 import (
 	"fmt"
-	"log"
+	"net"
 	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
 	"strconv"
+	"strings"
 	"time"
+
+	"github.com/kylerisse/go-signs/pkg/branding"
+	"github.com/kylerisse/go-signs/pkg/fleet"
+	"github.com/kylerisse/go-signs/pkg/sponsor"
 )
 
 // Config server configuration
 type Config struct {
-	Address         string
-	ScheduleJSONurl string
-	RefreshInterval time.Duration
+	Address          string   // Primary listen address, the first of Addresses
+	Addresses        []string // All listen addresses
+	ScheduleJSONurl  string   // Primary feed URL, the first of ScheduleJSONurls
+	ScheduleJSONurls []string // All feed URLs in priority order
+	RefreshInterval  time.Duration
+	Mirror           bool   // Serve the raw feed for other signs at schedule.FeedPath
+	SponsorsFile     string // Sponsor tier manifest replacing the embedded one
+	SponsorImagesDir string // Sponsor images served over the embedded ones
+	StrictSponsors   bool   // Refuse sponsor tiers with missing, broken or wrongly sized images
+	ImpressionsDB    string // BoltDB file counting sponsor impressions, none when empty
+	AdminToken       string // Secret for the sponsor admin API, off when empty
+	DisplayDir       string // Display build served instead of the embedded one, for development
+	BrandingFile     string // Event name, logos, Wi-Fi and colors for the display, SCaLE's when empty
+	BrandingAssets   string // Directory of the images the branding file names
+	TLSCertFile      string // Serve HTTPS when both cert and key are set
+	TLSKeyFile       string
+	RedirectAddress  string // Optional plain HTTP listener redirecting to HTTPS
+
+	SignID            string        // Identifies this sign to the fleet controller
+	FleetToken        string        // Shared secret for fleet requests
+	ControllerURL     string        // Send heartbeats to this controller when set
+	HeartbeatInterval time.Duration // How often to send heartbeats
+	Controller        bool          // Accept heartbeats from other signs
+	StaleAfter        time.Duration // Flag signs silent for longer than this
+	ProfilesFile      string        // Per-sign profiles the controller hands out
+	FleetAdminToken   string        // Secret for listing signs and queueing commands on the controller
+
+	Advertise          bool     // Announce this instance on the local network over mDNS
+	Browse             bool     // Find other instances over mDNS
+	TrustedHubs        []string // Hubs found over mDNS whose feeds are used as fallbacks, by name or address
+	DiscoveryInterface string   // Network interface for mDNS, all of them when empty
+
+	Profile fleet.Profile // Local display settings overriding the controller's
 }
 
 // NewConfig creates a new Config with validation
@@ -32,13 +70,385 @@ func NewConfig(listenPort string, jsonEndpoint string, refreshInterval int) (Con
 		return Config{}, fmt.Errorf("invalid refresh interval: %w", err)
 	}
 
+	address := fmt.Sprintf(":%v", listenPort)
 	return Config{
-		Address:         fmt.Sprintf(":%v", listenPort),
-		ScheduleJSONurl: jsonEndpoint,
-		RefreshInterval: time.Duration(refreshInterval) * time.Minute,
+		Address:          address,
+		Addresses:        []string{address},
+		ScheduleJSONurl:  jsonEndpoint,
+		ScheduleJSONurls: []string{jsonEndpoint},
+		RefreshInterval:  time.Duration(refreshInterval) * time.Minute,
 	}, nil
 }
 
+// SetListenAddresses replaces the port based listen address with one or more
+// full host:port addresses. An empty list keeps the current address.
+func (c *Config) SetListenAddresses(addrs []string) error {
+	if len(addrs) == 0 {
+		return nil
+	}
+
+	for _, addr := range addrs {
+		if err := validateListenAddress(addr); err != nil {
+			return fmt.Errorf("invalid listen address: %w", err)
+		}
+	}
+
+	c.Addresses = append([]string(nil), addrs...)
+	c.Address = c.Addresses[0]
+	return nil
+}
+
+// SetFeedFallbacks adds feed URLs to try, in order, when the primary feed
+// fails, such as a hub sign or a file:// URL on a USB stick
+func (c *Config) SetFeedFallbacks(urls []string) error {
+	for _, u := range urls {
+		if err := validateFeedURL(u); err != nil {
+			return fmt.Errorf("invalid feed fallback: %w", err)
+		}
+		if slices.Contains(c.ScheduleJSONurls, u) {
+			return fmt.Errorf("invalid feed fallback: %s is listed twice", u)
+		}
+		c.ScheduleJSONurls = append(c.ScheduleJSONurls, u)
+	}
+
+	return nil
+}
+
+// SetSponsorsFile replaces the embedded sponsor tiers with the manifest at
+// path. An empty path keeps the embedded tiers.
+func (c *Config) SetSponsorsFile(path string) error {
+	if path == "" {
+		return nil
+	}
+
+	if _, err := sponsor.LoadManifest(path); err != nil {
+		return fmt.Errorf("invalid sponsors file: %w", err)
+	}
+
+	c.SponsorsFile = path
+	return nil
+}
+
+// SetSponsorImagesDir serves the images in dir over the embedded sponsor
+// images. An empty dir serves the embedded images only.
+func (c *Config) SetSponsorImagesDir(dir string) error {
+	if dir == "" {
+		return nil
+	}
+
+	info, err := os.Stat(dir)
+	if err != nil {
+		return fmt.Errorf("invalid sponsor images directory: %w", err)
+	}
+	if !info.IsDir() {
+		return fmt.Errorf("invalid sponsor images directory: %s is not a directory", dir)
+	}
+
+	c.SponsorImagesDir = dir
+	return nil
+}
+
+// SetAdminToken enables the sponsor admin API for requests carrying token. It
+// stores logos and tiers on disk, so it requires the sponsors file and images
+// directory to be set first.
+func (c *Config) SetAdminToken(token string) error {
+	if token == "" {
+		return nil
+	}
+
+	if c.SponsorsFile == "" || c.SponsorImagesDir == "" {
+		return fmt.Errorf("invalid admin token: the admin API requires -sponsors and -sponsor-images")
+	}
+
+	if len(token) < 16 {
+		return fmt.Errorf("invalid admin token: must be at least 16 characters")
+	}
+
+	c.AdminToken = token
+	return nil
+}
+
+// SetFleetAdminToken lets requests carrying token list the signs and queue
+// commands on the controller. It must differ from the fleet token every sign
+// holds, so it requires the controller to be set first.
+func (c *Config) SetFleetAdminToken(token string) error {
+	if token == "" {
+		return nil
+	}
+
+	if !c.Controller {
+		return fmt.Errorf("invalid fleet admin token: requires -controller")
+	}
+
+	if len(token) < 16 {
+		return fmt.Errorf("invalid fleet admin token: must be at least 16 characters")
+	}
+
+	if token == c.FleetToken {
+		return fmt.Errorf("invalid fleet admin token: must differ from the fleet token")
+	}
+
+	c.FleetAdminToken = token
+	return nil
+}
+
+// SetDisplayDir serves the display built into dir instead of the embedded one.
+// An empty dir serves the embedded display.
+func (c *Config) SetDisplayDir(dir string) error {
+	if dir == "" {
+		return nil
+	}
+
+	info, err := os.Stat(dir)
+	if err != nil {
+		return fmt.Errorf("invalid display directory: %w", err)
+	}
+	if !info.IsDir() {
+		return fmt.Errorf("invalid display directory: %s is not a directory", dir)
+	}
+
+	c.DisplayDir = dir
+	return nil
+}
+
+// SetBranding dresses the display with the branding file at path, whose
+// images are in assetsDir or, when that is empty, next to the file. An empty
+// path keeps the SCaLE branding.
+func (c *Config) SetBranding(path string, assetsDir string) error {
+	if path == "" {
+		if assetsDir != "" {
+			return fmt.Errorf("invalid branding assets: -branding-assets requires -branding")
+		}
+		return nil
+	}
+
+	if assetsDir == "" {
+		assetsDir = filepath.Dir(path)
+	}
+	info, err := os.Stat(assetsDir)
+	if err != nil {
+		return fmt.Errorf("invalid branding assets: %w", err)
+	}
+	if !info.IsDir() {
+		return fmt.Errorf("invalid branding assets: %s is not a directory", assetsDir)
+	}
+
+	if _, err := branding.Load(path, assetsDir); err != nil {
+		return fmt.Errorf("invalid branding file: %w", err)
+	}
+
+	c.BrandingFile = path
+	c.BrandingAssets = assetsDir
+	return nil
+}
+
+// SetTLS enables HTTPS on all listen addresses using the given certificate and
+// key files. Both must be set, or neither to keep serving plain HTTP.
+func (c *Config) SetTLS(certFile string, keyFile string) error {
+	if err := validateTLSFiles(certFile, keyFile); err != nil {
+		return fmt.Errorf("invalid TLS configuration: %w", err)
+	}
+
+	c.TLSCertFile = certFile
+	c.TLSKeyFile = keyFile
+	return nil
+}
+
+// SetRedirectAddress adds a plain HTTP listener that redirects to HTTPS on the
+// port of the first host:port listen address. It requires TLS and the listen
+// addresses to be configured first.
+func (c *Config) SetRedirectAddress(addr string) error {
+	if addr == "" {
+		return nil
+	}
+
+	if !c.TLSEnabled() {
+		return fmt.Errorf("invalid redirect address: HTTPS redirect requires -tls-cert and -tls-key")
+	}
+	if c.AdvertisedPort() == 0 {
+		return fmt.Errorf("invalid redirect address: HTTPS redirect requires a host:port listen address")
+	}
+
+	if err := validateListenAddress(addr); err != nil {
+		return fmt.Errorf("invalid redirect address: %w", err)
+	}
+
+	c.RedirectAddress = addr
+	return nil
+}
+
+// SetReporter makes this sign send a heartbeat to the controller at
+// controllerURL every interval seconds. An empty URL disables reporting.
+func (c *Config) SetReporter(controllerURL string, signID string, token string, interval int) error {
+	if controllerURL == "" {
+		return nil
+	}
+
+	if err := validateURL(controllerURL); err != nil {
+		return fmt.Errorf("invalid controller URL: %w", err)
+	}
+
+	if err := validateSignID(signID); err != nil {
+		return fmt.Errorf("invalid sign ID: %w", err)
+	}
+
+	if err := validateFleetToken(token); err != nil {
+		return fmt.Errorf("invalid fleet token: %w", err)
+	}
+
+	if err := validateHeartbeatInterval(interval); err != nil {
+		return fmt.Errorf("invalid heartbeat interval: %w", err)
+	}
+
+	c.ControllerURL = controllerURL
+	c.SignID = signID
+	c.FleetToken = token
+	c.HeartbeatInterval = time.Duration(interval) * time.Second
+	return nil
+}
+
+// SetController makes this server accept heartbeats from other signs and flag
+// any silent for more than staleAfter seconds
+func (c *Config) SetController(enabled bool, token string, staleAfter int) error {
+	if !enabled {
+		return nil
+	}
+
+	if err := validateFleetToken(token); err != nil {
+		return fmt.Errorf("invalid fleet token: %w", err)
+	}
+
+	if staleAfter < 1 {
+		return fmt.Errorf("invalid stale threshold: must be at least 1 second, got %d", staleAfter)
+	}
+
+	c.Controller = true
+	c.FleetToken = token
+	c.StaleAfter = time.Duration(staleAfter) * time.Second
+	return nil
+}
+
+// SetImpressionsDB counts the sponsor impressions reported by displays in a
+// database at path, created if needed. An empty path leaves counting off.
+func (c *Config) SetImpressionsDB(path string) error {
+	if path == "" {
+		return nil
+	}
+
+	info, err := os.Stat(filepath.Dir(path))
+	if err != nil {
+		return fmt.Errorf("invalid impressions database: %w", err)
+	}
+	if !info.IsDir() {
+		return fmt.Errorf("invalid impressions database: %s is not a directory", filepath.Dir(path))
+	}
+	if info, err := os.Stat(path); err == nil && info.IsDir() {
+		return fmt.Errorf("invalid impressions database: %s is a directory", path)
+	}
+
+	c.ImpressionsDB = path
+	return nil
+}
+
+// SetProfilesFile makes the controller reply to heartbeats with the per-sign
+// profiles in path. It requires the controller to be enabled first.
+func (c *Config) SetProfilesFile(path string) error {
+	if path == "" {
+		return nil
+	}
+
+	if !c.Controller {
+		return fmt.Errorf("invalid profiles file: profiles require -controller")
+	}
+
+	if _, err := fleet.LoadProfiles(path); err != nil {
+		return fmt.Errorf("invalid profiles file: %w", err)
+	}
+
+	c.ProfilesFile = path
+	return nil
+}
+
+// SetProfile sets the ID of this sign and the local display settings, which
+// override any profile from the controller
+func (c *Config) SetProfile(signID string, p fleet.Profile) error {
+	if err := validateSignID(signID); err != nil {
+		return fmt.Errorf("invalid sign ID: %w", err)
+	}
+
+	if err := p.Validate(); err != nil {
+		return fmt.Errorf("invalid profile: %w", err)
+	}
+
+	c.SignID = signID
+	c.Profile = p
+	return nil
+}
+
+// SetDiscovery announces this instance on the local network and, with browse,
+// looks for hubs and other signs, on the named interface or all of them
+func (c *Config) SetDiscovery(advertise bool, browse bool, ifname string) error {
+	if !advertise && !browse {
+		return nil
+	}
+
+	if ifname != "" {
+		if _, err := net.InterfaceByName(ifname); err != nil {
+			return fmt.Errorf("invalid mDNS interface: %w", err)
+		}
+	}
+
+	if advertise && c.AdvertisedPort() == 0 {
+		return fmt.Errorf("invalid mDNS configuration: advertising needs a host:port listen address")
+	}
+
+	c.Advertise = advertise
+	c.Browse = browse
+	c.DiscoveryInterface = ifname
+	return nil
+}
+
+// SetTrustedHubs lets the feeds of the named hubs be used as fallbacks when
+// mDNS browsing finds them. Hubs are matched by instance name, sign ID, host
+// name or address; any other hub found is only listed.
+func (c *Config) SetTrustedHubs(hubs []string) error {
+	if len(hubs) == 0 {
+		return nil
+	}
+
+	if !c.Browse {
+		return fmt.Errorf("invalid mDNS hubs: -mdns-hub requires -mdns-browse")
+	}
+	for _, h := range hubs {
+		if strings.TrimSpace(h) == "" {
+			return fmt.Errorf("invalid mDNS hubs: empty hub name")
+		}
+	}
+
+	c.TrustedHubs = append([]string(nil), hubs...)
+	return nil
+}
+
+// AdvertisedPort returns the port of the first host:port listen address, or 0
+// when every address is a unix or systemd socket
+func (c Config) AdvertisedPort() int {
+	for _, addr := range c.Addresses {
+		_, port, err := net.SplitHostPort(addr)
+		if err != nil {
+			continue
+		}
+		if n, err := strconv.Atoi(port); err == nil {
+			return n
+		}
+	}
+	return 0
+}
+
+// TLSEnabled reports whether the server should serve HTTPS
+func (c Config) TLSEnabled() bool {
+	return c.TLSCertFile != "" && c.TLSKeyFile != ""
+}
+
 // validatePort checks if the port is valid
 func validatePort(port string) error {
 	// Convert to integer
@@ -55,6 +465,103 @@ func validatePort(port string) error {
 	return nil
 }
 
+// validateListenAddress checks if the address is a valid host:port, where the
+// host may be empty, a hostname, an IPv4 address or a bracketed IPv6 address.
+// unix:/path/to.sock and systemd[:name] are also accepted.
+func validateListenAddress(addr string) error {
+	if path, ok := strings.CutPrefix(addr, unixPrefix); ok {
+		if path == "" {
+			return fmt.Errorf("unix address must include a socket path")
+		}
+		return nil
+	}
+
+	if name, ok := strings.CutPrefix(addr, systemdPrefix); ok {
+		if name != "" && (!strings.HasPrefix(name, ":") || len(name) == 1) {
+			return fmt.Errorf("systemd address must be systemd or systemd:name")
+		}
+		return nil
+	}
+
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		return fmt.Errorf("address must be host:port: %v", err)
+	}
+
+	if err := validatePort(port); err != nil {
+		return err
+	}
+
+	if host != "" && net.ParseIP(host) == nil {
+		if _, err := url.Parse("http://" + host); err != nil {
+			return fmt.Errorf("invalid host %q: %v", host, err)
+		}
+	}
+
+	return nil
+}
+
+// validateTLSFiles checks that the certificate and key are given together and
+// can be read
+func validateTLSFiles(certFile string, keyFile string) error {
+	if certFile == "" && keyFile == "" {
+		return nil
+	}
+
+	if certFile == "" || keyFile == "" {
+		return fmt.Errorf("certificate and key files must be set together")
+	}
+
+	for _, f := range []string{certFile, keyFile} {
+		info, err := os.Stat(f)
+		if err != nil {
+			return fmt.Errorf("unable to stat %s: %v", f, err)
+		}
+		if !info.Mode().IsRegular() {
+			return fmt.Errorf("%s is not a regular file", f)
+		}
+	}
+
+	return nil
+}
+
+// validateSignID checks if the sign ID is usable as a fleet identifier
+func validateSignID(id string) error {
+	if id == "" {
+		return fmt.Errorf("sign ID must not be empty")
+	}
+
+	if len(id) > 63 {
+		return fmt.Errorf("sign ID must be at most 63 characters, got %d", len(id))
+	}
+
+	for _, r := range id {
+		if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '-' || r == '_' || r == '.') {
+			return fmt.Errorf("sign ID may only contain letters, digits, '-', '_' and '.', got %q", id)
+		}
+	}
+
+	return nil
+}
+
+// validateFleetToken checks if the fleet token is long enough to be a secret
+func validateFleetToken(token string) error {
+	if len(token) < 16 {
+		return fmt.Errorf("fleet token must be at least 16 characters")
+	}
+
+	return nil
+}
+
+// validateHeartbeatInterval checks if the heartbeat interval is valid
+func validateHeartbeatInterval(interval int) error {
+	if interval < 5 {
+		return fmt.Errorf("heartbeat interval must be at least 5 seconds, got %d", interval)
+	}
+
+	return nil
+}
+
 // validateURL checks if the URL is valid
 func validateURL(urlStr string) error {
 	_, err := url.ParseRequestURI(urlStr)
@@ -78,6 +585,25 @@ func validateURL(urlStr string) error {
 	return nil
 }
 
+// validateFeedURL checks if the URL is a valid http or https URL, or a file
+// URL with an absolute path
+func validateFeedURL(urlStr string) error {
+	u, err := url.Parse(urlStr)
+	if err != nil {
+		return fmt.Errorf("unable to parse URL: %v", err)
+	}
+
+	if u.Scheme != "file" {
+		return validateURL(urlStr)
+	}
+
+	if u.Host != "" || !strings.HasPrefix(u.Path, "/") {
+		return fmt.Errorf("file URL must have an absolute path, such as file:///media/usb/sign.json")
+	}
+
+	return nil
+}
+
 // validateRefreshInterval checks if the refresh interval is valid
 func validateRefreshInterval(interval int) error {
 	if interval < 1 {
@@ -86,7 +612,7 @@ func validateRefreshInterval(interval int) error {
 
 	if interval > 60 {
 		// This is just a warning, not an error
-		log.Printf("Warning: Refresh interval is set to %d minutes, which is quite long", interval)
+		logger().Warn("refresh interval is quite long", "minutes", interval)
 	}
 
 	return nil
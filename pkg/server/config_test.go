@@ -1,9 +1,13 @@
 package server
 
 import (
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 	"time"
+
+	"github.com/kylerisse/go-signs/pkg/fleet"
 )
 
 func TestNewConfig(t *testing.T) {
@@ -248,3 +252,617 @@ func TestValidateRefreshInterval(t *testing.T) {
 		})
 	}
 }
+
+func TestValidateListenAddress(t *testing.T) {
+	tests := []struct {
+		addr    string
+		wantErr bool
+	}{
+		{":2017", false},
+		{"0.0.0.0:80", false},
+		{"192.168.1.10:2017", false},
+		{"[::1]:2017", false},
+		{"[::]:443", false},
+		{"signs.local:8080", false},
+		{"unix:/run/go-signs/go-signs.sock", false},
+		{"unix:go-signs.sock", false},
+		{"systemd", false},
+		{"systemd:http", false},
+		{"unix:", true},
+		{"systemd:", true},
+		{"systemdhttp", true},
+		{"2017", true},
+		{"::1:2017", true},
+		{"127.0.0.1:0", true},
+		{"127.0.0.1:http", true},
+		{"", true},
+	}
+
+	for _, tt := range tests {
+		t.Run("addr_"+tt.addr, func(t *testing.T) {
+			err := validateListenAddress(tt.addr)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("❌ validateListenAddress(%s) error = %v, wantErr %v", tt.addr, err, tt.wantErr)
+			} else {
+				t.Logf("✅ validateListenAddress(%s) returned expected result: %v", tt.addr, err)
+			}
+		})
+	}
+}
+
+func TestConfigSetListenAddresses(t *testing.T) {
+	conf, err := NewConfig("2017", "https://example.com/schedule.json", 5)
+	if err != nil {
+		t.Fatalf("❌ NewConfig() unexpected error: %v", err)
+	}
+
+	// An empty list keeps the -port address
+	if err := conf.SetListenAddresses(nil); err != nil {
+		t.Fatalf("❌ SetListenAddresses(nil) unexpected error: %v", err)
+	}
+	if conf.Address != ":2017" || len(conf.Addresses) != 1 {
+		t.Errorf("❌ Address = %s, Addresses = %v, want :2017 only", conf.Address, conf.Addresses)
+	}
+
+	addrs := []string{"127.0.0.1:8080", "[::1]:8080"}
+	if err := conf.SetListenAddresses(addrs); err != nil {
+		t.Fatalf("❌ SetListenAddresses() unexpected error: %v", err)
+	}
+	if conf.Address != "127.0.0.1:8080" {
+		t.Errorf("❌ Address = %s, want 127.0.0.1:8080", conf.Address)
+	}
+	if len(conf.Addresses) != 2 || conf.Addresses[1] != "[::1]:8080" {
+		t.Errorf("❌ Addresses = %v, want %v", conf.Addresses, addrs)
+	}
+
+	if err := conf.SetListenAddresses([]string{"127.0.0.1:9090", "nope"}); err == nil {
+		t.Errorf("❌ SetListenAddresses() with invalid address expected error, got nil")
+	}
+	if conf.Address != "127.0.0.1:8080" {
+		t.Errorf("❌ Address changed after failed SetListenAddresses: %s", conf.Address)
+	}
+}
+
+func TestConfigSetTLS(t *testing.T) {
+	certFile, keyFile := writeTestCertificate(t, t.TempDir(), "localhost")
+
+	tests := []struct {
+		name        string
+		cert        string
+		key         string
+		redirect    string
+		listen      []string
+		wantTLS     bool
+		wantErr     bool
+		errContains string
+	}{
+		{name: "No TLS", wantTLS: false},
+		{name: "TLS", cert: certFile, key: keyFile, wantTLS: true},
+		{name: "TLS with redirect", cert: certFile, key: keyFile, redirect: ":8080", wantTLS: true},
+		{name: "Cert without key", cert: certFile, wantErr: true, errContains: "must be set together"},
+		{name: "Missing cert", cert: "/nonexistent/cert.pem", key: keyFile, wantErr: true, errContains: "unable to stat"},
+		{name: "Redirect without TLS", redirect: ":8080", wantErr: true, errContains: "requires -tls-cert"},
+		{name: "Invalid redirect", cert: certFile, key: keyFile, redirect: "8080", wantErr: true, errContains: "invalid redirect address"},
+		{name: "Redirect to a unix socket", cert: certFile, key: keyFile, redirect: ":8080", listen: []string{"unix:go-signs.sock"}, wantErr: true, errContains: "requires a host:port listen address"},
+		{name: "Redirect past a unix socket", cert: certFile, key: keyFile, redirect: ":8080", listen: []string{"unix:go-signs.sock", ":8443"}, wantTLS: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			conf, err := NewConfig("2017", "https://example.com/schedule.json", 5)
+			if err != nil {
+				t.Fatalf("❌ NewConfig() unexpected error: %v", err)
+			}
+
+			if tt.listen != nil {
+				if err := conf.SetListenAddresses(tt.listen); err != nil {
+					t.Fatalf("❌ SetListenAddresses() unexpected error: %v", err)
+				}
+			}
+
+			err = conf.SetTLS(tt.cert, tt.key)
+			if err == nil {
+				err = conf.SetRedirectAddress(tt.redirect)
+			}
+
+			if tt.wantErr {
+				if err == nil || !strings.Contains(err.Error(), tt.errContains) {
+					t.Errorf("❌ error = %v, want error containing %s", err, tt.errContains)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("❌ unexpected error: %v", err)
+			}
+			if conf.TLSEnabled() != tt.wantTLS {
+				t.Errorf("❌ TLSEnabled() = %v, want %v", conf.TLSEnabled(), tt.wantTLS)
+			}
+			if conf.RedirectAddress != tt.redirect {
+				t.Errorf("❌ RedirectAddress = %s, want %s", conf.RedirectAddress, tt.redirect)
+			}
+		})
+	}
+}
+
+func TestConfigFleet(t *testing.T) {
+	const token = "0123456789abcdef"
+
+	tests := []struct {
+		name        string
+		url         string
+		signID      string
+		token       string
+		interval    int
+		controller  bool
+		staleAfter  int
+		wantErr     bool
+		errContains string
+	}{
+		{name: "Disabled", signID: "", interval: 0},
+		{name: "Reporter", url: "http://controller:2017", signID: "ballroom-a", token: token, interval: 60},
+		{name: "Controller", signID: "hub", token: token, controller: true, staleAfter: 180},
+		{name: "Reporter bad URL", url: "controller:2017", signID: "ballroom-a", token: token, interval: 60, wantErr: true, errContains: "invalid controller URL"},
+		{name: "Reporter bad sign ID", url: "http://controller:2017", signID: "ballroom a", token: token, interval: 60, wantErr: true, errContains: "invalid sign ID"},
+		{name: "Reporter short token", url: "http://controller:2017", signID: "ballroom-a", token: "short", interval: 60, wantErr: true, errContains: "at least 16 characters"},
+		{name: "Reporter fast heartbeat", url: "http://controller:2017", signID: "ballroom-a", token: token, interval: 1, wantErr: true, errContains: "at least 5 seconds"},
+		{name: "Controller without token", controller: true, staleAfter: 180, wantErr: true, errContains: "invalid fleet token"},
+		{name: "Controller zero stale", controller: true, token: token, staleAfter: 0, wantErr: true, errContains: "invalid stale threshold"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			conf, err := NewConfig("2017", "https://example.com/schedule.json", 5)
+			if err != nil {
+				t.Fatalf("❌ NewConfig() unexpected error: %v", err)
+			}
+
+			err = conf.SetReporter(tt.url, tt.signID, tt.token, tt.interval)
+			if err == nil {
+				err = conf.SetController(tt.controller, tt.token, tt.staleAfter)
+			}
+
+			if tt.wantErr {
+				if err == nil || !strings.Contains(err.Error(), tt.errContains) {
+					t.Errorf("❌ error = %v, want error containing %s", err, tt.errContains)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("❌ unexpected error: %v", err)
+			}
+			if conf.ControllerURL != tt.url || conf.Controller != tt.controller {
+				t.Errorf("❌ ControllerURL = %s, Controller = %v", conf.ControllerURL, conf.Controller)
+			}
+			if tt.url != "" && conf.HeartbeatInterval != time.Duration(tt.interval)*time.Second {
+				t.Errorf("❌ HeartbeatInterval = %v, want %ds", conf.HeartbeatInterval, tt.interval)
+			}
+		})
+	}
+}
+
+func TestConfigProfile(t *testing.T) {
+	profiles := filepath.Join(t.TempDir(), "profiles.toml")
+	if err := os.WriteFile(profiles, []byte("[signs.ballroom-a]\nroom = \"Ballroom A\"\n"), 0o644); err != nil {
+		t.Fatalf("❌ unable to write profiles: %v", err)
+	}
+
+	tests := []struct {
+		name        string
+		signID      string
+		profile     fleet.Profile
+		controller  bool
+		profiles    string
+		wantErr     bool
+		errContains string
+	}{
+		{name: "Defaults", signID: "ballroom-a"},
+		{name: "Local overrides", signID: "ballroom-a", profile: fleet.Profile{Layout: "room", Room: "Ballroom A", SponsorTiers: []string{"gold"}}},
+		{name: "Controller profiles", signID: "hub", controller: true, profiles: profiles},
+		{name: "Bad sign ID", signID: "", wantErr: true, errContains: "invalid sign ID"},
+		{name: "Bad layout", signID: "ballroom-a", profile: fleet.Profile{Layout: "portrait"}, wantErr: true, errContains: "invalid profile"},
+		{name: "Profiles without controller", signID: "hub", profiles: profiles, wantErr: true, errContains: "require -controller"},
+		{name: "Missing profiles", signID: "hub", controller: true, profiles: profiles + ".missing", wantErr: true, errContains: "invalid profiles file"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			conf, err := NewConfig("2017", "https://example.com/schedule.json", 5)
+			if err != nil {
+				t.Fatalf("❌ NewConfig() unexpected error: %v", err)
+			}
+
+			err = conf.SetProfile(tt.signID, tt.profile)
+			if err == nil {
+				err = conf.SetController(tt.controller, "0123456789abcdef", 180)
+			}
+			if err == nil {
+				err = conf.SetProfilesFile(tt.profiles)
+			}
+
+			if tt.wantErr {
+				if err == nil || !strings.Contains(err.Error(), tt.errContains) {
+					t.Errorf("❌ error = %v, want error containing %s", err, tt.errContains)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("❌ unexpected error: %v", err)
+			}
+			if conf.SignID != tt.signID || conf.Profile.Layout != tt.profile.Layout || conf.ProfilesFile != tt.profiles {
+				t.Errorf("❌ SignID = %s, Profile = %+v, ProfilesFile = %s", conf.SignID, conf.Profile, conf.ProfilesFile)
+			}
+		})
+	}
+}
+
+func TestConfigFeedFallbacks(t *testing.T) {
+	const primary = "https://example.com/schedule.json"
+
+	tests := []struct {
+		name        string
+		fallbacks   []string
+		want        []string
+		wantErr     bool
+		errContains string
+	}{
+		{name: "None", want: []string{primary}},
+		{name: "Hub and USB", fallbacks: []string{"http://hub.local:2017/sign.json", "file:///media/usb/sign.json"},
+			want: []string{primary, "http://hub.local:2017/sign.json", "file:///media/usb/sign.json"}},
+		{name: "Relative file", fallbacks: []string{"file://media/usb/sign.json"}, wantErr: true, errContains: "absolute path"},
+		{name: "Bad scheme", fallbacks: []string{"ftp://hub.local/sign.json"}, wantErr: true, errContains: "invalid feed fallback"},
+		{name: "Duplicate", fallbacks: []string{primary}, wantErr: true, errContains: "listed twice"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			conf, err := NewConfig("2017", primary, 5)
+			if err != nil {
+				t.Fatalf("❌ NewConfig() unexpected error: %v", err)
+			}
+
+			err = conf.SetFeedFallbacks(tt.fallbacks)
+			if tt.wantErr {
+				if err == nil || !strings.Contains(err.Error(), tt.errContains) {
+					t.Errorf("❌ error = %v, want error containing %s", err, tt.errContains)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("❌ unexpected error: %v", err)
+			}
+			if strings.Join(conf.ScheduleJSONurls, " ") != strings.Join(tt.want, " ") || conf.ScheduleJSONurl != primary {
+				t.Errorf("❌ ScheduleJSONurls = %v, want %v", conf.ScheduleJSONurls, tt.want)
+			}
+		})
+	}
+}
+
+func TestConfigDiscovery(t *testing.T) {
+	tests := []struct {
+		name        string
+		listen      []string
+		advertise   bool
+		browse      bool
+		ifname      string
+		wantPort    int
+		wantErr     bool
+		errContains string
+	}{
+		{name: "Disabled", listen: []string{"unix:/run/go-signs.sock"}},
+		{name: "Advertise", listen: []string{"unix:/run/go-signs.sock", "10.0.0.5:8080"}, advertise: true, wantPort: 8080},
+		{name: "Advertise without port", listen: []string{"unix:/run/go-signs.sock", "systemd:http"}, advertise: true,
+			wantErr: true, errContains: "host:port listen address"},
+		{name: "Browse without port", listen: []string{"systemd"}, browse: true},
+		{name: "Unknown interface", advertise: true, ifname: "no-such-if0", wantErr: true, errContains: "invalid mDNS interface"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			conf, err := NewConfig("2017", "https://example.com/schedule.json", 5)
+			if err != nil {
+				t.Fatalf("❌ NewConfig() unexpected error: %v", err)
+			}
+			if err := conf.SetListenAddresses(tt.listen); err != nil {
+				t.Fatalf("❌ SetListenAddresses() unexpected error: %v", err)
+			}
+
+			err = conf.SetDiscovery(tt.advertise, tt.browse, tt.ifname)
+			if tt.wantErr {
+				if err == nil || !strings.Contains(err.Error(), tt.errContains) {
+					t.Errorf("❌ error = %v, want error containing %s", err, tt.errContains)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("❌ unexpected error: %v", err)
+			}
+			if conf.Advertise != tt.advertise || conf.Browse != tt.browse {
+				t.Errorf("❌ Advertise, Browse = %v, %v, want %v, %v", conf.Advertise, conf.Browse, tt.advertise, tt.browse)
+			}
+			if tt.advertise && conf.AdvertisedPort() != tt.wantPort {
+				t.Errorf("❌ AdvertisedPort() = %d, want %d", conf.AdvertisedPort(), tt.wantPort)
+			}
+		})
+	}
+}
+
+func TestConfigTrustedHubs(t *testing.T) {
+	conf, err := NewConfig("2017", "https://example.com/schedule.json", 5)
+	if err != nil {
+		t.Fatalf("❌ NewConfig() unexpected error: %v", err)
+	}
+
+	if err := conf.SetTrustedHubs([]string{"hub-1"}); err == nil || !strings.Contains(err.Error(), "requires -mdns-browse") {
+		t.Errorf("❌ SetTrustedHubs() without browsing error = %v, want requires -mdns-browse", err)
+	}
+
+	if err := conf.SetDiscovery(false, true, ""); err != nil {
+		t.Fatalf("❌ SetDiscovery() unexpected error: %v", err)
+	}
+	if err := conf.SetTrustedHubs([]string{" "}); err == nil {
+		t.Errorf("❌ SetTrustedHubs() with an empty name expected error, got nil")
+	}
+	if err := conf.SetTrustedHubs([]string{"hub-1", "10.0.0.5"}); err != nil {
+		t.Fatalf("❌ SetTrustedHubs() unexpected error: %v", err)
+	}
+	if strings.Join(conf.TrustedHubs, " ") != "hub-1 10.0.0.5" {
+		t.Errorf("❌ TrustedHubs = %v, want [hub-1 10.0.0.5]", conf.TrustedHubs)
+	}
+}
+
+func TestConfigSponsorImagesDir(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "logo.png")
+	if err := os.WriteFile(file, []byte("png"), 0600); err != nil {
+		t.Fatalf("❌ Failed to write fixture: %v", err)
+	}
+
+	tests := []struct {
+		name        string
+		dir         string
+		errContains string
+	}{
+		{name: "Embedded only", dir: ""},
+		{name: "Directory", dir: dir},
+		{name: "Missing", dir: filepath.Join(dir, "missing"), errContains: "invalid sponsor images directory"},
+		{name: "File", dir: file, errContains: "not a directory"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			conf, err := NewConfig("2017", "https://example.com/schedule.json", 5)
+			if err != nil {
+				t.Fatalf("❌ NewConfig() unexpected error: %v", err)
+			}
+
+			err = conf.SetSponsorImagesDir(tt.dir)
+			if tt.errContains != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.errContains) {
+					t.Errorf("❌ error = %v, want error containing %s", err, tt.errContains)
+				}
+				return
+			}
+			if err != nil || conf.SponsorImagesDir != tt.dir {
+				t.Errorf("❌ SetSponsorImagesDir() = %v, SponsorImagesDir = %q, want %q", err, conf.SponsorImagesDir, tt.dir)
+			}
+		})
+	}
+}
+
+func TestConfigImpressionsDB(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "notes.txt")
+	if err := os.WriteFile(file, []byte("notes"), 0600); err != nil {
+		t.Fatalf("❌ Failed to write fixture: %v", err)
+	}
+
+	tests := []struct {
+		name        string
+		path        string
+		errContains string
+	}{
+		{name: "Off", path: ""},
+		{name: "New file", path: filepath.Join(dir, "impressions.db")},
+		{name: "Missing directory", path: filepath.Join(dir, "missing", "impressions.db"), errContains: "invalid impressions database"},
+		{name: "Directory", path: dir, errContains: "is a directory"},
+		{name: "Under a file", path: filepath.Join(file, "impressions.db"), errContains: "not a directory"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			conf, err := NewConfig("2017", "https://example.com/schedule.json", 5)
+			if err != nil {
+				t.Fatalf("❌ NewConfig() unexpected error: %v", err)
+			}
+
+			err = conf.SetImpressionsDB(tt.path)
+			if tt.errContains != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.errContains) {
+					t.Errorf("❌ error = %v, want error containing %s", err, tt.errContains)
+				}
+				return
+			}
+			if err != nil || conf.ImpressionsDB != tt.path {
+				t.Errorf("❌ SetImpressionsDB() = %v, ImpressionsDB = %q, want %q", err, conf.ImpressionsDB, tt.path)
+			}
+		})
+	}
+}
+
+func TestConfigAdminToken(t *testing.T) {
+	dir := t.TempDir()
+	manifest := filepath.Join(dir, "sponsors.json")
+	if err := os.WriteFile(manifest, []byte(`{"tiers":[{"name":"gold","sponsors":[]}]}`), 0600); err != nil {
+		t.Fatalf("❌ Failed to write manifest: %v", err)
+	}
+
+	tests := []struct {
+		name        string
+		token       string
+		sponsors    string
+		images      string
+		errContains string
+	}{
+		{name: "Off", token: ""},
+		{name: "Enabled", token: "0123456789abcdef", sponsors: manifest, images: dir},
+		{name: "Short token", token: "short", sponsors: manifest, images: dir, errContains: "at least 16 characters"},
+		{name: "No sponsors file", token: "0123456789abcdef", images: dir, errContains: "requires -sponsors and -sponsor-images"},
+		{name: "No images directory", token: "0123456789abcdef", sponsors: manifest, errContains: "requires -sponsors and -sponsor-images"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			conf, err := NewConfig("2017", "https://example.com/schedule.json", 5)
+			if err != nil {
+				t.Fatalf("❌ NewConfig() unexpected error: %v", err)
+			}
+			if err := conf.SetSponsorsFile(tt.sponsors); err != nil {
+				t.Fatalf("❌ SetSponsorsFile() unexpected error: %v", err)
+			}
+			if err := conf.SetSponsorImagesDir(tt.images); err != nil {
+				t.Fatalf("❌ SetSponsorImagesDir() unexpected error: %v", err)
+			}
+
+			err = conf.SetAdminToken(tt.token)
+			if tt.errContains != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.errContains) {
+					t.Errorf("❌ error = %v, want error containing %s", err, tt.errContains)
+				}
+				return
+			}
+			if err != nil || conf.AdminToken != tt.token {
+				t.Errorf("❌ SetAdminToken() = %v, AdminToken = %q, want %q", err, conf.AdminToken, tt.token)
+			}
+		})
+	}
+}
+
+func TestConfigFleetAdminToken(t *testing.T) {
+	const fleetToken = "0123456789abcdef"
+
+	tests := []struct {
+		name        string
+		controller  bool
+		token       string
+		errContains string
+	}{
+		{name: "Off", controller: true, token: ""},
+		{name: "Enabled", controller: true, token: "fedcba9876543210"},
+		{name: "Short token", controller: true, token: "short", errContains: "at least 16 characters"},
+		{name: "Fleet token", controller: true, token: fleetToken, errContains: "must differ from the fleet token"},
+		{name: "Not a controller", token: "fedcba9876543210", errContains: "requires -controller"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			conf, err := NewConfig("2017", "https://example.com/schedule.json", 5)
+			if err != nil {
+				t.Fatalf("❌ NewConfig() unexpected error: %v", err)
+			}
+			if err := conf.SetController(tt.controller, fleetToken, 180); err != nil {
+				t.Fatalf("❌ SetController() unexpected error: %v", err)
+			}
+
+			err = conf.SetFleetAdminToken(tt.token)
+			if tt.errContains != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.errContains) {
+					t.Errorf("❌ error = %v, want error containing %s", err, tt.errContains)
+				}
+				return
+			}
+			if err != nil || conf.FleetAdminToken != tt.token {
+				t.Errorf("❌ SetFleetAdminToken() = %v, FleetAdminToken = %q, want %q", err, conf.FleetAdminToken, tt.token)
+			}
+		})
+	}
+}
+
+func TestConfigDisplayDir(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "index.html")
+	if err := os.WriteFile(file, []byte("<html></html>"), 0600); err != nil {
+		t.Fatalf("❌ Failed to write fixture: %v", err)
+	}
+
+	tests := []struct {
+		name        string
+		dir         string
+		errContains string
+	}{
+		{name: "Embedded", dir: ""},
+		{name: "Directory", dir: dir},
+		{name: "Missing", dir: filepath.Join(dir, "missing"), errContains: "invalid display directory"},
+		{name: "File", dir: file, errContains: "not a directory"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			conf, err := NewConfig("2017", "https://example.com/schedule.json", 5)
+			if err != nil {
+				t.Fatalf("❌ NewConfig() unexpected error: %v", err)
+			}
+
+			err = conf.SetDisplayDir(tt.dir)
+			if tt.errContains != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.errContains) {
+					t.Errorf("❌ error = %v, want error containing %s", err, tt.errContains)
+				}
+				return
+			}
+			if err != nil || conf.DisplayDir != tt.dir {
+				t.Errorf("❌ SetDisplayDir() = %v, DisplayDir = %q, want %q", err, conf.DisplayDir, tt.dir)
+			}
+		})
+	}
+}
+
+func TestConfigBranding(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "branding.toml")
+	if err := os.WriteFile(file, []byte("event-name = \"SCaLE 23x\"\n"), 0600); err != nil {
+		t.Fatalf("❌ Failed to write fixture: %v", err)
+	}
+	bad := filepath.Join(dir, "bad.toml")
+	if err := os.WriteFile(bad, []byte("[colors]\nprimary = \"blue\"\n"), 0600); err != nil {
+		t.Fatalf("❌ Failed to write fixture: %v", err)
+	}
+	assets := filepath.Join(dir, "assets")
+	os.Mkdir(assets, 0700)
+
+	tests := []struct {
+		name        string
+		path        string
+		assets      string
+		wantAssets  string
+		errContains string
+	}{
+		{name: "Default", path: "", assets: ""},
+		{name: "FileDirectory", path: file, assets: "", wantAssets: dir},
+		{name: "AssetsDirectory", path: file, assets: assets, wantAssets: assets},
+		{name: "AssetsWithoutFile", path: "", assets: assets, errContains: "requires -branding"},
+		{name: "MissingAssets", path: file, assets: filepath.Join(dir, "missing"), errContains: "invalid branding assets"},
+		{name: "AssetsFile", path: file, assets: file, errContains: "not a directory"},
+		{name: "MissingFile", path: filepath.Join(dir, "missing.toml"), errContains: "invalid branding file"},
+		{name: "BadColor", path: bad, errContains: "invalid branding file"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			conf, err := NewConfig("2017", "https://example.com/schedule.json", 5)
+			if err != nil {
+				t.Fatalf("❌ NewConfig() unexpected error: %v", err)
+			}
+
+			err = conf.SetBranding(tt.path, tt.assets)
+			if tt.errContains != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.errContains) {
+					t.Errorf("❌ error = %v, want error containing %s", err, tt.errContains)
+				}
+				return
+			}
+			if err != nil || conf.BrandingFile != tt.path || conf.BrandingAssets != tt.wantAssets {
+				t.Errorf("❌ SetBranding() = %v, BrandingFile = %q, BrandingAssets = %q", err, conf.BrandingFile, conf.BrandingAssets)
+			}
+		})
+	}
+}
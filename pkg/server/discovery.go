@@ -0,0 +1,115 @@
+package server
+
+import (
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/kylerisse/go-signs/pkg/discovery"
+	"github.com/kylerisse/go-signs/pkg/schedule"
+)
+
+// discoveryGroup is the multicast group mDNS runs on, changed by tests
+var discoveryGroup = discovery.DefaultGroup
+
+// newDiscovery joins mDNS and advertises this instance as configured by c. It
+// returns nil when discovery is disabled or unavailable.
+func newDiscovery(c Config, sch *schedule.Schedule) *discovery.Responder {
+	if !c.Advertise && !c.Browse {
+		return nil
+	}
+
+	r, err := discovery.Listen(discoveryGroup, c.DiscoveryInterface)
+	if err != nil {
+		logger().Error("unable to start mdns, continuing without discovery", "err", err)
+		return nil
+	}
+
+	if c.Advertise {
+		id := c.SignID
+		if id == "" {
+			id, _ = os.Hostname()
+		}
+		role := discoveryRole(c)
+		port := c.AdvertisedPort()
+		r.Advertise(func() discovery.Announcement {
+			hash, _ := sch.State()
+			return discovery.Announcement{
+				Instance:     id,
+				Port:         port,
+				TLS:          c.TLSEnabled(),
+				SignID:       id,
+				Role:         role,
+				ScheduleHash: hash,
+			}
+		})
+		logger().Info("advertising over mdns", "service", discovery.ServiceType, "instance", id, "role", role, "port", port)
+	}
+
+	return r
+}
+
+// discoveryRole is the role this instance advertises. A hub is what other
+// signs look for, so it wins over controller.
+func discoveryRole(c Config) string {
+	switch {
+	case c.Mirror:
+		return discovery.RoleHub
+	case c.Controller:
+		return discovery.RoleController
+	default:
+		return discovery.RoleSign
+	}
+}
+
+// useHubs adds the feeds of the trusted hubs found on the network as
+// fallbacks after the configured feeds. mDNS is unauthenticated, so any other
+// hub is ignored.
+func (s *Server) useHubs(peers []discovery.Peer) {
+	s.mutex.Lock()
+	trusted := s.config.TrustedHubs
+	s.mutex.Unlock()
+
+	var feeds []string
+	for _, p := range peers {
+		if p.Role == discovery.RoleHub && trustedHub(p, trusted) {
+			feeds = append(feeds, p.URL+schedule.FeedPath)
+		}
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if slices.Equal(feeds, s.hubFeeds) {
+		return
+	}
+	logger().Info("hub feeds changed", "feeds", strings.Join(feeds, ","))
+	s.hubFeeds = feeds
+	s.schedule.SetURLs(feedURLs(s.config.ScheduleJSONurls, s.hubFeeds))
+}
+
+// trustedHub reports whether p is one of the trusted hubs, by instance name,
+// sign ID, host name or address
+func trustedHub(p discovery.Peer, trusted []string) bool {
+	names := append([]string{p.Instance, p.SignID, strings.TrimSuffix(p.Host, ".")}, p.Addrs...)
+	for _, t := range trusted {
+		for _, n := range names {
+			if n != "" && strings.EqualFold(n, strings.TrimSuffix(t, ".")) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// feedURLs returns the configured feeds followed by any hub feeds not already
+// configured
+func feedURLs(configured []string, hubs []string) []string {
+	urls := slices.Clone(configured)
+	for _, h := range hubs {
+		if !slices.Contains(urls, h) {
+			urls = append(urls, h)
+		}
+	}
+	return urls
+}
@@ -0,0 +1,124 @@
+package server
+
+import (
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/kylerisse/go-signs/pkg/discovery"
+	"github.com/kylerisse/go-signs/pkg/schedule"
+)
+
+func TestDiscoveryHubFeeds(t *testing.T) {
+	lo, err := net.InterfaceByName("lo")
+	if err != nil || lo.Flags&net.FlagMulticast == 0 {
+		t.Skip("loopback multicast unavailable, enable it with: ip link set lo multicast on")
+	}
+	discoveryGroup = "224.0.0.251:25354"
+	defer func() { discoveryGroup = discovery.DefaultGroup }()
+
+	source := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.ServeFile(w, r, filepath.Join("testdata", "sign.json"))
+	}))
+	defer source.Close()
+
+	// The hub advertises the port it is reachable on
+	var hub *Server
+	hubHTTP := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hub.httpd.Handler.ServeHTTP(w, r)
+	}))
+	defer hubHTTP.Close()
+	hubURL, _ := url.Parse(hubHTTP.URL)
+
+	hubConf, err := NewConfig("2017", source.URL, 60)
+	if err != nil {
+		t.Fatalf("❌ Failed to create hub config (%v)", err)
+	}
+	hubConf.Mirror = true
+	hubConf.SignID = "hub-1"
+	if err := hubConf.SetListenAddresses([]string{hubURL.Host}); err != nil {
+		t.Fatalf("❌ SetListenAddresses() unexpected error: %v", err)
+	}
+	if err := hubConf.SetDiscovery(true, false, "lo"); err != nil {
+		t.Fatalf("❌ SetDiscovery() unexpected error: %v", err)
+	}
+	hub = NewServer(hubConf)
+	defer close(hub.stop)
+	waitForSessionCount(t, hub, 2)
+
+	// The sign only knows an unreachable feed until it finds the hub
+	signConf, err := NewConfig("2017", "http://127.0.0.1:1/sign.json", 60)
+	if err != nil {
+		t.Fatalf("❌ Failed to create sign config (%v)", err)
+	}
+	signConf.SignID = "room-101"
+	if err := signConf.SetDiscovery(true, true, "lo"); err != nil {
+		t.Fatalf("❌ SetDiscovery() unexpected error: %v", err)
+	}
+	if err := signConf.SetTrustedHubs([]string{"hub-1"}); err != nil {
+		t.Fatalf("❌ SetTrustedHubs() unexpected error: %v", err)
+	}
+	sign := NewServer(signConf)
+	defer close(sign.stop)
+
+	want := hubHTTP.URL + schedule.FeedPath
+	deadline := time.Now().Add(5 * time.Second)
+	for !slices.Contains(sign.schedule.URLs(), want) {
+		if time.Now().After(deadline) {
+			t.Fatalf("❌ sign feeds %v never included the hub feed %s", sign.schedule.URLs(), want)
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+	if urls := sign.schedule.URLs(); urls[0] != signConf.ScheduleJSONurl {
+		t.Errorf("❌ hub feed should come after the configured feed, got %v", urls)
+	}
+	t.Logf("✅ sign found hub feed %s", want)
+
+	// The next refresh falls back to the hub
+	if err := sign.schedule.UpdateFromJSON(); err != nil {
+		t.Fatalf("❌ UpdateFromJSON() through the hub unexpected error: %v", err)
+	}
+	if count := scheduleSessionCount(t, sign); count != 2 {
+		t.Errorf("❌ sign has %d sessions from the hub, want 2", count)
+	}
+
+	rr := httptest.NewRecorder()
+	sign.httpd.Handler.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, discovery.PeersPath, nil))
+	if rr.Code != http.StatusOK {
+		t.Errorf("❌ peers status %d, want 200", rr.Code)
+	}
+}
+
+func TestUseHubsTrusted(t *testing.T) {
+	const configured = "https://example.com/sign.json"
+	s := &Server{
+		schedule: schedule.NewSchedule(configured),
+		config:   Config{ScheduleJSONurls: []string{configured}, TrustedHubs: []string{"hub-1", "10.0.0.9"}},
+	}
+
+	s.useHubs([]discovery.Peer{
+		{Instance: "hub-1", SignID: "hub-1", Role: discovery.RoleHub, URL: "http://10.0.0.5:2017"},
+		{Instance: "rogue", SignID: "rogue", Role: discovery.RoleHub, URL: "http://10.0.0.6:2017"},
+		{Instance: "hub-2", Role: discovery.RoleHub, Addrs: []string{"10.0.0.9"}, URL: "http://10.0.0.9:2017"},
+		{Instance: "10.0.0.9", Role: discovery.RoleSign, URL: "http://10.0.0.10:2017"},
+	})
+
+	want := []string{configured, "http://10.0.0.5:2017" + schedule.FeedPath, "http://10.0.0.9:2017" + schedule.FeedPath}
+	if got := s.schedule.URLs(); !slices.Equal(got, want) {
+		t.Errorf("❌ feeds = %v, want %v", got, want)
+	} else {
+		t.Logf("✅ only trusted hubs used as feeds")
+	}
+
+	// Without trusted hubs the peers are only listed
+	s.config.TrustedHubs = nil
+	s.useHubs([]discovery.Peer{{Instance: "hub-1", Role: discovery.RoleHub, URL: "http://10.0.0.5:2017"}})
+	if got := s.schedule.URLs(); !slices.Equal(got, []string{configured}) {
+		t.Errorf("❌ feeds without trusted hubs = %v, want only %s", got, configured)
+	}
+}
@@ -0,0 +1,145 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/kylerisse/go-signs/pkg/schedule"
+)
+
+// scheduleSources fetches /schedule and returns the source and source health
+func scheduleSources(t *testing.T, s *Server) (string, []schedule.FeedSource) {
+	t.Helper()
+	rr := httptest.NewRecorder()
+	s.httpd.Handler.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/schedule", nil))
+
+	var body struct {
+		Source  string                `json:"source"`
+		Sources []schedule.FeedSource `json:"sources"`
+	}
+	if err := json.Unmarshal(rr.Body.Bytes(), &body); err != nil {
+		t.Fatalf("❌ Failed to decode schedule: %v", err)
+	}
+	return body.Source, body.Sources
+}
+
+func TestFeedFallbacks(t *testing.T) {
+	feed, err := os.ReadFile(filepath.Join("testdata", "sign.json"))
+	if err != nil {
+		t.Fatalf("❌ unable to read test feed: %v", err)
+	}
+	modified := time.Date(2025, 3, 6, 8, 0, 0, 0, time.UTC)
+
+	var primaryUp atomic.Bool
+	primary := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !primaryUp.Load() {
+			http.Error(w, "upstream unavailable", http.StatusBadGateway)
+			return
+		}
+		w.Header().Set("Last-Modified", modified.Format(http.TimeFormat))
+		w.Write(feed)
+	}))
+	defer primary.Close()
+
+	// The USB stick holds a copy from before the primary's last change
+	usb := filepath.Join(t.TempDir(), "sign.json")
+	if err := os.WriteFile(usb, feed, 0o644); err != nil {
+		t.Fatalf("❌ unable to write feed file: %v", err)
+	}
+	if err := os.Chtimes(usb, modified.Add(-time.Hour), modified.Add(-time.Hour)); err != nil {
+		t.Fatalf("❌ unable to set feed file time: %v", err)
+	}
+	usbURL := "file://" + usb
+
+	conf, err := NewConfig("2017", primary.URL, 60)
+	if err != nil {
+		t.Fatalf("❌ Failed to create config (%v)", err)
+	}
+	if err := conf.SetFeedFallbacks([]string{usbURL}); err != nil {
+		t.Fatalf("❌ SetFeedFallbacks() unexpected error: %v", err)
+	}
+	s := NewServer(conf)
+	defer close(s.stop)
+
+	t.Run("Fallback", func(t *testing.T) {
+		waitForSessionCount(t, s, 2)
+
+		source, sources := scheduleSources(t, s)
+		if source != usbURL {
+			t.Errorf("❌ source = %s, want %s", source, usbURL)
+		}
+		if len(sources) != 2 || sources[0].Healthy || sources[0].Failures == 0 || !sources[1].Healthy {
+			t.Errorf("❌ unexpected source health: %+v", sources)
+		} else {
+			t.Logf("✅ fell back to %s while %s reports %q", source, sources[0].URL, sources[0].LastError)
+		}
+	})
+
+	t.Run("PrimaryRecovers", func(t *testing.T) {
+		primaryUp.Store(true)
+		if err := s.schedule.UpdateFromJSON(); err != nil {
+			t.Fatalf("❌ UpdateFromJSON() unexpected error: %v", err)
+		}
+
+		source, sources := scheduleSources(t, s)
+		if source != primary.URL || !sources[0].Healthy || sources[0].Failures != 0 {
+			t.Errorf("❌ source = %s, health %+v, want the recovered primary", source, sources[0])
+		}
+	})
+
+	t.Run("StaleFallbackSkipped", func(t *testing.T) {
+		// Newer content from the primary, then the primary goes down again
+		newer := []byte(`[{"Name":"Keynote","Location":"Ballroom A","StartTime":"2025-03-06T10:00:00-08:00","EndTime":"2025-03-06T11:00:00-08:00","Speakers":"","Topic":"","Description":"Moved"}]`)
+		feed = newer
+		if err := s.schedule.UpdateFromJSON(); err != nil {
+			t.Fatalf("❌ UpdateFromJSON() unexpected error: %v", err)
+		}
+		hash, _ := s.schedule.State()
+		primaryUp.Store(false)
+
+		if err := s.schedule.UpdateFromJSON(); err == nil {
+			t.Errorf("❌ UpdateFromJSON() with only a stale fallback expected error, got nil")
+		}
+
+		source, _ := scheduleSources(t, s)
+		if got, _ := s.schedule.State(); got != hash || source != primary.URL {
+			t.Errorf("❌ schedule replaced by the older USB copy: source %s", source)
+		} else {
+			t.Logf("✅ kept %s over the older copy at %s", source, usbURL)
+		}
+	})
+
+	t.Run("PrimaryAdoptedOverNewerFallback", func(t *testing.T) {
+		// The USB stick's clock runs ahead, so its copy looks newer than the
+		// primary's even once the primary is back
+		moved := []byte(`[{"Name":"Keynote","Location":"Ballroom B","StartTime":"2025-03-06T10:00:00-08:00","EndTime":"2025-03-06T11:00:00-08:00","Speakers":"","Topic":"","Description":"Moved again"}]`)
+		if err := os.WriteFile(usb, moved, 0o644); err != nil {
+			t.Fatalf("❌ unable to write feed file: %v", err)
+		}
+		if err := os.Chtimes(usb, modified.Add(2*time.Hour), modified.Add(2*time.Hour)); err != nil {
+			t.Fatalf("❌ unable to set feed file time: %v", err)
+		}
+		if err := s.schedule.UpdateFromJSON(); err != nil {
+			t.Fatalf("❌ UpdateFromJSON() unexpected error: %v", err)
+		}
+		if source, _ := scheduleSources(t, s); source != usbURL {
+			t.Fatalf("❌ source = %s, want the newer copy at %s", source, usbURL)
+		}
+
+		primaryUp.Store(true)
+		if err := s.schedule.UpdateFromJSON(); err != nil {
+			t.Fatalf("❌ UpdateFromJSON() unexpected error: %v", err)
+		}
+		if source, _ := scheduleSources(t, s); source != primary.URL {
+			t.Errorf("❌ source = %s, want the recovered primary despite its older Last-Modified", source)
+		} else {
+			t.Logf("✅ %s adopted over the fallback it outranks", source)
+		}
+	})
+}
@@ -0,0 +1,120 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"net"
+	"os"
+	"strings"
+	"syscall"
+)
+
+// Prefixes for listen addresses that aren't TCP host:port
+const (
+	unixPrefix    = "unix:"
+	systemdPrefix = "systemd"
+)
+
+// listen opens a listener for every configured address, closing any already
+// opened if one fails. Addresses may be TCP host:port, unix:/path/to.sock, or
+// systemd to use all sockets passed by socket activation (systemd:name for only
+// those with FileDescriptorName=name).
+func (s *Server) listen() ([]net.Listener, error) {
+	var listeners []net.Listener
+	var inherited map[string][]net.Listener
+	var inheritedLoaded bool
+
+	fail := func(err error) ([]net.Listener, error) {
+		for _, l := range listeners {
+			l.Close()
+		}
+		for _, ls := range inherited {
+			for _, l := range ls {
+				l.Close()
+			}
+		}
+		return nil, err
+	}
+
+	for _, addr := range s.config.Addresses {
+		switch {
+		case addr == systemdPrefix || strings.HasPrefix(addr, systemdPrefix+":"):
+			if !inheritedLoaded {
+				var err error
+				inherited, err = activationListeners()
+				if err != nil {
+					return fail(err)
+				}
+				inheritedLoaded = true
+			}
+
+			name := strings.TrimPrefix(strings.TrimPrefix(addr, systemdPrefix), ":")
+			found := false
+			for n, ls := range inherited {
+				if name != "" && n != name {
+					continue
+				}
+				listeners = append(listeners, ls...)
+				delete(inherited, n)
+				found = true
+			}
+			if !found {
+				return fail(fmt.Errorf("no sockets passed by systemd for %s", addr))
+			}
+
+		case strings.HasPrefix(addr, unixPrefix):
+			ln, err := listenUnix(strings.TrimPrefix(addr, unixPrefix))
+			if err != nil {
+				return fail(err)
+			}
+			listeners = append(listeners, ln)
+
+		default:
+			ln, err := net.Listen("tcp", addr)
+			if err != nil {
+				return fail(err)
+			}
+			listeners = append(listeners, ln)
+		}
+	}
+
+	// Close inherited sockets nothing asked for
+	for name, ls := range inherited {
+		logger().Warn("ignoring unused socket passed by systemd", "name", name)
+		for _, l := range ls {
+			l.Close()
+		}
+	}
+
+	return listeners, nil
+}
+
+// listenUnix listens on a Unix socket, replacing a stale socket file left by a
+// previous run but refusing to take over one that is still accepting
+// connections
+func listenUnix(path string) (net.Listener, error) {
+	info, err := os.Lstat(path)
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+	case err != nil:
+		return nil, err
+	case info.Mode()&fs.ModeSocket == 0:
+		return nil, fmt.Errorf("%s exists and is not a socket", path)
+	default:
+		conn, err := net.Dial("unix", path)
+		if err == nil {
+			conn.Close()
+			return nil, fmt.Errorf("%s is in use by another process", path)
+		}
+		if !errors.Is(err, syscall.ECONNREFUSED) {
+			return nil, fmt.Errorf("unable to check existing socket %s: %w", path, err)
+		}
+		if err := os.Remove(path); err != nil {
+			return nil, fmt.Errorf("unable to remove stale socket %s: %w", path, err)
+		}
+		logger().Info("removed stale socket", "path", path)
+	}
+
+	return net.Listen("unix", path)
+}
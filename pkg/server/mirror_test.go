@@ -0,0 +1,111 @@
+package server
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/kylerisse/go-signs/pkg/schedule"
+)
+
+func TestMirror(t *testing.T) {
+	feed, err := os.ReadFile(filepath.Join("testdata", "sign.json"))
+	if err != nil {
+		t.Fatalf("❌ unable to read test feed: %v", err)
+	}
+	source := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.ServeFile(w, r, filepath.Join("testdata", "sign.json"))
+	}))
+	defer source.Close()
+
+	// The hub reads from the source and mirrors it
+	hubConf, err := NewConfig("2017", source.URL, 60)
+	if err != nil {
+		t.Fatalf("❌ Failed to create hub config (%v)", err)
+	}
+	hubConf.Mirror = true
+	hub := NewServer(hubConf)
+	defer close(hub.stop)
+	hubHTTP := httptest.NewServer(hub.httpd.Handler)
+	defer hubHTTP.Close()
+	waitForSessionCount(t, hub, 2)
+	hash, _ := hub.schedule.State()
+
+	t.Run("RawFeed", func(t *testing.T) {
+		resp, err := http.Get(hubHTTP.URL + schedule.FeedPath)
+		if err != nil {
+			t.Fatalf("❌ feed request failed: %v", err)
+		}
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(resp.Body)
+
+		if resp.StatusCode != http.StatusOK || string(body) != string(feed) {
+			t.Fatalf("❌ feed status %d, %d bytes, want 200 and the source feed", resp.StatusCode, len(body))
+		}
+		if resp.Header.Get("X-Content-Hash") != hash || resp.Header.Get("ETag") != `"`+hash+`"` {
+			t.Errorf("❌ feed hash headers %q %q, want %s", resp.Header.Get("X-Content-Hash"), resp.Header.Get("ETag"), hash)
+		} else {
+			t.Logf("✅ mirrored %d bytes with hash %s", len(body), hash)
+		}
+	})
+
+	t.Run("ConditionalGet", func(t *testing.T) {
+		req, _ := http.NewRequest(http.MethodGet, hubHTTP.URL+schedule.FeedPath, nil)
+		req.Header.Set("If-None-Match", `"`+hash+`"`)
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("❌ feed request failed: %v", err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusNotModified {
+			t.Errorf("❌ conditional feed request returned %d, want 304", resp.StatusCode)
+		}
+	})
+
+	t.Run("SignFromHub", func(t *testing.T) {
+		signConf, err := NewConfig("2017", hubHTTP.URL+schedule.FeedPath, 60)
+		if err != nil {
+			t.Fatalf("❌ Failed to create sign config (%v)", err)
+		}
+		sign := NewServer(signConf)
+		defer close(sign.stop)
+		waitForSessionCount(t, sign, 2)
+
+		if got, _ := sign.schedule.State(); got != hash {
+			t.Errorf("❌ sign hash %s, want hub hash %s", got, hash)
+		}
+		// A second fetch is answered with 304 and keeps the schedule
+		if err := sign.schedule.UpdateFromJSON(); err != nil {
+			t.Errorf("❌ UpdateFromJSON() from hub unexpected error: %v", err)
+		}
+		if got, _ := sign.schedule.State(); got != hash {
+			t.Errorf("❌ sign hash %s after refetch, want %s", got, hash)
+		}
+	})
+
+	t.Run("NotMirroring", func(t *testing.T) {
+		signConf, err := NewConfig("2017", source.URL, 60)
+		if err != nil {
+			t.Fatalf("❌ Failed to create sign config (%v)", err)
+		}
+		sign := NewServer(signConf)
+		defer close(sign.stop)
+
+		rr := httptest.NewRecorder()
+		sign.httpd.Handler.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, schedule.FeedPath, nil))
+		if rr.Code == http.StatusOK && rr.Header().Get("X-Content-Hash") != "" {
+			t.Errorf("❌ feed served without -mirror")
+		}
+	})
+
+	t.Run("NothingFetched", func(t *testing.T) {
+		rr := httptest.NewRecorder()
+		schedule.NewSchedule(source.URL).HandleFeed(rr, httptest.NewRequest(http.MethodGet, schedule.FeedPath, nil))
+		if rr.Code != http.StatusServiceUnavailable {
+			t.Errorf("❌ feed before first fetch returned %d, want 503", rr.Code)
+		}
+	})
+}
@@ -0,0 +1,80 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"sync"
+
+	"github.com/kylerisse/go-signs/pkg/fleet"
+)
+
+// ConfigPath is where the display reads its profile at boot
+const ConfigPath = "/config"
+
+// signProfile layers the display settings for this sign: built in defaults,
+// then the controller's profile, then local settings
+type signProfile struct {
+	mutex    sync.RWMutex
+	signID   string
+	remote   fleet.Profile
+	local    fleet.Profile
+	profiles *fleet.Profiles // Read directly when this sign is the controller
+}
+
+// newSignProfile produces a signProfile with the local settings from c
+func newSignProfile(c Config) *signProfile {
+	return &signProfile{
+		signID: c.SignID,
+		local:  c.Profile,
+	}
+}
+
+// effective returns the profile the display should use
+func (p *signProfile) effective() fleet.Profile {
+	p.mutex.RLock()
+	defer p.mutex.RUnlock()
+
+	remote := p.remote
+	if p.profiles != nil {
+		remote = p.profiles.For(p.signID)
+	}
+
+	profile := fleet.DefaultProfile().Merge(remote).Merge(p.local)
+	profile.SignID = p.signID
+	return profile
+}
+
+// setRemote replaces the profile received from the controller
+func (p *signProfile) setRemote(remote fleet.Profile) {
+	if err := remote.Validate(); err != nil {
+		logger().Warn("ignoring invalid profile from controller", "err", err)
+		return
+	}
+
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	p.remote = remote
+}
+
+// setProfiles makes the controller's own profiles the remote profile
+func (p *signProfile) setProfiles(profiles *fleet.Profiles) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	p.profiles = profiles
+}
+
+// setLocal replaces the local settings
+func (p *signProfile) setLocal(local fleet.Profile) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	p.local = local
+}
+
+// handleConfig serves the effective profile as JSON
+func (p *signProfile) handleConfig(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	if err := json.NewEncoder(w).Encode(p.effective()); err != nil {
+		logger().Error("unable to encode config", "err", err)
+	}
+}
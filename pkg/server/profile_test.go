@@ -0,0 +1,118 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/kylerisse/go-signs/pkg/fleet"
+)
+
+// getConfig fetches the profile a sign serves to its display
+func getConfig(t *testing.T, s *Server) fleet.Profile {
+	t.Helper()
+	rr := httptest.NewRecorder()
+	s.httpd.Handler.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, ConfigPath, nil))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("❌ %s status = %d, want %d", ConfigPath, rr.Code, http.StatusOK)
+	}
+
+	var p fleet.Profile
+	if err := json.Unmarshal(rr.Body.Bytes(), &p); err != nil {
+		t.Fatalf("❌ Failed to decode config: %v", err)
+	}
+	return p
+}
+
+func TestSignProfile(t *testing.T) {
+	const token = "0123456789abcdef"
+
+	source := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.ServeFile(w, r, filepath.Join("testdata", "sign.json"))
+	}))
+	defer source.Close()
+
+	profiles := filepath.Join(t.TempDir(), "profiles.toml")
+	content := `
+[default]
+sponsor-tiers = ["diamond", "platinum"]
+
+[signs.hub]
+room = "Registration"
+
+[signs.ballroom-a]
+layout = "room"
+room = "Ballroom A"
+schedule-rotation = 30
+`
+	if err := os.WriteFile(profiles, []byte(content), 0o644); err != nil {
+		t.Fatalf("❌ unable to write profiles: %v", err)
+	}
+
+	controllerConf, err := NewConfig("2017", source.URL, 60)
+	if err != nil {
+		t.Fatalf("❌ Failed to create controller config (%v)", err)
+	}
+	if err := controllerConf.SetProfile("hub", fleet.Profile{}); err != nil {
+		t.Fatalf("❌ SetProfile() unexpected error: %v", err)
+	}
+	if err := controllerConf.SetController(true, token, 60); err != nil {
+		t.Fatalf("❌ SetController() unexpected error: %v", err)
+	}
+	if err := controllerConf.SetProfilesFile(profiles); err != nil {
+		t.Fatalf("❌ SetProfilesFile() unexpected error: %v", err)
+	}
+	controller := NewServer(controllerConf)
+	defer close(controller.stop)
+	controllerHTTP := httptest.NewServer(controller.httpd.Handler)
+	defer controllerHTTP.Close()
+
+	// The controller reads its own profile straight from the file
+	if p := getConfig(t, controller); p.SignID != "hub" || p.Room != "Registration" {
+		t.Errorf("❌ controller config = %+v, want the hub profile", p)
+	}
+
+	signConf, err := NewConfig("2017", source.URL, 60)
+	if err != nil {
+		t.Fatalf("❌ Failed to create sign config (%v)", err)
+	}
+	// The sponsor rotation is set locally on this sign
+	if err := signConf.SetProfile("ballroom-a", fleet.Profile{SponsorRotationSeconds: 20}); err != nil {
+		t.Fatalf("❌ SetProfile() unexpected error: %v", err)
+	}
+	if err := signConf.SetReporter(controllerHTTP.URL, "ballroom-a", token, 5); err != nil {
+		t.Fatalf("❌ SetReporter() unexpected error: %v", err)
+	}
+	sign := NewServer(signConf)
+	defer close(sign.stop)
+
+	// Before hearing from the controller the sign serves defaults and local settings
+	if p := getConfig(t, sign); p.Layout != "hallway" || p.SponsorRotationSeconds != 20 {
+		t.Errorf("❌ config before heartbeat = %+v", p)
+	}
+
+	r := newReporter(signConf, sign.schedule, sign.started, sign.profile.setRemote, sign.commands)
+	if err := r.send(); err != nil {
+		t.Fatalf("❌ send() unexpected error: %v", err)
+	}
+
+	p := getConfig(t, sign)
+	want := fleet.Profile{
+		SignID:                  "ballroom-a",
+		Room:                    "Ballroom A",
+		Layout:                  "room",
+		SponsorTiers:            []string{"diamond", "platinum"},
+		ScheduleRotationSeconds: 30,
+		SponsorRotationSeconds:  20,
+	}
+	if p.SignID != want.SignID || p.Room != want.Room || p.Layout != want.Layout || !slices.Equal(p.SponsorTiers, want.SponsorTiers) ||
+		p.ScheduleRotationSeconds != want.ScheduleRotationSeconds || p.SponsorRotationSeconds != want.SponsorRotationSeconds {
+		t.Errorf("❌ config = %+v, want %+v", p, want)
+	} else {
+		t.Logf("✅ config = %+v", p)
+	}
+}
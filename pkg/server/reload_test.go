@@ -0,0 +1,271 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// scheduleSessionCount reads the session count served at /schedule
+func scheduleSessionCount(t *testing.T, s *Server) int {
+	t.Helper()
+	rr := httptest.NewRecorder()
+	s.schedule.HandleScheduleAll(rr, httptest.NewRequest(http.MethodGet, "/schedule", nil))
+
+	var data struct {
+		SessionCount int `json:"sessionCount"`
+	}
+	if err := json.NewDecoder(rr.Body).Decode(&data); err != nil {
+		t.Fatalf("❌ Failed to decode schedule: %v", err)
+	}
+	return data.SessionCount
+}
+
+// waitForSessionCount polls the schedule until it has want sessions
+func waitForSessionCount(t *testing.T, s *Server, want int) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		if scheduleSessionCount(t, s) == want {
+			return
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+	t.Fatalf("❌ schedule never reached %d sessions, have %d", want, scheduleSessionCount(t, s))
+}
+
+func TestServerReload(t *testing.T) {
+	// Source A serves the two session test feed
+	sourceA := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.ServeFile(w, r, filepath.Join("testdata", "sign.json"))
+	}))
+	defer sourceA.Close()
+
+	// Source B serves a single session
+	sourceB := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`[{"Name":"Reloaded","Location":"Ballroom A","StartTime":"2025-03-06T10:00:00-08:00","EndTime":"2025-03-06T11:00:00-08:00","Speakers":"","Topic":"","Description":"After SIGHUP"}]`))
+	}))
+	defer sourceB.Close()
+
+	conf, err := NewConfig("7104", sourceA.URL, 60)
+	if err != nil {
+		t.Fatalf("❌ Failed to create server config (%v)", err)
+	}
+
+	s := NewServer(conf)
+	defer close(s.stop)
+	waitForSessionCount(t, s, 2)
+
+	t.Run("NoReloadFunc", func(t *testing.T) {
+		if err := s.Reload(); err == nil {
+			t.Errorf("❌ Reload() without a reload function expected error, got nil")
+		}
+	})
+
+	t.Run("ReloadError", func(t *testing.T) {
+		s.SetReloadFunc(func() (Config, error) {
+			return Config{}, errors.New("bad config")
+		})
+		if err := s.Reload(); err == nil {
+			t.Errorf("❌ Reload() expected error, got nil")
+		}
+		if s.config.ScheduleJSONurl != sourceA.URL {
+			t.Errorf("❌ config changed after failed reload: %v", s.config.ScheduleJSONurl)
+		}
+		if got := scheduleSessionCount(t, s); got != 2 {
+			t.Errorf("❌ schedule changed after failed reload: %d sessions", got)
+		}
+	})
+
+	t.Run("SwapSource", func(t *testing.T) {
+		newConf, err := NewConfig("7105", sourceB.URL, 30)
+		if err != nil {
+			t.Fatalf("❌ Failed to create reload config (%v)", err)
+		}
+		s.SetReloadFunc(func() (Config, error) { return newConf, nil })
+
+		if err := s.Reload(); err != nil {
+			t.Fatalf("❌ Reload() unexpected error: %v", err)
+		}
+
+		if got := s.schedule.URLs(); len(got) != 1 || got[0] != sourceB.URL {
+			t.Errorf("❌ schedule URLs = %v, want %s", got, sourceB.URL)
+		}
+		if s.config.RefreshInterval != 30*time.Minute {
+			t.Errorf("❌ RefreshInterval = %v, want 30m", s.config.RefreshInterval)
+		}
+		if s.config.Address != ":7104" {
+			t.Errorf("❌ Address = %s, want :7104 to be kept until restart", s.config.Address)
+		}
+
+		waitForSessionCount(t, s, 1)
+		t.Logf("✅ schedule reloaded from %s", sourceB.URL)
+	})
+}
+
+func TestReloadSponsors(t *testing.T) {
+	source := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.ServeFile(w, r, filepath.Join("testdata", "sign.json"))
+	}))
+	defer source.Close()
+
+	manifest := filepath.Join(t.TempDir(), "sponsors.json")
+	if err := os.WriteFile(manifest, []byte(`{"tiers":[{"name":"title","sponsors":["aws.png"]}]}`), 0600); err != nil {
+		t.Fatalf("❌ Failed to write manifest: %v", err)
+	}
+
+	newConf := func(sponsorsFile string) Config {
+		conf, err := NewConfig("7104", source.URL, 60)
+		if err != nil {
+			t.Fatalf("❌ Failed to create server config (%v)", err)
+		}
+		if err := conf.SetSponsorsFile(sponsorsFile); err != nil {
+			t.Fatalf("❌ SetSponsorsFile() unexpected error: %v", err)
+		}
+		return conf
+	}
+
+	s := NewServer(newConf(manifest))
+	defer close(s.stop)
+
+	get := func(path string) (int, string) {
+		rr := httptest.NewRecorder()
+		s.httpd.Handler.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, path, nil))
+		return rr.Code, strings.TrimSpace(rr.Body.String())
+	}
+
+	if code, body := get("/sponsors/title"); code != http.StatusOK || body != `["aws.png"]` {
+		t.Errorf("❌ /sponsors/title = %d %s, want the manifest tier", code, body)
+	}
+	if code, _ := get("/sponsors/gold"); code != http.StatusNotFound {
+		t.Errorf("❌ /sponsors/gold = %d, want 404 with the manifest loaded", code)
+	}
+	if code, _ := get("/sponsors/all"); code != http.StatusOK {
+		t.Errorf("❌ /sponsors/all = %d, want 200", code)
+	}
+	if code, _ := get("/sponsors/images/aws.png"); code != http.StatusOK {
+		t.Errorf("❌ /sponsors/images/aws.png = %d, want 200", code)
+	}
+
+	// The same file with new contents is picked up on reload
+	if err := os.WriteFile(manifest, []byte(`{"tiers":[{"name":"title","sponsors":["aws.png","google.png"]}]}`), 0600); err != nil {
+		t.Fatalf("❌ Failed to write manifest: %v", err)
+	}
+	s.SetReloadFunc(func() (Config, error) { return newConf(manifest), nil })
+	if err := s.Reload(); err != nil {
+		t.Fatalf("❌ Reload() unexpected error: %v", err)
+	}
+	if code, body := get("/sponsors/title"); code != http.StatusOK || body != `["aws.png","google.png"]` {
+		t.Errorf("❌ /sponsors/title after reload = %d %s", code, body)
+	} else {
+		t.Logf("✅ sponsor manifest reloaded")
+	}
+
+	// Dropping the file goes back to the embedded tiers
+	s.SetReloadFunc(func() (Config, error) { return newConf(""), nil })
+	if err := s.Reload(); err != nil {
+		t.Fatalf("❌ Reload() unexpected error: %v", err)
+	}
+	if code, _ := get("/sponsors/gold"); code != http.StatusOK {
+		t.Errorf("❌ /sponsors/gold after dropping the manifest = %d, want 200", code)
+	}
+}
+
+func TestReloadStrictSponsors(t *testing.T) {
+	source := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.ServeFile(w, r, filepath.Join("testdata", "sign.json"))
+	}))
+	defer source.Close()
+
+	manifest := filepath.Join(t.TempDir(), "sponsors.json")
+	if err := os.WriteFile(manifest, []byte(`{"tiers":[{"name":"title","sponsors":["aws.png"]}]}`), 0600); err != nil {
+		t.Fatalf("❌ Failed to write manifest: %v", err)
+	}
+
+	conf, err := NewConfig("7105", source.URL, 60)
+	if err != nil {
+		t.Fatalf("❌ Failed to create server config (%v)", err)
+	}
+	if err := conf.SetSponsorsFile(manifest); err != nil {
+		t.Fatalf("❌ SetSponsorsFile() unexpected error: %v", err)
+	}
+	conf.StrictSponsors = true
+
+	s := NewServer(conf)
+	defer close(s.stop)
+
+	get := func(path string) (int, string) {
+		rr := httptest.NewRecorder()
+		s.httpd.Handler.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, path, nil))
+		return rr.Code, strings.TrimSpace(rr.Body.String())
+	}
+
+	if code, body := get("/sponsors/status"); code != http.StatusOK || !strings.Contains(body, `"ok":true`) {
+		t.Errorf("❌ /sponsors/status = %d %s, want ok", code, body)
+	}
+
+	// A typo in strict mode keeps the last good tiers
+	if err := os.WriteFile(manifest, []byte(`{"tiers":[{"name":"title","sponsors":["aws.png","gogle.png"]}]}`), 0600); err != nil {
+		t.Fatalf("❌ Failed to write manifest: %v", err)
+	}
+	s.SetReloadFunc(func() (Config, error) { return conf, nil })
+	if err := s.Reload(); err != nil {
+		t.Fatalf("❌ Reload() unexpected error: %v", err)
+	}
+	if _, body := get("/sponsors/title"); body != `["aws.png"]` {
+		t.Errorf("❌ /sponsors/title after a strict reload = %s, want the last good tiers", body)
+	} else {
+		t.Logf("✅ strict mode kept the last good tiers")
+	}
+
+	// Without strict mode the tiers are applied and the problem reported
+	conf.StrictSponsors = false
+	if err := s.Reload(); err != nil {
+		t.Fatalf("❌ Reload() unexpected error: %v", err)
+	}
+	if _, body := get("/sponsors/title"); body != `["aws.png","gogle.png"]` {
+		t.Errorf("❌ /sponsors/title after a reload = %s, want the new tiers", body)
+	}
+	if _, body := get("/sponsors/status"); !strings.Contains(body, `"ok":false`) || !strings.Contains(body, "gogle.png") {
+		t.Errorf("❌ /sponsors/status = %s, want the missing image", body)
+	} else {
+		t.Logf("✅ /sponsors/status reports the missing image")
+	}
+}
+
+func TestReloadSponsorImages(t *testing.T) {
+	source := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.ServeFile(w, r, filepath.Join("testdata", "sign.json"))
+	}))
+	defer source.Close()
+
+	conf, err := NewConfig("7105", source.URL, 60)
+	if err != nil {
+		t.Fatalf("❌ Failed to create server config (%v)", err)
+	}
+	if err := conf.SetSponsorImagesDir(t.TempDir()); err != nil {
+		t.Fatalf("❌ SetSponsorImagesDir() unexpected error: %v", err)
+	}
+
+	s := NewServer(conf)
+	defer close(s.stop)
+
+	// Images may have changed on disk, so the embedded tiers are checked again
+	before := s.sponsors.Manifest()
+	s.SetReloadFunc(func() (Config, error) { return conf, nil })
+	if err := s.Reload(); err != nil {
+		t.Fatalf("❌ Reload() unexpected error: %v", err)
+	}
+	if s.sponsors.Manifest() == before {
+		t.Errorf("❌ Reload() with only -sponsor-images did not re-check the sponsor tiers")
+	} else {
+		t.Logf("✅ sponsor tiers re-checked on reload")
+	}
+}
@@ -0,0 +1,147 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/kylerisse/go-signs/pkg/fleet"
+	"github.com/kylerisse/go-signs/pkg/schedule"
+	"github.com/kylerisse/go-signs/pkg/sponsor"
+)
+
+// reporter sends heartbeats describing this sign to a fleet controller
+type reporter struct {
+	url      string
+	signID   string
+	token    string
+	interval time.Duration
+	schedule *schedule.Schedule
+	started  time.Time
+	client   *http.Client
+	profile  func(fleet.Profile) // Receives the profile the controller replies with
+	commands *commandRunner
+	// Impressions the display reported, forwarded with each heartbeat
+	impressions *sponsor.ImpressionQueue
+}
+
+// newReporter produces a reporter for the controller configured in c
+func newReporter(c Config, sch *schedule.Schedule, started time.Time, profile func(fleet.Profile), commands *commandRunner) *reporter {
+	return &reporter{
+		url:      strings.TrimSuffix(c.ControllerURL, "/") + fleet.HeartbeatPath,
+		signID:   c.SignID,
+		token:    c.FleetToken,
+		interval: c.HeartbeatInterval,
+		schedule: sch,
+		started:  started,
+		client:   &http.Client{Timeout: 10 * time.Second},
+		profile:  profile,
+		commands: commands,
+	}
+}
+
+// heartbeat describes the current state of this sign
+func (r *reporter) heartbeat(now time.Time) fleet.Heartbeat {
+	hash, lastUpdate := r.schedule.State()
+	return fleet.Heartbeat{
+		SignID:         r.signID,
+		Version:        Version,
+		ScheduleHash:   hash,
+		LastUpdateTime: lastUpdate,
+		UptimeSeconds:  int64(now.Sub(r.started).Seconds()),
+	}
+}
+
+// send POSTs one heartbeat with any command acknowledgements and impressions
+// to the controller, then applies the profile and runs the commands it replies
+// with. No content means the controller has neither. Impressions the controller
+// didn't take are sent again with the next heartbeat.
+func (r *reporter) send() (err error) {
+	hb := r.heartbeat(time.Now())
+	if r.commands != nil {
+		hb.Acks = r.commands.takeAcks()
+	}
+	if r.impressions != nil {
+		hb.Impressions = r.impressions.Take()
+		defer func() {
+			if err != nil {
+				r.impressions.Requeue(hb.Impressions)
+			}
+		}()
+	}
+
+	body, err := json.Marshal(hb)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest(http.MethodPost, r.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+r.token)
+
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	var reply fleet.HeartbeatReply
+	switch resp.StatusCode {
+	case http.StatusNoContent:
+	case http.StatusOK:
+		if err := json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(&reply); err != nil {
+			return fmt.Errorf("unable to decode heartbeat reply: %w", err)
+		}
+	default:
+		return fmt.Errorf("controller returned %s", resp.Status)
+	}
+	hb.Impressions = nil
+
+	if r.profile != nil {
+		var profile fleet.Profile
+		if reply.Profile != nil {
+			profile = *reply.Profile
+		}
+		r.profile(profile)
+	}
+	// Run even without commands so finished ones are forgotten
+	if r.commands != nil {
+		r.commands.run(reply.Commands)
+	}
+	return nil
+}
+
+// run sends a heartbeat every interval until stop is closed, and right away
+// when there are command acknowledgements to report
+func (r *reporter) run(stop <-chan struct{}) {
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+
+	var wake <-chan struct{}
+	if r.commands != nil {
+		wake = r.commands.wake
+	}
+
+	for {
+		if err := r.send(); err != nil {
+			logger().Warn("unable to send heartbeat", "url", r.url, "sign", r.signID, "err", err)
+		} else {
+			logger().Debug("sent heartbeat", "url", r.url, "sign", r.signID)
+		}
+
+		select {
+		case <-ticker.C:
+		case <-wake:
+		case <-stop:
+			logger().Info("heartbeat reporter stopping")
+			return
+		}
+	}
+}
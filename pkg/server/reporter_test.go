@@ -0,0 +1,182 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kylerisse/go-signs/pkg/fleet"
+	"github.com/kylerisse/go-signs/pkg/sponsor"
+)
+
+// listSigns fetches the signs known to the controller at baseURL
+func listSigns(t *testing.T, baseURL string, token string) []fleet.Sign {
+	t.Helper()
+	req, _ := http.NewRequest(http.MethodGet, baseURL+fleet.SignsPath, nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("❌ signs request failed: %v", err)
+	}
+	defer resp.Body.Close()
+
+	var signs []fleet.Sign
+	if err := json.NewDecoder(resp.Body).Decode(&signs); err != nil {
+		t.Fatalf("❌ Failed to decode signs: %v", err)
+	}
+	return signs
+}
+
+func TestReporter(t *testing.T) {
+	const token = "0123456789abcdef"
+	const adminToken = "fedcba9876543210"
+
+	source := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.ServeFile(w, r, filepath.Join("testdata", "sign.json"))
+	}))
+	defer source.Close()
+
+	// The controller is a go-signs server reading the same feed
+	controllerConf, err := NewConfig("2017", source.URL, 60)
+	if err != nil {
+		t.Fatalf("❌ Failed to create controller config (%v)", err)
+	}
+	if err := controllerConf.SetController(true, token, 60); err != nil {
+		t.Fatalf("❌ SetController() unexpected error: %v", err)
+	}
+	if err := controllerConf.SetFleetAdminToken(adminToken); err != nil {
+		t.Fatalf("❌ SetFleetAdminToken() unexpected error: %v", err)
+	}
+	controller := NewServer(controllerConf)
+	defer close(controller.stop)
+	controllerHTTP := httptest.NewServer(controller.httpd.Handler)
+	defer controllerHTTP.Close()
+
+	signConf, err := NewConfig("2017", source.URL, 60)
+	if err != nil {
+		t.Fatalf("❌ Failed to create sign config (%v)", err)
+	}
+	if err := signConf.SetReporter(controllerHTTP.URL, "ballroom-a", token, 5); err != nil {
+		t.Fatalf("❌ SetReporter() unexpected error: %v", err)
+	}
+	sign := NewServer(signConf)
+	defer close(sign.stop)
+	waitForSessionCount(t, sign, 2)
+	waitForSessionCount(t, controller, 2)
+
+	// Send one now rather than waiting for the interval
+	r := newReporter(signConf, sign.schedule, sign.started, sign.profile.setRemote, sign.commands)
+	if err := r.send(); err != nil {
+		t.Fatalf("❌ send() unexpected error: %v", err)
+	}
+
+	var signs []fleet.Sign
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		signs = listSigns(t, controllerHTTP.URL, adminToken)
+		if len(signs) == 1 {
+			break
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+	if len(signs) != 1 {
+		t.Fatalf("❌ controller knows %d signs, want 1", len(signs))
+	}
+
+	got := signs[0]
+	hash, lastUpdate := sign.schedule.State()
+	if got.SignID != "ballroom-a" || got.Version != Version || got.ScheduleHash != hash || got.LastUpdateTime != lastUpdate {
+		t.Errorf("❌ unexpected heartbeat: %+v", got)
+	}
+	if got.Stale || got.OutOfSync {
+		t.Errorf("❌ sign flagged stale=%v outOfSync=%v, want neither", got.Stale, got.OutOfSync)
+	} else {
+		t.Logf("✅ controller sees %s in sync with hash %s", got.SignID, got.ScheduleHash)
+	}
+
+	// A reporter with the wrong token is rejected
+	r.token = "fedcba9876543210"
+	if err := r.send(); err == nil {
+		t.Errorf("❌ send() with wrong token expected error, got nil")
+	}
+}
+
+func TestReporterImpressions(t *testing.T) {
+	const token = "0123456789abcdef"
+
+	source := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.ServeFile(w, r, filepath.Join("testdata", "sign.json"))
+	}))
+	defer source.Close()
+
+	// The controller counts the impressions of the whole fleet
+	controllerConf, err := NewConfig("2017", source.URL, 60)
+	if err != nil {
+		t.Fatalf("❌ Failed to create controller config (%v)", err)
+	}
+	if err := controllerConf.SetController(true, token, 60); err != nil {
+		t.Fatalf("❌ SetController() unexpected error: %v", err)
+	}
+	if err := controllerConf.SetImpressionsDB(filepath.Join(t.TempDir(), "impressions.db")); err != nil {
+		t.Fatalf("❌ SetImpressionsDB() unexpected error: %v", err)
+	}
+	controller := NewServer(controllerConf)
+	defer close(controller.stop)
+	defer controller.impressions.Close()
+	controllerHTTP := httptest.NewServer(controller.httpd.Handler)
+	defer controllerHTTP.Close()
+
+	signConf, err := NewConfig("2017", source.URL, 60)
+	if err != nil {
+		t.Fatalf("❌ Failed to create sign config (%v)", err)
+	}
+	if err := signConf.SetReporter(controllerHTTP.URL, "ballroom-a", token, 5); err != nil {
+		t.Fatalf("❌ SetReporter() unexpected error: %v", err)
+	}
+	sign := NewServer(signConf)
+	defer close(sign.stop)
+
+	// The display reports to its own sign, which has no database
+	post := func(remote string) int {
+		body := `{"sign":"ballroom-a","impressions":[{"image":"aws.png","seconds":10}]}`
+		req := httptest.NewRequest(http.MethodPost, sponsor.ImpressionsPath, strings.NewReader(body))
+		req.RemoteAddr = remote
+		rr := httptest.NewRecorder()
+		sign.httpd.Handler.ServeHTTP(rr, req)
+		return rr.Code
+	}
+	if code := post("10.0.0.7:51000"); code != http.StatusUnauthorized {
+		t.Errorf("❌ POST %s from another machine = %d, want 401", sponsor.ImpressionsPath, code)
+	}
+	if code := post("127.0.0.1:51000"); code != http.StatusNoContent {
+		t.Fatalf("❌ POST %s = %d, want 204", sponsor.ImpressionsPath, code)
+	}
+
+	r := newReporter(signConf, sign.schedule, sign.started, sign.profile.setRemote, sign.commands)
+	r.impressions = sign.forward
+	if err := r.send(); err != nil {
+		t.Fatalf("❌ send() unexpected error: %v", err)
+	}
+
+	// The background reporter may have sent them first
+	var report sponsor.ImpressionSummary
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		if report, err = controller.impressions.Report(sponsor.ImpressionFilter{}); err != nil {
+			t.Fatalf("❌ Report() unexpected error: %v", err)
+		}
+		if len(report.Rows) > 0 {
+			break
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+	if len(report.Rows) != 1 || report.Rows[0].Sign != "ballroom-a" || report.Rows[0].Image != "aws.png" || report.Rows[0].Shown != 1 {
+		t.Errorf("❌ controller report rows = %+v, want aws.png shown once on ballroom-a", report.Rows)
+	} else {
+		t.Logf("✅ controller counted %s from %s", report.Rows[0].Image, report.Rows[0].Sign)
+	}
+}
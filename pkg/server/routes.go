@@ -1,36 +1,47 @@
 package server
 
 import (
-	"log"
-	"net/http"
-
 	"github.com/gin-gonic/gin"
 	"github.com/kylerisse/go-signs/pkg/display"
+	"github.com/kylerisse/go-signs/pkg/fleet"
 	"github.com/kylerisse/go-signs/pkg/schedule"
 	"github.com/kylerisse/go-signs/pkg/sponsor"
 )
 
 // setupRoutes configures all routes for the application
-func setupRoutes(r *gin.Engine, s *schedule.Schedule) {
-	// Set up sponsor handling
-	sponsorManager, err := sponsor.NewManager()
-	if err != nil {
-		log.Fatal(err)
-	}
-
-	// Configure all routes
-	r.GET("/sponsors/diamond", gin.WrapF(sponsorManager.HandleDiamond))
-	r.GET("/sponsors/platinum", gin.WrapF(sponsorManager.HandlePlatinum))
-	r.GET("/sponsors/gold", gin.WrapF(sponsorManager.HandleGold))
+func setupRoutes(r *gin.Engine, s *schedule.Schedule, controller *fleet.Controller, sponsorManager *sponsor.Manager, displayDir string) error {
+	// Configure all routes, with a sponsor endpoint per tier in the manifest
+	r.GET("/sponsors", gin.WrapF(sponsorManager.HandleSponsors))
+	r.GET("/sponsors/:tier", gin.WrapF(sponsorManager.HandleTier))
 	r.GET("/sponsors/all", gin.WrapF(sponsorManager.HandleAllSponsors))
-	r.StaticFS("/sponsors/images", sponsorManager.GetFS())
+	r.GET(sponsor.StatusPath, gin.WrapF(sponsorManager.HandleStatus))
+	r.GET(sponsor.RotationPath, gin.WrapF(sponsorManager.HandleRotation))
+	r.GET(sponsor.VersionPath, gin.WrapF(sponsorManager.HandleVersion))
+	r.GET(sponsor.ImagesPath+"/*name", gin.WrapF(sponsorManager.HandleImage))
+	r.HEAD(sponsor.ImagesPath+"/*name", gin.WrapF(sponsorManager.HandleImage))
 
 	r.GET("/schedule", gin.WrapF(s.HandleScheduleAll))
+	r.GET(schedule.RoomsPath, gin.WrapF(s.HandleRooms))
+	r.GET(schedule.RoomsPath+"/*room", gin.WrapF(s.HandleRoom))
+
+	// Fleet controller endpoints, only when running as a controller
+	if controller != nil {
+		r.POST(fleet.HeartbeatPath, gin.WrapF(controller.HandleHeartbeat))
+		r.GET(fleet.SignsPath, gin.WrapF(controller.HandleSigns))
+		r.GET(fleet.CommandsPath, gin.WrapF(controller.HandleCommands))
+		r.POST(fleet.CommandsPath, gin.WrapF(controller.HandleCommands))
+	}
 
 	// Static files - this must come last as it's a catch-all
 	// Use a NoRoute handler instead of StaticFS to avoid path conflicts
-	r.NoRoute(func(c *gin.Context) {
-		fileServer := http.FileServer(display.GetFS())
-		fileServer.ServeHTTP(c.Writer, c.Request)
-	})
+	if displayDir != "" {
+		r.NoRoute(gin.WrapH(display.DirHandler(displayDir)))
+		return nil
+	}
+	h, err := display.Handler()
+	if err != nil {
+		return err
+	}
+	r.NoRoute(gin.WrapH(h))
+	return nil
 }
@@ -2,116 +2,620 @@ package server
 
 import (
 	"context"
-	"log"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
 	"net/http"
 	"os"
 	"os/signal"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
 	"syscall"
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/kylerisse/go-signs/pkg/branding"
+	"github.com/kylerisse/go-signs/pkg/discovery"
+	"github.com/kylerisse/go-signs/pkg/fleet"
+	"github.com/kylerisse/go-signs/pkg/logging"
 	"github.com/kylerisse/go-signs/pkg/schedule"
+	"github.com/kylerisse/go-signs/pkg/sponsor"
 )
 
+// ReloadFunc re-reads the configuration when the server receives SIGHUP
+type ReloadFunc func() (Config, error)
+
 // Server is the main webserver process
 type Server struct {
 	httpd       *http.Server
-	stopRefresh chan struct{}
-	refreshDone chan struct{}
+	redirect    *http.Server
+	schedule    *schedule.Schedule
+	started     time.Time
+	stop        chan struct{}
+	quit        chan os.Signal // Receives shutdown signals
+	background  sync.WaitGroup
+	refreshNow  chan time.Duration
+	profile     *signProfile
+	commands    *commandRunner
+	sponsors    *sponsor.Manager
+	impressions *sponsor.Impressions     // Nil unless counting impressions
+	forward     *sponsor.ImpressionQueue // Nil unless reporting to a controller
+
+	mutex    sync.Mutex
+	config   Config
+	reload   ReloadFunc
+	hubFeeds []string // Feeds of hubs found over mDNS, tried after the configured ones
+}
+
+// logger returns the default logger tagged for this package
+func logger() *slog.Logger {
+	return slog.Default().With("component", "server")
 }
 
 // NewServer sets up the cron runs for schedule and sponsors returns the *Server
 func NewServer(c Config) *Server {
-	sch := schedule.NewSchedule(c.ScheduleJSONurl)
+	sch := schedule.NewSchedule(c.ScheduleJSONurls...)
 
-	// Channels for coordinating shutdown
-	stopRefresh := make(chan struct{})
-	refreshDone := make(chan struct{})
+	profile := newSignProfile(c)
+	commands := newCommandRunner(sch.UpdateFromJSON)
 
-	// Start the schedule refresh goroutine
-	go func() {
-		sch.UpdateFromJSON()
-		ticker := time.NewTicker(c.RefreshInterval)
-		defer ticker.Stop()
-		defer close(refreshDone)
-
-		for {
-			select {
-			case <-ticker.C:
-				sch.UpdateFromJSON()
-			case <-stopRefresh:
-				log.Println("Schedule refresh routine stopping...")
-				return
+	var controller *fleet.Controller
+	if c.Controller {
+		registry := fleet.NewRegistry(c.StaleAfter, func() string {
+			hash, _ := sch.State()
+			return hash
+		})
+		controller = fleet.NewController(registry, c.FleetToken)
+		if c.FleetAdminToken != "" {
+			controller.SetAdminToken(c.FleetAdminToken)
+		} else {
+			logger().Warn("no fleet admin token, signs can't be listed or sent commands")
+		}
+
+		if c.ProfilesFile != "" {
+			profiles, err := fleet.LoadProfiles(c.ProfilesFile)
+			if err != nil {
+				// Already loaded once by SetProfilesFile
+				logger().Error("unable to load profiles", "path", c.ProfilesFile, "err", err)
+			} else {
+				controller.SetProfiles(profiles)
+				// The controller is a sign too, unless it reports elsewhere
+				if c.ControllerURL == "" {
+					profile.setProfiles(profiles)
+				}
 			}
 		}
-	}()
+	}
+
+	sponsors, err := sponsor.NewManager(c.SponsorImagesDir)
+	if err != nil {
+		logger().Error("unable to create sponsor manager", "err", err)
+		os.Exit(1)
+	}
+	manifest, err := sponsor.DefaultManifest()
+	if err != nil {
+		logger().Error("unable to load embedded sponsor manifest", "err", err)
+		os.Exit(1)
+	}
+	if c.SponsorsFile != "" {
+		if m, err := sponsor.LoadManifest(c.SponsorsFile); err != nil {
+			// Already loaded once by SetSponsorsFile
+			logger().Error("unable to load sponsor manifest, using the embedded one", "path", c.SponsorsFile, "err", err)
+		} else {
+			manifest = m
+		}
+	}
+	// Checked and logged once here, then on every reload
+	if err := sponsors.SetManifest(manifest).Err(); err != nil && c.StrictSponsors {
+		logger().Error("refusing to start with invalid sponsor assets", "err", err)
+		os.Exit(1)
+	}
+	sch.SetSponsors(sessionSponsors{manager: sponsors})
+
+	var impressions *sponsor.Impressions
+	if c.ImpressionsDB != "" {
+		if impressions, err = sponsor.OpenImpressions(c.ImpressionsDB, sponsors); err != nil {
+			logger().Error("unable to open impressions database", "path", c.ImpressionsDB, "err", err)
+			os.Exit(1)
+		}
+		if controller != nil {
+			controller.SetImpressions(func(signID string, imps []fleet.Impression) error {
+				return impressions.Record(sponsor.ImpressionReport{Sign: signID, Impressions: imps})
+			})
+		}
+	}
+
+	// Count impressions here and forward them to the controller, either or both
+	var recorders []sponsor.ImpressionRecorder
+	if impressions != nil {
+		recorders = append(recorders, impressions)
+	}
+	var forward *sponsor.ImpressionQueue
+	if c.ControllerURL != "" {
+		forward = sponsor.NewImpressionQueue()
+		recorders = append(recorders, forward)
+	}
 
 	gin.SetMode(gin.ReleaseMode)
-	router := gin.Default()
-	setupRoutes(router, sch)
+	router := gin.New()
+	router.Use(logging.GinMiddleware(nil), gin.Recovery())
+	if err := setupRoutes(router, sch, controller, sponsors, c.DisplayDir); err != nil {
+		logger().Error("unable to set up routes", "err", err)
+		os.Exit(1)
+	}
+	if c.DisplayDir != "" {
+		logger().Warn("serving the display from disk for development", "dir", c.DisplayDir)
+	}
+	router.GET(ConfigPath, gin.WrapF(profile.handleConfig))
+	if c.Mirror {
+		router.GET(schedule.FeedPath, gin.WrapF(sch.HandleFeed))
+		router.HEAD(schedule.FeedPath, gin.WrapF(sch.HandleFeed))
+	}
+	router.GET(DisplayCommandsPath, gin.WrapF(commands.handleDisplayCommands))
+	router.POST(DisplayCommandsPath+"/:id/ack", commands.handleDisplayAck)
+
+	if len(recorders) > 0 {
+		router.POST(sponsor.ImpressionsPath, gin.WrapF(sponsor.HandleImpressions(c.FleetToken, recorders...)))
+	}
+	if impressions != nil {
+		router.GET(sponsor.ReportPath, gin.WrapF(impressions.HandleReport))
+	}
+
+	if c.AdminToken != "" {
+		admin := sponsor.NewAdmin(sponsors, c.SponsorImagesDir, c.SponsorsFile, c.AdminToken)
+		router.PUT(sponsor.AdminLogosPath+"/:name", gin.WrapF(admin.HandleLogo))
+		router.DELETE(sponsor.AdminLogosPath+"/:name", gin.WrapF(admin.HandleLogo))
+		router.PUT(sponsor.AdminTiersPath+"/:tier", gin.WrapF(admin.HandleTier))
+		router.DELETE(sponsor.AdminTiersPath+"/:tier", gin.WrapF(admin.HandleTier))
+	}
+
+	brand, err := branding.Load(c.BrandingFile, c.BrandingAssets)
+	if err != nil {
+		// Already loaded once by SetBranding
+		logger().Error("unable to load branding", "path", c.BrandingFile, "err", err)
+		os.Exit(1)
+	}
+	router.GET(branding.Path, gin.WrapF(brand.HandleBranding))
+	router.GET(branding.AssetsPath+"/:name", gin.WrapF(brand.HandleAsset))
+	router.HEAD(branding.AssetsPath+"/:name", gin.WrapF(brand.HandleAsset))
+
+	disc := newDiscovery(c, sch)
+	if disc != nil {
+		router.GET(discovery.PeersPath, gin.WrapF(disc.HandlePeers))
+		router.GET(discovery.AdminPath, gin.WrapF(disc.HandleAdmin))
+	}
+
+	srv := newHTTPServer(c.Address, router)
+
+	var redirect *http.Server
+	if c.RedirectAddress != "" {
+		redirect = newHTTPServer(c.RedirectAddress, redirectHandler(c.AdvertisedPort()))
+	}
+
+	s := &Server{
+		httpd:    srv,
+		redirect: redirect,
+		schedule: sch,
+		started:  time.Now(),
+		// Closed to stop the background goroutines on shutdown
+		stop:        make(chan struct{}),
+		quit:        make(chan os.Signal, 1),
+		refreshNow:  make(chan time.Duration, 1),
+		profile:     profile,
+		commands:    commands,
+		sponsors:    sponsors,
+		impressions: impressions,
+		forward:     forward,
+		config:      c,
+	}
+
+	// Start the schedule refresh goroutine
+	s.goBackground(func() { s.refreshSchedule(c.RefreshInterval) })
+
+	// Report to the fleet controller if configured
+	if c.ControllerURL != "" {
+		r := newReporter(c, sch, s.started, profile.setRemote, commands)
+		r.impressions = forward
+		s.goBackground(func() { r.run(s.stop) })
+	}
+
+	// Advertise on the local network and look for hubs if configured
+	if disc != nil {
+		if c.Browse {
+			disc.Browse(s.useHubs)
+		}
+		s.goBackground(func() { disc.Run(s.stop) })
+	}
+
+	return s
+}
 
-	srv := &http.Server{
-		Handler:      router,
-		Addr:         c.Address,
+// goBackground runs f in a goroutine that shutdown waits for. f must return
+// once s.stop is closed.
+func (s *Server) goBackground(f func()) {
+	s.background.Add(1)
+	go func() {
+		defer s.background.Done()
+		f()
+	}()
+}
+
+// newHTTPServer returns an http.Server with the go-signs timeouts and logging
+func newHTTPServer(addr string, h http.Handler) *http.Server {
+	return &http.Server{
+		Handler:      h,
+		Addr:         addr,
 		WriteTimeout: 15 * time.Second,
 		ReadTimeout:  15 * time.Second,
+		ErrorLog:     slog.NewLogLogger(slog.Default().With("component", "http").Handler(), slog.LevelWarn),
 	}
+}
 
-	return &Server{
-		httpd:       srv,
-		stopRefresh: stopRefresh,
-		refreshDone: refreshDone,
+// refreshSchedule updates the schedule every interval until stop is closed. A
+// value sent on refreshNow triggers an immediate update and resets the ticker
+// to the new interval.
+func (s *Server) refreshSchedule(interval time.Duration) {
+	s.schedule.UpdateFromJSON()
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			s.schedule.UpdateFromJSON()
+		case interval = <-s.refreshNow:
+			ticker.Reset(interval)
+			s.schedule.UpdateFromJSON()
+		case <-s.stop:
+			logger().Info("schedule refresh routine stopping")
+			return
+		}
 	}
 }
 
-// ListenAndServe starts the server and sets up graceful shutdown
-func (s *Server) ListenAndServe() error {
-	// Set up channel for shutdown signals
-	quit := make(chan os.Signal, 1)
-	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
+// SetReloadFunc sets the function used to re-read the configuration on SIGHUP
+func (s *Server) SetReloadFunc(f ReloadFunc) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.reload = f
+}
+
+// Reload re-reads the configuration and applies the settings that can change
+// while running. Settings that need a restart are logged and left as they are.
+// The current schedule keeps being served throughout.
+func (s *Server) Reload() error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.reload == nil {
+		return errors.New("no reload function configured")
+	}
+
+	c, err := s.reload()
+	if err != nil {
+		return err
+	}
+
+	old := s.config
+	changed := false
+
+	if !slices.Equal(c.Addresses, old.Addresses) {
+		warnRestartRequired("listen", strings.Join(old.Addresses, ","), strings.Join(c.Addresses, ","))
+		c.Address, c.Addresses = old.Address, old.Addresses
+	}
+
+	if c.TLSCertFile != old.TLSCertFile || c.TLSKeyFile != old.TLSKeyFile {
+		warnRestartRequired("tls", old.TLSCertFile+","+old.TLSKeyFile, c.TLSCertFile+","+c.TLSKeyFile)
+		c.TLSCertFile, c.TLSKeyFile = old.TLSCertFile, old.TLSKeyFile
+	}
+
+	if c.RedirectAddress != old.RedirectAddress {
+		warnRestartRequired("redirect-http", old.RedirectAddress, c.RedirectAddress)
+		c.RedirectAddress = old.RedirectAddress
+	}
+
+	if c.ControllerURL != old.ControllerURL || c.SignID != old.SignID || c.HeartbeatInterval != old.HeartbeatInterval ||
+		c.Controller != old.Controller || c.StaleAfter != old.StaleAfter || c.FleetToken != old.FleetToken ||
+		c.FleetAdminToken != old.FleetAdminToken {
+		warnRestartRequired("fleet", old.SignID+"@"+old.ControllerURL, c.SignID+"@"+c.ControllerURL)
+		c.ControllerURL, c.SignID, c.HeartbeatInterval = old.ControllerURL, old.SignID, old.HeartbeatInterval
+		c.Controller, c.StaleAfter, c.FleetToken = old.Controller, old.StaleAfter, old.FleetToken
+		c.FleetAdminToken = old.FleetAdminToken
+	}
+
+	if c.Mirror != old.Mirror {
+		warnRestartRequired("mirror", strconv.FormatBool(old.Mirror), strconv.FormatBool(c.Mirror))
+		c.Mirror = old.Mirror
+	}
+
+	if c.SponsorImagesDir != old.SponsorImagesDir {
+		warnRestartRequired("sponsor-images", old.SponsorImagesDir, c.SponsorImagesDir)
+		c.SponsorImagesDir = old.SponsorImagesDir
+	}
 
-	// Start HTTP server in a goroutine
-	serverErrors := make(chan error, 1)
+	// The admin API keeps writing to the manifest it started with
+	if c.AdminToken != old.AdminToken || (old.AdminToken != "" && c.SponsorsFile != old.SponsorsFile) {
+		warnRestartRequired("admin-token", fmt.Sprintf("enabled=%v,sponsors=%s", old.AdminToken != "", old.SponsorsFile),
+			fmt.Sprintf("enabled=%v,sponsors=%s", c.AdminToken != "", c.SponsorsFile))
+		c.AdminToken, c.SponsorsFile = old.AdminToken, old.SponsorsFile
+	}
+
+	// The branding file itself is re-read whenever it changes
+	if c.BrandingFile != old.BrandingFile || c.BrandingAssets != old.BrandingAssets {
+		warnRestartRequired("branding", old.BrandingFile, c.BrandingFile)
+		c.BrandingFile, c.BrandingAssets = old.BrandingFile, old.BrandingAssets
+	}
+
+	if c.DisplayDir != old.DisplayDir {
+		warnRestartRequired("display-dir", old.DisplayDir, c.DisplayDir)
+		c.DisplayDir = old.DisplayDir
+	}
+
+	if c.ImpressionsDB != old.ImpressionsDB {
+		warnRestartRequired("impressions-db", old.ImpressionsDB, c.ImpressionsDB)
+		c.ImpressionsDB = old.ImpressionsDB
+	}
+
+	if c.ProfilesFile != old.ProfilesFile {
+		warnRestartRequired("profiles", old.ProfilesFile, c.ProfilesFile)
+		c.ProfilesFile = old.ProfilesFile
+	}
+
+	if c.Advertise != old.Advertise || c.Browse != old.Browse || c.DiscoveryInterface != old.DiscoveryInterface ||
+		!slices.Equal(c.TrustedHubs, old.TrustedHubs) {
+		warnRestartRequired("mdns", fmt.Sprintf("advertise=%v,browse=%v,hubs=%s", old.Advertise, old.Browse, strings.Join(old.TrustedHubs, ",")),
+			fmt.Sprintf("advertise=%v,browse=%v,hubs=%s", c.Advertise, c.Browse, strings.Join(c.TrustedHubs, ",")))
+		c.Advertise, c.Browse, c.DiscoveryInterface = old.Advertise, old.Browse, old.DiscoveryInterface
+		c.TrustedHubs = old.TrustedHubs
+	}
+
+	if !reflect.DeepEqual(c.Profile, old.Profile) {
+		logger().Info("local profile changed", "room", c.Profile.Room, "layout", c.Profile.Layout)
+		s.profile.setLocal(c.Profile)
+	}
+
+	// The manifest or images may have changed on disk even if their paths
+	// have not
+	if c.SponsorsFile != "" || old.SponsorsFile != "" || c.SponsorImagesDir != "" {
+		s.loadSponsors(c.SponsorsFile, c.StrictSponsors)
+	}
+
+	if !slices.Equal(c.ScheduleJSONurls, old.ScheduleJSONurls) {
+		logger().Info("schedule sources changed", "old", strings.Join(old.ScheduleJSONurls, ","), "urls", strings.Join(c.ScheduleJSONurls, ","))
+		s.schedule.SetURLs(feedURLs(c.ScheduleJSONurls, s.hubFeeds))
+		changed = true
+	}
+
+	if c.RefreshInterval != old.RefreshInterval {
+		logger().Info("refresh interval changed", "old", old.RefreshInterval.String(), "interval", c.RefreshInterval.String())
+		changed = true
+	}
+
+	s.config = c
+
+	if changed {
+		// Drop any pending request so the newest interval wins
+		select {
+		case <-s.refreshNow:
+		default:
+		}
+		s.refreshNow <- c.RefreshInterval
+	}
+
+	logger().Info("configuration reloaded")
+	return nil
+}
+
+// loadSponsors replaces the sponsor tiers with the manifest at path, or the
+// embedded one when path is empty, keeping the current tiers on error. In
+// strict mode tiers with asset errors are refused too.
+func (s *Server) loadSponsors(path string, strict bool) {
+	load := sponsor.DefaultManifest
+	if path != "" {
+		load = func() (*sponsor.Manifest, error) { return sponsor.LoadManifest(path) }
+	}
+
+	manifest, err := load()
+	if err != nil {
+		logger().Error("unable to load sponsor manifest, keeping current tiers", "path", path, "err", err)
+		return
+	}
+	if strict {
+		if err := s.sponsors.Check(manifest).Err(); err != nil {
+			logger().Error("refusing sponsor manifest with invalid assets, keeping current tiers", "path", path, "err", err)
+			return
+		}
+	}
+	s.sponsors.SetManifest(manifest)
+}
+
+// refreshGrace is how long a schedule refresh may run past its interval,
+// fetching from every source in turn, before the server counts as hung
+const refreshGrace = time.Minute
+
+// healthy reports whether the schedule is still being refreshed: it was last
+// checked for updates within two refresh intervals and its lock is free within
+// timeout
+func (s *Server) healthy(timeout time.Duration) bool {
+	done := make(chan bool, 1)
 	go func() {
-		log.Printf("Listening on %s", s.httpd.Addr)
-		if err := s.httpd.ListenAndServe(); err != nil && err != http.ErrServerClosed {
-			serverErrors <- err
+		s.mutex.Lock()
+		interval := s.config.RefreshInterval
+		s.mutex.Unlock()
+
+		last := s.schedule.LastRefresh()
+		if last.IsZero() {
+			last = s.started
 		}
+		done <- time.Since(last) <= 2*interval+refreshGrace
 	}()
 
-	// Wait for termination signal or server error
 	select {
-	case <-quit:
-		log.Println("Shutdown signal received...")
-	case err := <-serverErrors:
-		log.Printf("Server error: %v", err)
+	case ok := <-done:
+		return ok
+	case <-time.After(timeout):
+		return false
+	}
+}
+
+// warnRestartRequired reports a reloaded setting that only applies on restart
+func warnRestartRequired(setting string, current string, requested string) {
+	logger().Warn("setting cannot be changed without a restart",
+		"setting", setting, "current", current, "requested", requested)
+}
+
+// ListenAndServe starts the server and sets up graceful shutdown
+func (s *Server) ListenAndServe() error {
+	listeners, err := s.listen()
+	if err != nil {
 		return err
 	}
 
+	var redirect net.Listener
+	if s.redirect != nil {
+		if redirect, err = net.Listen("tcp", s.redirect.Addr); err != nil {
+			for _, ln := range listeners {
+				ln.Close()
+			}
+			return err
+		}
+	}
+
+	return s.serve(listeners, redirect)
+}
+
+// serve answers requests on listeners, and HTTPS redirects on redirect when
+// set, until a shutdown signal or server error
+func (s *Server) serve(listeners []net.Listener, redirect net.Listener) error {
+	s.mutex.Lock()
+	c := s.config
+	s.mutex.Unlock()
+
+	if c.TLSEnabled() {
+		certs, err := newCertReloader(c.TLSCertFile, c.TLSKeyFile)
+		if err != nil {
+			for _, ln := range listeners {
+				ln.Close()
+			}
+			if redirect != nil {
+				redirect.Close()
+			}
+			return err
+		}
+		s.httpd.TLSConfig = certs.tlsConfig()
+	}
+
+	// Set up channels for shutdown and reload signals
+	signal.Notify(s.quit, syscall.SIGINT, syscall.SIGTERM)
+	defer signal.Stop(s.quit)
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	defer signal.Stop(hup)
+
+	// Start HTTP server on each listener in a goroutine
+	serverErrors := make(chan error, len(listeners)+1)
+	for _, ln := range listeners {
+		go func() {
+			logger().Info("listening", "addr", ln.Addr().String(), "tls", c.TLSEnabled())
+			var err error
+			if c.TLSEnabled() {
+				err = s.httpd.ServeTLS(ln, "", "")
+			} else {
+				err = s.httpd.Serve(ln)
+			}
+			if err != nil && err != http.ErrServerClosed {
+				serverErrors <- err
+			}
+		}()
+	}
+
+	if redirect != nil {
+		go func() {
+			logger().Info("redirecting to https", "addr", redirect.Addr().String())
+			if err := s.redirect.Serve(redirect); err != nil && err != http.ErrServerClosed {
+				serverErrors <- err
+			}
+		}()
+	}
+
+	// Tell systemd we're up and start pinging its watchdog if enabled
+	notify("READY=1")
+	stopWatchdog := make(chan struct{})
+	defer close(stopWatchdog)
+	if interval := sdWatchdogInterval(); interval > 0 {
+		go s.watchdog(interval, stopWatchdog)
+	}
+
+	// Wait for termination signal or server error, reloading on SIGHUP
+wait:
+	for {
+		select {
+		case <-hup:
+			logger().Info("reload signal received")
+			notify(fmt.Sprintf("RELOADING=1\nMONOTONIC_USEC=%d", monotonicUsec()))
+			if err := s.Reload(); err != nil {
+				logger().Error("unable to reload configuration, keeping current settings", "err", err)
+			}
+			notify("READY=1")
+		case sig := <-s.quit:
+			logger().Info("shutdown signal received", "signal", sig.String())
+			break wait
+		case err := <-serverErrors:
+			logger().Error("server error", "err", err)
+			s.httpd.Close()
+			if s.redirect != nil {
+				s.redirect.Close()
+			}
+			return err
+		}
+	}
+
 	// Begin graceful shutdown
-	log.Println("Shutting down server...")
+	logger().Info("shutting down server")
+	notify("STOPPING=1")
 
 	// Create a deadline for shutdown
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 
-	// Signal refresh goroutine to stop and wait for it to finish
-	close(s.stopRefresh)
+	// Signal background goroutines to stop and wait for them to finish
+	close(s.stop)
+	backgroundDone := make(chan struct{})
+	go func() {
+		s.background.Wait()
+		close(backgroundDone)
+	}()
 
-	// Wait for refresh goroutine with timeout
+	// Wait for background goroutines with timeout
 	select {
-	case <-s.refreshDone:
-		log.Println("Refresh routine stopped successfully")
+	case <-backgroundDone:
+		logger().Info("background routines stopped")
 	case <-time.After(5 * time.Second):
-		log.Println("Refresh routine stop timed out")
+		logger().Warn("background routines stop timed out")
 	}
 
-	// Shut down HTTP server with timeout
+	// Shut down HTTP servers with timeout
+	if s.redirect != nil {
+		if err := s.redirect.Shutdown(ctx); err != nil {
+			logger().Error("redirect server shutdown error", "err", err)
+		}
+	}
 	if err := s.httpd.Shutdown(ctx); err != nil {
-		log.Printf("HTTP server shutdown error: %v", err)
+		logger().Error("http server shutdown error", "err", err)
 		return err
 	}
 
-	log.Println("Server shutdown complete")
+	// Only once nothing can report impressions any more
+	if s.impressions != nil {
+		if err := s.impressions.Close(); err != nil {
+			logger().Error("impressions database close error", "err", err)
+		}
+	}
+
+	logger().Info("server shutdown complete")
 	return nil
 }
@@ -0,0 +1,258 @@
+package server
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/pelletier/go-toml/v2"
+)
+
+// EnvPrefix is prepended to the upper-cased flag name to form its environment
+// variable, e.g. -refresh becomes GO_SIGNS_REFRESH
+const EnvPrefix = "GO_SIGNS_"
+
+// Names of the flags that control settings loading itself
+const (
+	configFlag      = "config"
+	printConfigFlag = "print-config"
+)
+
+// Sources a setting's effective value can come from, lowest precedence first
+const (
+	sourceDefault = "default"
+	sourceFile    = "file"
+	sourceEnv     = "env"
+	sourceFlag    = "flag"
+)
+
+// Settings tracks where every flag in a FlagSet got its effective value
+type Settings struct {
+	fs         *flag.FlagSet
+	sources    map[string]string
+	ConfigFile string
+	Print      bool
+}
+
+// LoadSettings registers -config and -print-config on fs, parses args and
+// fills in every other flag from, in order of precedence, the command line,
+// GO_SIGNS_* environment variables, the TOML config file and the flag default.
+// The config file is named by -config or GO_SIGNS_CONFIG and uses the flag
+// names as keys.
+func LoadSettings(fs *flag.FlagSet, args []string, getenv func(string) string) (*Settings, error) {
+	s := &Settings{
+		fs:      fs,
+		sources: make(map[string]string),
+	}
+	fs.StringVar(&s.ConfigFile, configFlag, "", "Path to TOML config file (env "+envName(configFlag)+")")
+	fs.BoolVar(&s.Print, printConfigFlag, false, "Print the effective configuration and exit")
+
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+
+	fs.VisitAll(func(f *flag.Flag) {
+		s.sources[f.Name] = sourceDefault
+	})
+	fs.Visit(func(f *flag.Flag) {
+		s.sources[f.Name] = sourceFlag
+	})
+
+	if s.sources[configFlag] != sourceFlag {
+		if v := getenv(envName(configFlag)); v != "" {
+			s.ConfigFile = v
+			s.sources[configFlag] = sourceEnv
+		}
+	}
+
+	fileValues := make(map[string]any)
+	if s.ConfigFile != "" {
+		var err error
+		fileValues, err = readConfigFile(s.ConfigFile)
+		if err != nil {
+			return nil, err
+		}
+		for key := range fileValues {
+			if !s.configurable(key) {
+				return nil, fmt.Errorf("unknown setting %q in config file %s", key, s.ConfigFile)
+			}
+		}
+	}
+
+	// Each flag takes its value from the single highest precedence source
+	var err error
+	fs.VisitAll(func(f *flag.Flag) {
+		if err != nil || !s.configurable(f.Name) || s.sources[f.Name] == sourceFlag {
+			return
+		}
+
+		if v := getenv(envName(f.Name)); v != "" {
+			if setErr := f.Value.Set(v); setErr != nil {
+				err = fmt.Errorf("invalid value %q for %s: %w", v, envName(f.Name), setErr)
+				return
+			}
+			s.sources[f.Name] = sourceEnv
+			return
+		}
+
+		if v, ok := fileValues[f.Name]; ok {
+			// Arrays set the flag once per element for repeatable flags
+			items, isArray := v.([]any)
+			if !isArray {
+				items = []any{v}
+			}
+			for _, item := range items {
+				if setErr := f.Value.Set(fmt.Sprint(item)); setErr != nil {
+					err = fmt.Errorf("invalid value %v for %q in config file %s: %w", item, f.Name, s.ConfigFile, setErr)
+					return
+				}
+			}
+			s.sources[f.Name] = sourceFile
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return s, nil
+}
+
+// readConfigFile reads the top level keys of a TOML config file
+func readConfigFile(path string) (map[string]any, error) {
+	if err := validateConfigFile(path); err != nil {
+		return nil, fmt.Errorf("invalid config file: %w", err)
+	}
+
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read config file: %w", err)
+	}
+
+	values := make(map[string]any)
+	if err := toml.Unmarshal(b, &values); err != nil {
+		return nil, fmt.Errorf("unable to parse config file %s: %w", path, err)
+	}
+
+	return values, nil
+}
+
+// configurable reports whether name is a flag that may be set from a file or
+// the environment
+func (s *Settings) configurable(name string) bool {
+	if name == configFlag || name == printConfigFlag {
+		return false
+	}
+	return s.fs.Lookup(name) != nil
+}
+
+// Source reports where the named flag got its value: "flag", "env", "file"
+// or "default"
+func (s *Settings) Source(name string) string {
+	return s.sources[name]
+}
+
+// Write writes the effective settings as TOML, annotated with their source
+func (s *Settings) Write(w io.Writer) error {
+	var names []string
+	s.fs.VisitAll(func(f *flag.Flag) {
+		if s.configurable(f.Name) {
+			names = append(names, f.Name)
+		}
+	})
+	sort.Strings(names)
+
+	if s.ConfigFile != "" {
+		if _, err := fmt.Fprintf(w, "# config file: %s\n", s.ConfigFile); err != nil {
+			return err
+		}
+	}
+
+	for _, name := range names {
+		f := s.fs.Lookup(name)
+		value := tomlValue(f.Value)
+		if isSecret(name) && f.Value.String() != "" {
+			value = `"********"`
+		}
+		if _, err := fmt.Fprintf(w, "%s = %s # %s\n", name, value, s.sources[name]); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// isSecret reports whether a flag holds a secret that must not be printed
+func isSecret(name string) bool {
+	return strings.HasSuffix(name, "-token") || strings.HasSuffix(name, "-password")
+}
+
+// tomlValue renders a flag value as a TOML literal
+func tomlValue(v flag.Value) string {
+	getter, ok := v.(flag.Getter)
+	if !ok {
+		return fmt.Sprintf("%q", v.String())
+	}
+	switch g := getter.Get().(type) {
+	case bool, int, int64, uint, uint64, float64:
+		return fmt.Sprint(g)
+	case []string:
+		quoted := make([]string, len(g))
+		for i, item := range g {
+			quoted[i] = fmt.Sprintf("%q", item)
+		}
+		return "[" + strings.Join(quoted, ", ") + "]"
+	default:
+		return fmt.Sprintf("%q", v.String())
+	}
+}
+
+// envName returns the environment variable for a flag name
+func envName(name string) string {
+	return EnvPrefix + strings.ToUpper(strings.ReplaceAll(name, "-", "_"))
+}
+
+// validateConfigFile checks if the config file exists and is a regular file
+func validateConfigFile(path string) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		return fmt.Errorf("unable to stat config file: %v", err)
+	}
+
+	if !info.Mode().IsRegular() {
+		return fmt.Errorf("config file %s is not a regular file", path)
+	}
+
+	return nil
+}
+
+// StringList is a repeatable flag collecting values in order. Each value may
+// also hold several comma separated entries, which suits environment variables.
+type StringList []string
+
+// String implements flag.Value
+func (l *StringList) String() string {
+	if l == nil {
+		return ""
+	}
+	return strings.Join(*l, ",")
+}
+
+// Set implements flag.Value
+func (l *StringList) Set(v string) error {
+	for _, item := range strings.Split(v, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		*l = append(*l, item)
+	}
+	return nil
+}
+
+// Get implements flag.Getter
+func (l *StringList) Get() any {
+	return []string(*l)
+}
@@ -0,0 +1,192 @@
+package server
+
+import (
+	"bytes"
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// newTestFlagSet mirrors the go-signs flags used for settings tests
+func newTestFlagSet() (*flag.FlagSet, *string, *string, *int) {
+	fs := flag.NewFlagSet("go-signs", flag.ContinueOnError)
+	port := fs.String("port", "2017", "")
+	jsonURL := fs.String("json", "https://example.com/signs", "")
+	refresh := fs.Int("refresh", 5, "")
+	return fs, port, jsonURL, refresh
+}
+
+func writeConfigFile(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "go-signs.toml")
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatalf("❌ Failed to write config file: %v", err)
+	}
+	return path
+}
+
+func TestLoadSettingsPrecedence(t *testing.T) {
+	configFile := writeConfigFile(t, "port = \"3000\"\nrefresh = 10\njson = \"https://file.example.com/signs\"\n")
+
+	env := map[string]string{
+		"GO_SIGNS_PORT":    "4000",
+		"GO_SIGNS_REFRESH": "15",
+	}
+	getenv := func(k string) string { return env[k] }
+
+	fs, port, jsonURL, refresh := newTestFlagSet()
+	settings, err := LoadSettings(fs, []string{"-config", configFile, "-port", "5000"}, getenv)
+	if err != nil {
+		t.Fatalf("❌ LoadSettings() unexpected error: %v", err)
+	}
+
+	tests := []struct {
+		name       string
+		got        any
+		want       any
+		wantSource string
+	}{
+		{"port", *port, "5000", "flag"},
+		{"refresh", *refresh, 15, "env"},
+		{"json", *jsonURL, "https://file.example.com/signs", "file"},
+	}
+
+	for _, tt := range tests {
+		if tt.got != tt.want {
+			t.Errorf("❌ %s = %v, want %v", tt.name, tt.got, tt.want)
+		}
+		if src := settings.Source(tt.name); src != tt.wantSource {
+			t.Errorf("❌ %s source = %s, want %s", tt.name, src, tt.wantSource)
+		} else {
+			t.Logf("✅ %s = %v from %s", tt.name, tt.got, src)
+		}
+	}
+}
+
+func TestLoadSettingsDefaults(t *testing.T) {
+	fs, port, _, refresh := newTestFlagSet()
+	settings, err := LoadSettings(fs, nil, func(string) string { return "" })
+	if err != nil {
+		t.Fatalf("❌ LoadSettings() unexpected error: %v", err)
+	}
+
+	if *port != "2017" || *refresh != 5 {
+		t.Errorf("❌ defaults not kept: port=%s refresh=%d", *port, *refresh)
+	}
+	if src := settings.Source("port"); src != "default" {
+		t.Errorf("❌ port source = %s, want default", src)
+	}
+}
+
+func TestLoadSettingsConfigFromEnv(t *testing.T) {
+	configFile := writeConfigFile(t, "refresh = 30\n")
+	getenv := func(k string) string {
+		if k == "GO_SIGNS_CONFIG" {
+			return configFile
+		}
+		return ""
+	}
+
+	fs, _, _, refresh := newTestFlagSet()
+	settings, err := LoadSettings(fs, nil, getenv)
+	if err != nil {
+		t.Fatalf("❌ LoadSettings() unexpected error: %v", err)
+	}
+
+	if settings.ConfigFile != configFile {
+		t.Errorf("❌ ConfigFile = %s, want %s", settings.ConfigFile, configFile)
+	}
+	if *refresh != 30 {
+		t.Errorf("❌ refresh = %d, want 30", *refresh)
+	}
+}
+
+func TestLoadSettingsErrors(t *testing.T) {
+	tests := []struct {
+		name        string
+		config      string
+		env         map[string]string
+		errContains string
+	}{
+		{
+			name:        "Unknown key in config file",
+			config:      "colour = \"blue\"\n",
+			errContains: "unknown setting \"colour\"",
+		},
+		{
+			name:        "Wrong type in config file",
+			config:      "refresh = \"often\"\n",
+			errContains: "invalid value often for \"refresh\"",
+		},
+		{
+			name:        "Malformed config file",
+			config:      "port = \n",
+			errContains: "unable to parse config file",
+		},
+		{
+			name:        "Invalid env value",
+			env:         map[string]string{"GO_SIGNS_REFRESH": "often"},
+			errContains: "invalid value \"often\" for GO_SIGNS_REFRESH",
+		},
+		{
+			name:        "Missing config file",
+			env:         map[string]string{"GO_SIGNS_CONFIG": "/nonexistent/go-signs.toml"},
+			errContains: "invalid config file",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var args []string
+			if tt.config != "" {
+				args = []string{"-config", writeConfigFile(t, tt.config)}
+			}
+			getenv := func(k string) string { return tt.env[k] }
+
+			fs, _, _, _ := newTestFlagSet()
+			_, err := LoadSettings(fs, args, getenv)
+			if err == nil {
+				t.Fatalf("❌ LoadSettings() expected error containing %q, got nil", tt.errContains)
+			}
+			if !strings.Contains(err.Error(), tt.errContains) {
+				t.Errorf("❌ LoadSettings() error = %v, want error containing %s", err, tt.errContains)
+			} else {
+				t.Logf("✅ LoadSettings() returned expected error: %v", err)
+			}
+		})
+	}
+}
+
+func TestSettingsWrite(t *testing.T) {
+	getenv := func(k string) string {
+		if k == "GO_SIGNS_JSON" {
+			return "https://env.example.com/signs"
+		}
+		return ""
+	}
+
+	fs, _, _, _ := newTestFlagSet()
+	fs.String("fleet-token", "", "")
+	settings, err := LoadSettings(fs, []string{"-refresh", "7", "-print-config", "-fleet-token", "supersecretvalue"}, getenv)
+	if err != nil {
+		t.Fatalf("❌ LoadSettings() unexpected error: %v", err)
+	}
+	if !settings.Print {
+		t.Errorf("❌ Print = false, want true")
+	}
+
+	var buf bytes.Buffer
+	if err := settings.Write(&buf); err != nil {
+		t.Fatalf("❌ Write() unexpected error: %v", err)
+	}
+
+	want := "fleet-token = \"********\" # flag\n" +
+		"json = \"https://env.example.com/signs\" # env\n" +
+		"port = \"2017\" # default\n" +
+		"refresh = 7 # flag\n"
+	if buf.String() != want {
+		t.Errorf("❌ Write() =\n%s\nwant\n%s", buf.String(), want)
+	}
+}
@@ -0,0 +1,43 @@
+package server
+
+import (
+	"time"
+
+	"github.com/kylerisse/go-signs/pkg/schedule"
+	"github.com/kylerisse/go-signs/pkg/sponsor"
+)
+
+// sessionSponsors finds the sponsors of sessions and rooms in the current
+// sponsor manifest, so they follow the manifest when it is reloaded
+type sessionSponsors struct {
+	manager *sponsor.Manager
+}
+
+// SessionSponsors implements schedule.Sponsors
+func (s sessionSponsors) SessionSponsors(room, topic string, start, end time.Time) []schedule.Sponsor {
+	return toScheduleSponsors(s.manager.Manifest().SessionSponsors(room, topic, start, end))
+}
+
+// RoomSponsors implements schedule.Sponsors
+func (s sessionSponsors) RoomSponsors(room string) []schedule.Sponsor {
+	return toScheduleSponsors(s.manager.Manifest().RoomSponsors(room))
+}
+
+// toScheduleSponsors converts sponsor placements for the schedule
+func toScheduleSponsors(placements []sponsor.Placement) []schedule.Sponsor {
+	if len(placements) == 0 {
+		return nil
+	}
+	sponsors := make([]schedule.Sponsor, len(placements))
+	for i, p := range placements {
+		sponsors[i] = schedule.Sponsor{
+			Name:     p.Name,
+			Tier:     p.Tier,
+			ImageURL: p.ImageURL,
+			Website:  p.Website,
+			From:     p.From,
+			Until:    p.Until,
+		}
+	}
+	return sponsors
+}
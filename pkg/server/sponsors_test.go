@@ -0,0 +1,108 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/kylerisse/go-signs/pkg/schedule"
+)
+
+func TestSessionSponsors(t *testing.T) {
+	source := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.ServeFile(w, r, filepath.Join("testdata", "sign.json"))
+	}))
+	defer source.Close()
+
+	// The test feed has a keynote in Ballroom DE on Sunday and a session in
+	// Room 106 on Saturday evening
+	manifest := filepath.Join(t.TempDir(), "sponsors.json")
+	if err := os.WriteFile(manifest, []byte(`{"tiers":[
+		{"name":"diamond","sponsors":[
+			"meta.png",
+			{"image":"google.png","name":"Google","rooms":["room 106"]},
+			{"image":"aws.png","topics":["Keynote"],"from":"2026-03-08T00:00:00-08:00"}
+		]},
+		{"name":"gold","sponsors":[
+			{"image":"microsoft.png","from":"2026-03-07T00:00:00-08:00","until":"2026-03-08T00:00:00-08:00"},
+			{"image":"redhat.png","rooms":["Room 106"],"until":"2026-03-06T00:00:00-08:00"}
+		]}
+	]}`), 0600); err != nil {
+		t.Fatalf("❌ Failed to write manifest: %v", err)
+	}
+
+	conf, err := NewConfig("7106", source.URL, 60)
+	if err != nil {
+		t.Fatalf("❌ Failed to create server config (%v)", err)
+	}
+	if err := conf.SetSponsorsFile(manifest); err != nil {
+		t.Fatalf("❌ SetSponsorsFile() unexpected error: %v", err)
+	}
+	s := NewServer(conf)
+	defer close(s.stop)
+	waitForSessionCount(t, s, 2)
+
+	get := func(path string, v any) int {
+		t.Helper()
+		rr := httptest.NewRecorder()
+		s.httpd.Handler.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, path, nil))
+		if rr.Code == http.StatusOK {
+			if err := json.NewDecoder(rr.Body).Decode(v); err != nil {
+				t.Fatalf("❌ Failed to decode %s: %v", path, err)
+			}
+		}
+		return rr.Code
+	}
+	names := func(sponsors []schedule.Sponsor) []string {
+		var n []string
+		for _, sp := range sponsors {
+			n = append(n, sp.Name)
+		}
+		return n
+	}
+
+	var sch struct {
+		Presentations []schedule.Presentation
+	}
+	get("/schedule", &sch)
+	want := map[string][]string{
+		"Ballroom DE": {"aws"},
+		"Room 106":    {"Google", "microsoft"},
+	}
+	for _, p := range sch.Presentations {
+		if got := names(p.Sponsors); len(got) != len(want[p.Location]) || (len(got) > 0 && got[0] != want[p.Location][0]) {
+			t.Errorf("❌ Sponsors of the session in %s = %v, want %v", p.Location, got, want[p.Location])
+		}
+	}
+	if !t.Failed() {
+		t.Logf("✅ Sessions carry the sponsors of their room, topic and time")
+	}
+
+	var rooms []schedule.Room
+	if code := get(schedule.RoomsPath, &rooms); code != http.StatusOK || len(rooms) != 2 {
+		t.Fatalf("❌ %s = %d %+v, want two rooms", schedule.RoomsPath, code, rooms)
+	}
+	if rooms[1].Name != "Room 106" || len(rooms[1].Sponsors) != 2 || rooms[1].Sponsors[0].ImageURL != "/sponsors/images/google.png" {
+		t.Errorf("❌ Room 106 = %+v, want Google and the expired Red Hat window", rooms[1])
+	}
+
+	var room schedule.Room
+	if code := get(schedule.RoomsPath+"/room%20106", &room); code != http.StatusOK {
+		t.Fatalf("❌ %s/room%%20106 = %d, want 200", schedule.RoomsPath, code)
+	}
+	if room.Name != "Room 106" || room.Sessions != 1 || len(room.Schedule) != 1 || len(room.Schedule[0].Sponsors) != 2 {
+		t.Errorf("❌ Room 106 = %+v, want its one session with its sponsors", room)
+	}
+	if len(room.Sponsors) != 2 || room.Sponsors[1].Until.IsZero() {
+		t.Errorf("❌ Room 106 sponsors = %+v, want Red Hat with its window", room.Sponsors)
+	} else {
+		t.Logf("✅ Door sign sponsors for %s: %v", room.Name, names(room.Sponsors))
+	}
+
+	if code := get(schedule.RoomsPath+"/Nowhere", &room); code != http.StatusNotFound {
+		t.Errorf("❌ Unknown room = %d, want 404", code)
+	}
+}
@@ -0,0 +1,154 @@
+package server
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/sys/unix"
+)
+
+// sdListenFDsStart is the first file descriptor passed by systemd socket
+// activation
+const sdListenFDsStart = 3
+
+// activationListeners returns the sockets passed in by systemd socket
+// activation keyed by their FileDescriptorName, or nil when the process was not
+// socket activated. The LISTEN_* variables are cleared so children don't
+// inherit them.
+func activationListeners() (map[string][]net.Listener, error) {
+	defer func() {
+		os.Unsetenv("LISTEN_PID")
+		os.Unsetenv("LISTEN_FDS")
+		os.Unsetenv("LISTEN_FDNAMES")
+	}()
+
+	pid, err := strconv.Atoi(os.Getenv("LISTEN_PID"))
+	if err != nil || pid != os.Getpid() {
+		return nil, nil
+	}
+
+	n, err := strconv.Atoi(os.Getenv("LISTEN_FDS"))
+	if err != nil || n < 1 {
+		return nil, nil
+	}
+
+	// systemd passes them in order from the first descriptor after stderr
+	fds := make([]int, n)
+	for i := range fds {
+		fds[i] = sdListenFDsStart + i
+	}
+	return listenersFromFDs(fds, os.Getenv("LISTEN_FDNAMES"))
+}
+
+// listenersFromFDs wraps the file descriptors fds as listeners, named by the
+// colon separated names, and takes ownership of them. On error the listeners
+// already wrapped are closed.
+func listenersFromFDs(fds []int, names string) (map[string][]net.Listener, error) {
+	nameList := strings.Split(names, ":")
+	listeners := make(map[string][]net.Listener)
+
+	for i, fd := range fds {
+		name := "unknown"
+		if i < len(nameList) && nameList[i] != "" {
+			name = nameList[i]
+		}
+
+		f := os.NewFile(uintptr(fd), name)
+		ln, err := net.FileListener(f)
+		f.Close()
+		if err != nil {
+			for _, ls := range listeners {
+				for _, l := range ls {
+					l.Close()
+				}
+			}
+			return nil, fmt.Errorf("inherited socket %d (%s) is not a listener: %w", fd, name, err)
+		}
+		listeners[name] = append(listeners[name], ln)
+	}
+
+	return listeners, nil
+}
+
+// sdNotify sends a state string such as "READY=1" to the systemd notify socket.
+// It returns false without error when not running under a notify service.
+func sdNotify(state string) (bool, error) {
+	socket := os.Getenv("NOTIFY_SOCKET")
+	if socket == "" {
+		return false, nil
+	}
+
+	// A leading @ names a socket in the abstract namespace
+	if strings.HasPrefix(socket, "@") {
+		socket = "\x00" + socket[1:]
+	}
+
+	conn, err := net.DialUnix("unixgram", nil, &net.UnixAddr{Name: socket, Net: "unixgram"})
+	if err != nil {
+		return false, err
+	}
+	defer conn.Close()
+
+	if _, err := conn.Write([]byte(state)); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// sdWatchdogInterval returns how often to send WATCHDOG=1, which is half the
+// WatchdogSec configured for the unit, or zero when the watchdog is disabled
+func sdWatchdogInterval() time.Duration {
+	usec, err := strconv.ParseInt(os.Getenv("WATCHDOG_USEC"), 10, 64)
+	if err != nil || usec <= 0 {
+		return 0
+	}
+
+	if pidStr := os.Getenv("WATCHDOG_PID"); pidStr != "" {
+		pid, err := strconv.Atoi(pidStr)
+		if err != nil || pid != os.Getpid() {
+			return 0
+		}
+	}
+
+	return time.Duration(usec) * time.Microsecond / 2
+}
+
+// monotonicUsec returns CLOCK_MONOTONIC in microseconds for RELOADING=1
+func monotonicUsec() int64 {
+	var ts unix.Timespec
+	if err := unix.ClockGettime(unix.CLOCK_MONOTONIC, &ts); err != nil {
+		return 0
+	}
+	return ts.Nano() / 1000
+}
+
+// watchdog pings the systemd watchdog every interval while the server can still
+// answer a request, so a hung server stops pinging and gets restarted
+func (s *Server) watchdog(interval time.Duration, stop <-chan struct{}) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			if s.healthy(interval) {
+				notify("WATCHDOG=1")
+			} else {
+				logger().Error("health check failed, skipping watchdog ping")
+			}
+		case <-stop:
+			return
+		}
+	}
+}
+
+// notify sends state to systemd, logging failures
+func notify(state string) {
+	if _, err := sdNotify(state); err != nil {
+		logger().Warn("unable to notify systemd", "state", state, "err", err)
+	}
+}
@@ -0,0 +1,254 @@
+package server
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/kylerisse/go-signs/pkg/schedule"
+)
+
+func TestSdNotify(t *testing.T) {
+	t.Setenv("NOTIFY_SOCKET", "")
+	sent, err := sdNotify("READY=1")
+	if sent || err != nil {
+		t.Errorf("❌ sdNotify() without NOTIFY_SOCKET = %v, %v, want false, nil", sent, err)
+	}
+
+	socket := filepath.Join(t.TempDir(), "notify.sock")
+	conn, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Name: socket, Net: "unixgram"})
+	if err != nil {
+		t.Fatalf("❌ Failed to listen on notify socket: %v", err)
+	}
+	defer conn.Close()
+	t.Setenv("NOTIFY_SOCKET", socket)
+
+	sent, err = sdNotify("READY=1")
+	if !sent || err != nil {
+		t.Fatalf("❌ sdNotify() = %v, %v, want true, nil", sent, err)
+	}
+
+	buf := make([]byte, 64)
+	conn.SetReadDeadline(time.Now().Add(time.Second))
+	n, err := conn.Read(buf)
+	if err != nil {
+		t.Fatalf("❌ Failed to read notification: %v", err)
+	}
+	if got := string(buf[:n]); got != "READY=1" {
+		t.Errorf("❌ notification = %q, want READY=1", got)
+	} else {
+		t.Logf("✅ systemd received %s", got)
+	}
+}
+
+func TestSdWatchdogInterval(t *testing.T) {
+	pid := strconv.Itoa(os.Getpid())
+
+	tests := []struct {
+		name string
+		usec string
+		pid  string
+		want time.Duration
+	}{
+		{"disabled", "", "", 0},
+		{"enabled", "2000000", "", time.Second},
+		{"enabled for this process", "30000000", pid, 15 * time.Second},
+		{"enabled for another process", "30000000", "1", 0},
+		{"invalid", "soon", "", 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("WATCHDOG_USEC", tt.usec)
+			t.Setenv("WATCHDOG_PID", tt.pid)
+			if got := sdWatchdogInterval(); got != tt.want {
+				t.Errorf("❌ sdWatchdogInterval() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestListenersFromFDs(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("❌ Failed to listen: %v", err)
+	}
+	defer ln.Close()
+
+	// Duplicate the descriptor, as systemd would pass it. listenersFromFDs takes
+	// ownership, so it must not also belong to an *os.File that closes it later.
+	f, err := ln.(*net.TCPListener).File()
+	if err != nil {
+		t.Fatalf("❌ Failed to get listener file: %v", err)
+	}
+	fd, err := syscall.Dup(int(f.Fd()))
+	f.Close()
+	if err != nil {
+		t.Fatalf("❌ Failed to duplicate listener: %v", err)
+	}
+
+	listeners, err := listenersFromFDs([]int{fd}, "http")
+	if err != nil {
+		t.Fatalf("❌ listenersFromFDs() unexpected error: %v", err)
+	}
+	if len(listeners["http"]) != 1 {
+		t.Fatalf("❌ listenersFromFDs() = %v, want one listener named http", listeners)
+	}
+	inherited := listeners["http"][0]
+	defer inherited.Close()
+
+	if inherited.Addr().String() != ln.Addr().String() {
+		t.Errorf("❌ inherited address = %s, want %s", inherited.Addr(), ln.Addr())
+	}
+
+	// A descriptor that isn't a socket fails the whole set, closing the
+	// listener already wrapped
+	sock, err := ln.(*net.TCPListener).File()
+	if err != nil {
+		t.Fatalf("❌ Failed to get listener file: %v", err)
+	}
+	sockFD, err := syscall.Dup(int(sock.Fd()))
+	sock.Close()
+	if err != nil {
+		t.Fatalf("❌ Failed to duplicate listener: %v", err)
+	}
+	file, err := os.CreateTemp(t.TempDir(), "not-a-socket")
+	if err != nil {
+		syscall.Close(sockFD)
+		t.Fatalf("❌ Failed to create file: %v", err)
+	}
+	fileFD, err := syscall.Dup(int(file.Fd()))
+	file.Close()
+	if err != nil {
+		syscall.Close(sockFD)
+		t.Fatalf("❌ Failed to duplicate file: %v", err)
+	}
+
+	if ls, err := listenersFromFDs([]int{sockFD, fileFD}, "http:file"); err == nil {
+		t.Errorf("❌ listenersFromFDs() with a regular file expected error, got %v", ls)
+	} else {
+		t.Logf("✅ regular file refused: %v", err)
+	}
+}
+
+func TestHealthy(t *testing.T) {
+	s := &Server{
+		schedule: schedule.NewSchedule(),
+		started:  time.Now().Add(-time.Hour),
+		config:   Config{RefreshInterval: time.Minute},
+	}
+
+	if s.healthy(time.Second) {
+		t.Errorf("❌ healthy() = true with no refresh for an hour, want false")
+	}
+
+	s.schedule.UpdateFromJSON()
+	if !s.healthy(time.Second) {
+		t.Errorf("❌ healthy() = false right after a refresh, want true")
+	} else {
+		t.Logf("✅ healthy after a refresh")
+	}
+}
+
+func TestListenUnixStaleSocket(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "go-signs.sock")
+
+	// Leave a socket file behind without anyone listening
+	stale, err := net.Listen("unix", path)
+	if err != nil {
+		t.Fatalf("❌ Failed to create socket: %v", err)
+	}
+	stale.(*net.UnixListener).SetUnlinkOnClose(false)
+	stale.Close()
+
+	ln, err := listenUnix(path)
+	if err != nil {
+		t.Fatalf("❌ listenUnix() over stale socket unexpected error: %v", err)
+	}
+	defer ln.Close()
+
+	// A live socket must not be taken over
+	if _, err := listenUnix(path); err == nil {
+		t.Errorf("❌ listenUnix() over live socket expected error, got nil")
+	}
+
+	// Nor may a regular file be removed
+	file := filepath.Join(t.TempDir(), "not-a-socket")
+	if err := os.WriteFile(file, []byte("data"), 0600); err != nil {
+		t.Fatalf("❌ Failed to write fixture: %v", err)
+	}
+	if _, err := listenUnix(file); err == nil {
+		t.Errorf("❌ listenUnix() over regular file expected error, got nil")
+	}
+}
+
+func TestServerUnixSocket(t *testing.T) {
+	source := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.ServeFile(w, r, filepath.Join("testdata", "sign.json"))
+	}))
+	defer source.Close()
+
+	path := filepath.Join(t.TempDir(), "go-signs.sock")
+	conf, err := NewConfig("2017", source.URL, 1)
+	if err != nil {
+		t.Fatalf("❌ Failed to create server config (%v)", err)
+	}
+	if err := conf.SetListenAddresses([]string{"unix:" + path}); err != nil {
+		t.Fatalf("❌ SetListenAddresses() unexpected error: %v", err)
+	}
+
+	// Capture READY=1 from the server
+	notifySocket := filepath.Join(t.TempDir(), "notify.sock")
+	notifyConn, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Name: notifySocket, Net: "unixgram"})
+	if err != nil {
+		t.Fatalf("❌ Failed to listen on notify socket: %v", err)
+	}
+	defer notifyConn.Close()
+	t.Setenv("NOTIFY_SOCKET", notifySocket)
+
+	server := NewServer(conf)
+	go func() {
+		if err := server.ListenAndServe(); err != nil {
+			t.Logf("Server stopped: %v", err)
+		}
+	}()
+
+	buf := make([]byte, 64)
+	notifyConn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	n, err := notifyConn.Read(buf)
+	if err != nil {
+		t.Fatalf("❌ server never notified systemd: %v", err)
+	}
+	if got := string(buf[:n]); got != "READY=1" {
+		t.Errorf("❌ notification = %q, want READY=1", got)
+	}
+
+	client := &http.Client{
+		Transport: &http.Transport{
+			DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+				return (&net.Dialer{}).DialContext(ctx, "unix", path)
+			},
+		},
+	}
+
+	resp, err := client.Get("http://go-signs/schedule")
+	if err != nil {
+		t.Fatalf("❌ request over unix socket failed: %v", err)
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("❌ Expected status 200 over unix socket, got %d: %s", resp.StatusCode, body)
+	} else {
+		t.Logf("✅ served %d bytes over %s", len(body), path)
+	}
+}
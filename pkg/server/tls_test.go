@@ -0,0 +1,241 @@
+package server
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+	"time"
+)
+
+// writeTestCertificate writes a self-signed certificate and key for
+// commonName into dir and returns their paths
+func writeTestCertificate(t *testing.T, dir string, commonName string) (string, string) {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("❌ Failed to generate key: %v", err)
+	}
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: commonName},
+		DNSNames:     []string{commonName},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("❌ Failed to create certificate: %v", err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatalf("❌ Failed to marshal key: %v", err)
+	}
+
+	certFile := filepath.Join(dir, "cert.pem")
+	keyFile := filepath.Join(dir, "key.pem")
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+	if err := os.WriteFile(certFile, certPEM, 0600); err != nil {
+		t.Fatalf("❌ Failed to write certificate: %v", err)
+	}
+	if err := os.WriteFile(keyFile, keyPEM, 0600); err != nil {
+		t.Fatalf("❌ Failed to write key: %v", err)
+	}
+
+	return certFile, keyFile
+}
+
+// leafCommonName returns the common name of the certificate served by r
+func leafCommonName(t *testing.T, r *certReloader) string {
+	t.Helper()
+	cert, err := r.GetCertificate(nil)
+	if err != nil {
+		t.Fatalf("❌ GetCertificate() unexpected error: %v", err)
+	}
+	leaf, err := x509.ParseCertificate(cert.Certificate[0])
+	if err != nil {
+		t.Fatalf("❌ Failed to parse certificate: %v", err)
+	}
+	return leaf.Subject.CommonName
+}
+
+func TestCertReloader(t *testing.T) {
+	dir := t.TempDir()
+	certFile, keyFile := writeTestCertificate(t, dir, "first.local")
+
+	r, err := newCertReloader(certFile, keyFile)
+	if err != nil {
+		t.Fatalf("❌ newCertReloader() unexpected error: %v", err)
+	}
+	if cn := leafCommonName(t, r); cn != "first.local" {
+		t.Errorf("❌ initial certificate = %s, want first.local", cn)
+	}
+
+	// Replace the files with a newer certificate
+	writeTestCertificate(t, dir, "second.local")
+	future := time.Now().Add(time.Minute)
+	if err := os.Chtimes(certFile, future, future); err != nil {
+		t.Fatalf("❌ Failed to set modification time: %v", err)
+	}
+	if err := os.Chtimes(keyFile, future, future); err != nil {
+		t.Fatalf("❌ Failed to set modification time: %v", err)
+	}
+
+	// Checks are rate limited, so force the next one
+	r.lastCheck = time.Time{}
+	if cn := leafCommonName(t, r); cn != "second.local" {
+		t.Errorf("❌ reloaded certificate = %s, want second.local", cn)
+	} else {
+		t.Logf("✅ certificate reloaded after change on disk")
+	}
+
+	// A broken replacement keeps the previous certificate
+	if err := os.WriteFile(certFile, []byte("not a certificate"), 0600); err != nil {
+		t.Fatalf("❌ Failed to write fixture: %v", err)
+	}
+	later := future.Add(time.Minute)
+	if err := os.Chtimes(certFile, later, later); err != nil {
+		t.Fatalf("❌ Failed to set modification time: %v", err)
+	}
+	r.lastCheck = time.Time{}
+	if cn := leafCommonName(t, r); cn != "second.local" {
+		t.Errorf("❌ certificate after failed reload = %s, want second.local", cn)
+	}
+
+	if _, err := newCertReloader(certFile, keyFile); err == nil {
+		t.Errorf("❌ newCertReloader() with invalid certificate expected error, got nil")
+	}
+}
+
+func TestRedirectHandler(t *testing.T) {
+	tests := []struct {
+		name      string
+		httpsPort int
+		host      string
+		path      string
+		want      string
+	}{
+		{"default port", 443, "signs.local", "/schedule?x=1", "https://signs.local/schedule?x=1"},
+		{"strips http port", 443, "signs.local:80", "/", "https://signs.local/"},
+		{"custom port", 8443, "10.0.0.5:8080", "/index.html", "https://10.0.0.5:8443/index.html"},
+		{"ipv6", 8443, "[fe80::1]:80", "/", "https://[fe80::1]:8443/"},
+		{"ipv6 default port", 443, "[fe80::1]", "/", "https://[fe80::1]/"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			req.Host = tt.host
+			rr := httptest.NewRecorder()
+			redirectHandler(tt.httpsPort).ServeHTTP(rr, req)
+
+			if rr.Code != http.StatusMovedPermanently {
+				t.Errorf("❌ status = %d, want %d", rr.Code, http.StatusMovedPermanently)
+			}
+			if got := rr.Header().Get("Location"); got != tt.want {
+				t.Errorf("❌ Location = %s, want %s", got, tt.want)
+			} else {
+				t.Logf("✅ redirected to %s", got)
+			}
+		})
+	}
+}
+
+func TestServerTLS(t *testing.T) {
+	certFile, keyFile := writeTestCertificate(t, t.TempDir(), "localhost")
+
+	source := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.ServeFile(w, r, filepath.Join("testdata", "sign.json"))
+	}))
+	defer source.Close()
+
+	// Ephemeral listeners keep the test off fixed ports
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("❌ Failed to listen: %v", err)
+	}
+	redirectLn, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		ln.Close()
+		t.Fatalf("❌ Failed to listen: %v", err)
+	}
+	httpsAddr := ln.Addr().String()
+
+	conf, err := NewConfig("7106", source.URL, 1)
+	if err != nil {
+		t.Fatalf("❌ Failed to create server config (%v)", err)
+	}
+	if err := conf.SetListenAddresses([]string{httpsAddr}); err != nil {
+		t.Fatalf("❌ SetListenAddresses() unexpected error: %v", err)
+	}
+	if err := conf.SetTLS(certFile, keyFile); err != nil {
+		t.Fatalf("❌ SetTLS() unexpected error: %v", err)
+	}
+	if err := conf.SetRedirectAddress(redirectLn.Addr().String()); err != nil {
+		t.Fatalf("❌ SetRedirectAddress() unexpected error: %v", err)
+	}
+
+	server := NewServer(conf)
+	done := make(chan error, 1)
+	go func() {
+		done <- server.serve([]net.Listener{ln}, redirectLn)
+	}()
+	t.Cleanup(func() {
+		server.quit <- syscall.SIGTERM
+		if err := <-done; err != nil {
+			t.Errorf("❌ server shutdown error: %v", err)
+		}
+	})
+
+	client := &http.Client{
+		Transport: &http.Transport{
+			TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
+		},
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+
+	t.Run("HTTPS", func(t *testing.T) {
+		resp, err := client.Get("https://" + httpsAddr + "/schedule")
+		if err != nil {
+			t.Fatalf("❌ HTTPS request failed: %v", err)
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			t.Errorf("❌ Expected status 200 over HTTPS, got %d", resp.StatusCode)
+		}
+		if resp.TLS == nil {
+			t.Errorf("❌ response was not served over TLS")
+		}
+	})
+
+	t.Run("Redirect", func(t *testing.T) {
+		resp, err := client.Get("http://" + redirectLn.Addr().String() + "/schedule")
+		if err != nil {
+			t.Fatalf("❌ HTTP request failed: %v", err)
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusMovedPermanently {
+			t.Errorf("❌ Expected status 301 from redirect listener, got %d", resp.StatusCode)
+		}
+		want := "https://" + httpsAddr + "/schedule"
+		if loc := resp.Header.Get("Location"); loc != want {
+			t.Errorf("❌ Location = %s, want %s", loc, want)
+		}
+	})
+}
@@ -0,0 +1,6 @@
+package server
+
+// Version is the go-signs version reported to the fleet controller. Release
+// builds set it with
+// -ldflags "-X github.com/kylerisse/go-signs/pkg/server.Version=0.3.0"
+var Version = "unstable"
@@ -4,7 +4,6 @@ import (
 	"fmt"
 	"io"
 	"io/fs"
-	"log"
 	"net/http"
 
 	bolt "go.etcd.io/bbolt"
@@ -29,7 +28,7 @@ func checkOrCreateJSONBucket(db *bolt.DB, archiveDir fs.FS) error {
 
 			// Check if the key already exists
 			if bucket.Get([]byte(key)) != nil {
-				log.Printf("JSON data for %s already exists, skipping", key)
+				logger().Debug("JSON data already exists, skipping", "key", key)
 				continue
 			}
 
@@ -42,7 +41,7 @@ func checkOrCreateJSONBucket(db *bolt.DB, archiveDir fs.FS) error {
 			if err != nil {
 				return fmt.Errorf("store archived JSON data for %s: %w", key, err)
 			}
-			log.Printf("Stored JSON data for %s (%d bytes)", key, len(data))
+			logger().Info("stored JSON data", "key", key, "bytes", len(data))
 		}
 
 		// drupal data next (23x and beyond)
@@ -51,17 +50,17 @@ func checkOrCreateJSONBucket(db *bolt.DB, archiveDir fs.FS) error {
 
 			// Check if the key already exists
 			if bucket.Get([]byte(key)) != nil {
-				log.Printf("JSON data for %s already exists, skipping", key)
+				logger().Debug("JSON data already exists, skipping", "key", key)
 				continue
 			}
 
 			// Key doesn't exist, fetch the data
 			url := fmt.Sprintf("https://www.socallinuxexpo.org/scale/%s/signs", key)
-			log.Printf("Fetching JSON data from %s", url)
+			logger().Info("fetching JSON data", "key", key, "url", url)
 
 			data, err := fetch(url)
 			if err != nil {
-				log.Printf("Warning: Failed to fetch JSON for %s: %v", key, err)
+				logger().Warn("unable to fetch JSON data", "key", key, "url", url, "err", err)
 				continue
 			}
 
@@ -69,7 +68,7 @@ func checkOrCreateJSONBucket(db *bolt.DB, archiveDir fs.FS) error {
 			if err != nil {
 				return fmt.Errorf("store JSON data for %s: %w", key, err)
 			}
-			log.Printf("Stored JSON data for %s (%d bytes)", key, len(data))
+			logger().Info("stored JSON data", "key", key, "bytes", len(data))
 		}
 
 		return nil
@@ -5,7 +5,7 @@ import (
 	"embed"
 	"fmt"
 	"io/fs"
-	"log"
+	"log/slog"
 	"net/http"
 	"os"
 	"os/signal"
@@ -13,6 +13,7 @@ import (
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/kylerisse/go-signs/pkg/logging"
 	bolt "go.etcd.io/bbolt"
 )
 
@@ -32,6 +33,11 @@ type Server struct {
 	lastScheduleRun time.Time
 }
 
+// logger returns the default logger tagged for this package
+func logger() *slog.Logger {
+	return slog.Default().With("component", "simulator")
+}
+
 // NewServer creates a new simulator server
 func NewServer(dbPath, port string) (*Server, error) {
 	// Create archivePath
@@ -54,7 +60,8 @@ func NewServer(dbPath, port string) (*Server, error) {
 
 	// Set up router
 	gin.SetMode(gin.ReleaseMode)
-	router := gin.Default()
+	router := gin.New()
+	router.Use(logging.GinMiddleware(nil), gin.Recovery())
 
 	// Create HTTP server
 	srv := &http.Server{
@@ -62,6 +69,7 @@ func NewServer(dbPath, port string) (*Server, error) {
 		Addr:         fmt.Sprintf(":%s", port),
 		WriteTimeout: 15 * time.Second,
 		ReadTimeout:  15 * time.Second,
+		ErrorLog:     slog.NewLogLogger(slog.Default().With("component", "http").Handler(), slog.LevelWarn),
 	}
 
 	// Create the server
@@ -95,14 +103,14 @@ func (s *Server) runScheduler(interval time.Duration) {
 	ticker := time.NewTicker(interval)
 	defer ticker.Stop()
 
-	log.Printf("Scheduler started with interval of %v", interval)
+	logger().Info("scheduler started", "interval", interval)
 
 	for {
 		select {
 		case <-ticker.C:
 			s.runScheduledCheck()
 		case <-s.stopScheduler:
-			log.Println("Scheduler stopping...")
+			logger().Info("scheduler stopping")
 			return
 		}
 	}
@@ -111,12 +119,12 @@ func (s *Server) runScheduler(interval time.Duration) {
 // runScheduledCheck performs a single simulation check
 func (s *Server) runScheduledCheck() {
 	now := time.Now()
-	log.Printf("Running scheduled simulation check at %s", now.Format("2006-01-02 15:04:05"))
+	logger().Debug("running scheduled simulation check")
 
 	if err := checkOrCreateSimulationBucket(s.db); err != nil {
-		log.Printf("Error in simulation check: %v", err)
+		logger().Error("simulation check failed", "err", err)
 	} else {
-		log.Println("Simulation check completed successfully")
+		logger().Info("simulation check completed")
 	}
 
 	s.lastScheduleRun = now
@@ -131,7 +139,7 @@ func (s *Server) ListenAndServe() error {
 	// Start HTTP server in a goroutine
 	serverErrors := make(chan error, 1)
 	go func() {
-		log.Printf("simulator listening on %s", s.httpd.Addr)
+		logger().Info("listening", "addr", s.httpd.Addr)
 		if err := s.httpd.ListenAndServe(); err != nil && err != http.ErrServerClosed {
 			serverErrors <- err
 		}
@@ -139,15 +147,15 @@ func (s *Server) ListenAndServe() error {
 
 	// Wait for termination signal or server error
 	select {
-	case <-quit:
-		log.Println("Shutdown signal received...")
+	case sig := <-quit:
+		logger().Info("shutdown signal received", "signal", sig.String())
 	case err := <-serverErrors:
-		log.Printf("Server error: %v", err)
+		logger().Error("server error", "err", err)
 		return err
 	}
 
 	// Begin graceful shutdown
-	log.Println("Shutting down server...")
+	logger().Info("shutting down server")
 
 	// Create a deadline for shutdown
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
@@ -159,23 +167,23 @@ func (s *Server) ListenAndServe() error {
 	// Wait for scheduler to finish with timeout
 	select {
 	case <-s.schedulerDone:
-		log.Println("Scheduler stopped successfully")
+		logger().Info("scheduler stopped")
 	case <-time.After(5 * time.Second):
-		log.Println("Scheduler stop timed out")
+		logger().Warn("scheduler stop timed out")
 	}
 
 	// Close database connection
 	if err := s.db.Close(); err != nil {
-		log.Printf("Error closing database: %v", err)
+		logger().Error("unable to close database", "err", err)
 	}
 
 	// Shut down HTTP server with timeout
 	if err := s.httpd.Shutdown(ctx); err != nil {
-		log.Printf("HTTP server shutdown error: %v", err)
+		logger().Error("http server shutdown error", "err", err)
 		return err
 	}
 
-	log.Println("Server shutdown complete")
+	logger().Info("server shutdown complete")
 	return nil
 }
 
@@ -199,7 +207,7 @@ func openDatabase(dbPath string) (*bolt.DB, error) {
 		return nil, fmt.Errorf("failed to open database: %w", err)
 	}
 
-	log.Printf("Successfully opened database: %s", dbPath)
+	logger().Info("opened database", "path", dbPath)
 	return db, nil
 }
 
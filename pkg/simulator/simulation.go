@@ -3,7 +3,6 @@ package simulator
 import (
 	"encoding/json"
 	"fmt"
-	"log"
 	"math/rand"
 	"time"
 
@@ -28,20 +27,20 @@ func checkOrCreateSimulationBucket(db *bolt.DB) error {
 		if endDateBytes == nil {
 			// If endDate doesn't exist, we need to reset
 			resetNeeded = true
-			log.Println("No endDate found, will initialize simulation bucket")
+			logger().Info("no endDate found, will initialize simulation bucket")
 		} else {
 			// Check if today is past the endDate
 			endDateStr := string(endDateBytes)
 			endDate, err := time.Parse("2006-01-02", endDateStr)
 			if err != nil {
-				log.Printf("Invalid endDate format: %s, will reset", endDateStr)
+				logger().Warn("invalid endDate format, will reset", "endDate", endDateStr, "err", err)
 				resetNeeded = true
 			} else if today.After(endDate) {
-				log.Printf("Current date %s is past endDate %s, will reset simulation",
-					today.Format("2006-01-02"), endDateStr)
+				logger().Info("current date is past endDate, will reset simulation",
+					"today", today.Format("2006-01-02"), "endDate", endDateStr)
 				resetNeeded = true
 			} else {
-				log.Printf("Simulation bucket has valid endDate: %s", endDateStr)
+				logger().Debug("simulation bucket has valid endDate", "endDate", endDateStr)
 			}
 		}
 
@@ -50,20 +49,20 @@ func checkOrCreateSimulationBucket(db *bolt.DB) error {
 			mockJSONBytes := bucket.Get([]byte("mockJSON"))
 			if mockJSONBytes == nil {
 				resetNeeded = true
-				log.Println("No mockJSON found in simulation bucket, will reset")
+				logger().Info("no mockJSON found in simulation bucket, will reset")
 			} else {
 				// Parse and check if there are any running or upcoming events
 				presentations, err := schedule.DrupalToPresentations(mockJSONBytes)
 				if err != nil {
-					log.Printf("Error parsing presentations: %v", err)
+					logger().Error("unable to parse presentations", "err", err)
 					resetNeeded = true
 				} else {
 					hasEvents := hasRunningOrUpcomingEvents(presentations, today)
 					if !hasEvents {
 						resetNeeded = true
-						log.Println("No running or upcoming events in simulation, will reset")
+						logger().Info("no running or upcoming events in simulation, will reset")
 					} else {
-						log.Println("Simulation has running or upcoming events, no reset needed")
+						logger().Debug("simulation has running or upcoming events, no reset needed")
 					}
 				}
 			}
@@ -85,7 +84,7 @@ func checkOrCreateSimulationBucket(db *bolt.DB) error {
 				return fmt.Errorf("failed to set endDate: %w", err)
 			}
 
-			log.Printf("Reset simulation bucket with new endDate: %s", endDateValue)
+			logger().Info("reset simulation bucket", "endDate", endDateValue)
 
 			// Create simulated conference data
 			if err := createSimulatedConferenceData(tx, today); err != nil {
@@ -106,20 +105,20 @@ func hasRunningOrUpcomingEvents(presentations []schedule.Presentation, now time.
 	for _, p := range presentations {
 		// Check if the presentation is currently running
 		if now.After(p.StartTime) && now.Before(p.EndTime) {
-			log.Printf("Found currently running presentation: %s", p.Name)
+			logger().Debug("found currently running presentation", "name", p.Name)
 			return true
 		}
 
 		// Check if the presentation will start within the next 24 hours
 		if p.StartTime.After(now) && p.StartTime.Before(cutoff) {
-			log.Printf("Found upcoming presentation within 24h: %s at %s",
-				p.Name, p.StartTime.Format("2006-01-02 15:04:05"))
+			logger().Debug("found upcoming presentation within 24h",
+				"name", p.Name, "start", p.StartTime.Format(time.RFC3339))
 			return true
 		}
 	}
 
 	// No running or upcoming events found
-	log.Println("No running or upcoming events found in the next 24 hours")
+	logger().Debug("no running or upcoming events found in the next 24 hours")
 	return false
 }
 
@@ -150,7 +149,7 @@ func createSimulatedConferenceData(tx *bolt.Tx, today time.Time) error {
 
 	// Randomly select ONE conference to be the primary one with date shifting
 	selectedKey := jsonKeys[rand.Intn(len(jsonKeys))]
-	log.Printf("Selected %s as primary conference with date shifting", selectedKey)
+	logger().Info("selected primary conference for date shifting", "key", selectedKey)
 
 	// Get the JSON data for the selected conference
 	jsonData := jsonBucket.Get([]byte(selectedKey))
@@ -180,23 +179,23 @@ func createSimulatedConferenceData(tx *bolt.Tx, today time.Time) error {
 		// Get the JSON data for this conference
 		jsonData := jsonBucket.Get([]byte(key))
 		if jsonData == nil {
-			log.Printf("Warning: no data found for key %s, skipping", key)
+			logger().Warn("no data found, skipping", "key", key)
 			continue
 		}
 
 		// Parse the JSON data into nodes structure (keeping original dates)
 		var additionalNodes []schedule.DrupalNode
 		if err := json.Unmarshal(jsonData, &additionalNodes); err != nil {
-			log.Printf("Warning: error parsing JSON for %s: %v, skipping", key, err)
+			logger().Warn("unable to parse JSON, skipping", "key", key, "err", err)
 			continue
 		}
 
 		// Add these nodes to our merged structure
-		log.Printf("Adding %d nodes from %s with original dates", len(additionalNodes), key)
+		logger().Debug("adding nodes with original dates", "key", key, "sessions", len(additionalNodes))
 		mergedNodes = append(mergedNodes, additionalNodes...)
 	}
 
-	log.Printf("Merged a total of %d nodes from all JSON data sources", len(mergedNodes))
+	logger().Info("merged nodes from all JSON data sources", "sessions", len(mergedNodes))
 
 	// Marshal the merged nodes back to JSON
 	finalJSON, err := json.MarshalIndent(mergedNodes, "", "  ")
@@ -209,8 +208,8 @@ func createSimulatedConferenceData(tx *bolt.Tx, today time.Time) error {
 		return fmt.Errorf("failed to store mockJSON: %w", err)
 	}
 
-	log.Printf("Created mockJSON from %s with date-shifted sessions plus original sessions from other conferences",
-		selectedKey)
+	logger().Info("created mockJSON with date-shifted sessions plus original sessions from other conferences",
+		"key", selectedKey)
 
 	return nil
 }
@@ -237,12 +236,12 @@ func modifyJSONDates(jsonData []byte, today time.Time) ([]byte, error) {
 	for _, node := range origNodes {
 		origStart, err := time.Parse(time.RFC3339, node.StartTime)
 		if err != nil {
-			log.Printf("Invalid StartTime in %v", node)
+			logger().Warn("invalid StartTime", "name", node.Name, "start", node.StartTime, "err", err)
 			continue
 		}
 		origEnd, err := time.Parse(time.RFC3339, node.EndTime)
 		if err != nil {
-			log.Printf("Invalid EndTime in %v", node)
+			logger().Warn("invalid EndTime", "name", node.Name, "end", node.EndTime, "err", err)
 			continue
 		}
 
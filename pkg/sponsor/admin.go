@@ -0,0 +1,477 @@
+package sponsor
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"image"
+	"image/png"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/kylerisse/go-signs/pkg/fleet"
+)
+
+// Admin paths. PUT and DELETE on AdminLogosPath/<image> and
+// AdminTiersPath/<tier> change one logo or tier.
+const (
+	AdminLogosPath = "/admin/sponsors/logos"
+	AdminTiersPath = "/admin/sponsors/tiers"
+)
+
+// maxTierBody limits the tier JSON accepted by the admin API
+const maxTierBody = 1 << 20
+
+// Admin changes the sponsor logos in the images directory and the tiers in
+// the manifest file for requests carrying its bearer token. Changes are served
+// as soon as they are written, and displays pick them up through Version.
+type Admin struct {
+	manager      *Manager
+	imagesDir    string
+	manifestPath string
+	token        string
+
+	mutex sync.Mutex // Serializes changes to the directory and manifest
+}
+
+// NewAdmin changes the logos in imagesDir and the tiers in the manifest at
+// manifestPath, which manager must serve, for requests carrying token
+func NewAdmin(manager *Manager, imagesDir, manifestPath, token string) *Admin {
+	return &Admin{
+		manager:      manager,
+		imagesDir:    imagesDir,
+		manifestPath: manifestPath,
+		token:        token,
+	}
+}
+
+// authorized rejects requests without the admin token, reporting whether the
+// request may go on
+func (a *Admin) authorized(w http.ResponseWriter, r *http.Request) bool {
+	if !fleet.Authorized(r, a.token) {
+		logger().Warn("rejected unauthorized admin request", "client", r.RemoteAddr, "path", r.URL.Path)
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return false
+	}
+	return true
+}
+
+// validateLogoName checks that name is a plain file name for a logo. Uploaded
+// SVGs are kept as vectors and anything else is stored as a PNG, so those are
+// the only extensions accepted.
+func validateLogoName(name string) error {
+	if name == "" || name != path.Base(name) || strings.HasPrefix(name, ".") {
+		return fmt.Errorf("logo name %q must be a file name", name)
+	}
+	if ext := path.Ext(name); ext != ".png" && ext != ".svg" {
+		return fmt.Errorf("logo name %q must end in .png, or .svg for an SVG", name)
+	}
+	return nil
+}
+
+// normalizeLogo returns an uploaded logo as it is stored: SVGs sanitized and
+// anything else decoded and padded to a 220x220 PNG on white, as imports are
+func normalizeLogo(name string, data []byte) ([]byte, error) {
+	if isSVG(name) {
+		svg, config, err := sanitizeSVG(data)
+		if err != nil {
+			return nil, err
+		}
+		if config.Width == 0 || config.Height == 0 {
+			return nil, errors.New("SVG has no width, height or viewBox to scale from")
+		}
+		return svg, nil
+	}
+
+	config, format, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, errors.New("not a PNG, JPEG or GIF image")
+	}
+	if err := checkLogoPixels(config); err != nil {
+		return nil, fmt.Errorf("%s %w", format, err)
+	}
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("unable to decode %s: %w", format, err)
+	}
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, padLogo(img)); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// clone returns a copy of the manifest whose tiers can be changed
+func (m *Manifest) clone() *Manifest {
+	c := &Manifest{Tiers: slices.Clone(m.Tiers)}
+	for i := range c.Tiers {
+		c.Tiers[i].Sponsors = slices.Clone(c.Tiers[i].Sponsors)
+	}
+	return c
+}
+
+// removeImage drops image from every tier, returning the sponsor entry it had
+// in the first tier listing it
+func (m *Manifest) removeImage(image string) (Sponsor, bool) {
+	var removed Sponsor
+	found := false
+	for i, t := range m.Tiers {
+		m.Tiers[i].Sponsors = slices.DeleteFunc(t.Sponsors, func(s Sponsor) bool {
+			if s.Image != image {
+				return false
+			}
+			if !found {
+				removed, found = s, true
+			}
+			return true
+		})
+	}
+	return removed, found
+}
+
+// HandleLogo uploads or replaces the logo named by the last path element with
+// the request body on PUT, or deletes it on DELETE. An upload may place the
+// logo in a tier with ?tier=, creating the tier after the others if needed,
+// and set its name and website with ?name= and ?website=. A replaced logo
+// keeps its tiers unless one is given.
+func (a *Admin) HandleLogo(w http.ResponseWriter, r *http.Request) {
+	if !a.authorized(w, r) {
+		return
+	}
+
+	name := path.Base(r.URL.Path)
+	if err := validateLogoName(name); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodPut:
+		a.putLogo(w, r, name)
+	case http.MethodDelete:
+		a.deleteLogo(w, name)
+	default:
+		w.Header().Set("Allow", "PUT, DELETE")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// putLogo stores an uploaded logo and places it as the query asks
+func (a *Admin) putLogo(w http.ResponseWriter, r *http.Request, name string) {
+	data, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxLogoSize))
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		http.Error(w, fmt.Sprintf("logo is larger than %d bytes", maxLogoSize), http.StatusRequestEntityTooLarge)
+		return
+	}
+	if err != nil {
+		http.Error(w, "unable to read logo: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// Decoding under the lock keeps concurrent uploads to one image in memory
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+
+	logo, err := normalizeLogo(name, data)
+	if err != nil {
+		http.Error(w, "invalid logo: "+err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+
+	q := r.URL.Query()
+	manifest := a.manager.Manifest().clone()
+	changed := q.Has("tier") || q.Has("name") || q.Has("website")
+	if changed {
+		if err := placeLogo(manifest, name, q); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+
+	file := filepath.Join(a.imagesDir, name)
+	previous, readErr := os.ReadFile(file)
+	created := errors.Is(readErr, os.ErrNotExist)
+	if readErr != nil && !created {
+		logger().Error("unable to read sponsor logo", "image", name, "err", readErr)
+		http.Error(w, "unable to store logo", http.StatusInternalServerError)
+		return
+	}
+	if err := writeFileAtomic(file, logo); err != nil {
+		logger().Error("unable to store sponsor logo", "image", name, "err", err)
+		http.Error(w, "unable to store logo", http.StatusInternalServerError)
+		return
+	}
+	a.manager.rescanImages()
+	logger().Info("sponsor logo uploaded", "image", name, "bytes", len(logo), "created", created)
+
+	if changed && !a.saveManifest(w, manifest) {
+		a.restoreLogo(file, previous, created)
+		return
+	}
+
+	status := http.StatusOK
+	if created {
+		status = http.StatusCreated
+	}
+	a.writeEntries(w, status, name)
+}
+
+// restoreLogo puts back the logo file an upload replaced, or removes it when
+// the upload created it, so a logo isn't live without its manifest entry
+func (a *Admin) restoreLogo(file string, previous []byte, created bool) {
+	var err error
+	if created {
+		err = os.Remove(file)
+	} else {
+		err = writeFileAtomic(file, previous)
+	}
+	if err != nil {
+		logger().Error("unable to restore sponsor logo", "image", filepath.Base(file), "err", err)
+	}
+	a.manager.rescanImages()
+}
+
+// placeLogo moves image into the tier the query names, or updates it where it
+// is when there is none, setting the name and website the query has
+func placeLogo(manifest *Manifest, image string, q url.Values) error {
+	update := func(s *Sponsor) {
+		if q.Has("name") {
+			s.Name = q.Get("name")
+		}
+		if q.Has("website") {
+			s.Website = q.Get("website")
+		}
+	}
+
+	tier := q.Get("tier")
+	if tier == "" {
+		found := false
+		for i := range manifest.Tiers {
+			for j := range manifest.Tiers[i].Sponsors {
+				if manifest.Tiers[i].Sponsors[j].Image == image {
+					update(&manifest.Tiers[i].Sponsors[j])
+					found = true
+				}
+			}
+		}
+		if !found {
+			return fmt.Errorf("logo %s is in no tier, so a tier is needed to set its name or website", image)
+		}
+		return manifest.Validate()
+	}
+
+	s, found := manifest.removeImage(image)
+	if !found {
+		s = Sponsor{Image: image}
+	}
+	update(&s)
+	i := slices.IndexFunc(manifest.Tiers, func(t Tier) bool { return t.Name == tier })
+	if i < 0 {
+		manifest.Tiers = append(manifest.Tiers, Tier{Name: tier})
+		i = len(manifest.Tiers) - 1
+	}
+	manifest.Tiers[i].Sponsors = append(manifest.Tiers[i].Sponsors, s)
+	return manifest.Validate()
+}
+
+// deleteLogo removes a logo from the images directory and from every tier. An
+// embedded image of the same name is served again once the file is gone.
+func (a *Admin) deleteLogo(w http.ResponseWriter, name string) {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+
+	manifest := a.manager.Manifest().clone()
+	_, listed := manifest.removeImage(name)
+
+	err := os.Remove(filepath.Join(a.imagesDir, name))
+	onDisk := err == nil
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		logger().Error("unable to delete sponsor logo", "image", name, "err", err)
+		http.Error(w, "unable to delete logo", http.StatusInternalServerError)
+		return
+	}
+	if !onDisk && !listed {
+		http.Error(w, "unknown sponsor logo "+name, http.StatusNotFound)
+		return
+	}
+	if onDisk {
+		a.manager.rescanImages()
+		logger().Info("sponsor logo deleted", "image", name)
+	}
+
+	if listed && !a.saveManifest(w, manifest) {
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// HandleTier replaces or adds the tier named by the last path element with
+// the tier JSON in the body on PUT, or removes it on DELETE. Every sponsor in
+// a tier that is put must have a decodable image.
+func (a *Admin) HandleTier(w http.ResponseWriter, r *http.Request) {
+	if !a.authorized(w, r) {
+		return
+	}
+
+	name := path.Base(r.URL.Path)
+	if err := validateTierName(name); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodPut:
+		a.putTier(w, r, name)
+	case http.MethodDelete:
+		a.deleteTier(w, name)
+	default:
+		w.Header().Set("Allow", "PUT, DELETE")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// putTier replaces the tier in place, or adds it after the others
+func (a *Admin) putTier(w http.ResponseWriter, r *http.Request, name string) {
+	var tier Tier
+	dec := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxTierBody))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&tier); err != nil {
+		http.Error(w, "invalid tier: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	if tier.Name == "" {
+		tier.Name = name
+	}
+	if tier.Name != name {
+		http.Error(w, fmt.Sprintf("tier name %s doesn't match the path", tier.Name), http.StatusBadRequest)
+		return
+	}
+	if tier.Sponsors == nil {
+		tier.Sponsors = []Sponsor{}
+	}
+
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+
+	manifest := a.manager.Manifest().clone()
+	if i := slices.IndexFunc(manifest.Tiers, func(t Tier) bool { return t.Name == name }); i >= 0 {
+		manifest.Tiers[i] = tier
+	} else {
+		manifest.Tiers = append(manifest.Tiers, tier)
+	}
+	if err := manifest.Validate(); err != nil {
+		http.Error(w, "invalid tier: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	var problems []Problem
+	for _, p := range a.manager.Check(manifest).Errors() {
+		if p.Tier == name && p.Kind != ProblemWrongSize {
+			problems = append(problems, p)
+		}
+	}
+	if len(problems) > 0 {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		if err := json.NewEncoder(w).Encode(problems); err != nil {
+			logger().Error("unable to encode tier problems", "tier", name, "err", err)
+		}
+		return
+	}
+
+	if !a.saveManifest(w, manifest) {
+		return
+	}
+	logger().Info("sponsor tier saved", "tier", name, "sponsors", len(tier.Sponsors))
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(tier); err != nil {
+		logger().Error("unable to encode tier", "tier", name, "err", err)
+	}
+}
+
+// deleteTier removes a tier, leaving its logos in the images directory
+func (a *Admin) deleteTier(w http.ResponseWriter, name string) {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+
+	manifest := a.manager.Manifest().clone()
+	i := slices.IndexFunc(manifest.Tiers, func(t Tier) bool { return t.Name == name })
+	if i < 0 {
+		http.Error(w, "unknown sponsor tier "+name, http.StatusNotFound)
+		return
+	}
+	manifest.Tiers = slices.Delete(manifest.Tiers, i, i+1)
+	if err := manifest.Validate(); err != nil {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
+
+	if !a.saveManifest(w, manifest) {
+		return
+	}
+	logger().Info("sponsor tier deleted", "tier", name)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// saveManifest writes manifest to the manifest file and serves it, replying
+// with an error and returning false if it can't be written
+func (a *Admin) saveManifest(w http.ResponseWriter, manifest *Manifest) bool {
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err == nil {
+		err = writeFileAtomic(a.manifestPath, append(data, '\n'))
+	}
+	if err != nil {
+		logger().Error("unable to save sponsor manifest", "path", a.manifestPath, "err", err)
+		http.Error(w, "unable to save sponsor tiers", http.StatusInternalServerError)
+		return false
+	}
+
+	a.manager.SetManifest(manifest)
+	return true
+}
+
+// writeEntries replies with the listing entries of image, one per tier
+func (a *Admin) writeEntries(w http.ResponseWriter, status int, image string) {
+	entries := slices.DeleteFunc(a.manager.Manifest().Listing().Sponsors, func(e Entry) bool {
+		return e.Image != image
+	})
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(entries); err != nil {
+		logger().Error("unable to encode sponsors", "image", image, "err", err)
+	}
+}
+
+// writeFileAtomic replaces path with data so readers never see a partial file
+func writeFileAtomic(path string, data []byte) error {
+	f, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Chmod(0644); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(f.Name(), path)
+}
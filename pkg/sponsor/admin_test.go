@@ -0,0 +1,305 @@
+package sponsor
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"io/fs"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+)
+
+const testAdminToken = "0123456789abcdef"
+
+// adminRequest sends method to path on admin with the admin token and body
+func adminRequest(t *testing.T, handler http.HandlerFunc, method, path string, body []byte) *httptest.ResponseRecorder {
+	t.Helper()
+	req := httptest.NewRequest(method, path, bytes.NewReader(body))
+	req.Header.Set("Authorization", "Bearer "+testAdminToken)
+	rr := httptest.NewRecorder()
+	handler(rr, req)
+	return rr
+}
+
+// testJPEG returns a solid width x height JPEG
+func testJPEG(t *testing.T, width, height int) []byte {
+	t.Helper()
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	for x := range width {
+		for y := range height {
+			img.Set(x, y, color.RGBA{R: 0x20, G: 0x54, B: 0x93, A: 0xff})
+		}
+	}
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, img, nil); err != nil {
+		t.Fatalf("❌ Failed to encode JPEG: %v", err)
+	}
+	return buf.Bytes()
+}
+
+func TestAdminLogos(t *testing.T) {
+	dir := t.TempDir()
+	manifestPath := filepath.Join(t.TempDir(), "sponsors.json")
+	if err := os.WriteFile(manifestPath, []byte(`{"tiers":[{"name":"gold","sponsors":["aws.png"]}]}`), 0600); err != nil {
+		t.Fatalf("❌ Failed to write manifest: %v", err)
+	}
+
+	manager, err := NewManager(dir)
+	if err != nil {
+		t.Fatalf("❌ Failed to create sponsor manager: %v", err)
+	}
+	manifest, err := LoadManifest(manifestPath)
+	if err != nil {
+		t.Fatalf("❌ LoadManifest() unexpected error: %v", err)
+	}
+	manager.SetManifest(manifest)
+	admin := NewAdmin(manager, dir, manifestPath, testAdminToken)
+	version := manager.Version()
+
+	rr := httptest.NewRecorder()
+	admin.HandleLogo(rr, httptest.NewRequest(http.MethodPut, AdminLogosPath+"/acme.png", bytes.NewReader(testJPEG(t, 400, 200))))
+	if rr.Code != http.StatusUnauthorized {
+		t.Fatalf("❌ Upload without a token = %d, want 401", rr.Code)
+	}
+
+	rr = adminRequest(t, admin.HandleLogo, http.MethodPut, AdminLogosPath+"/acme.png?tier=silver&name=Acme&website=https://acme.example", testJPEG(t, 400, 200))
+	if rr.Code != http.StatusCreated {
+		t.Fatalf("❌ Upload = %d, want 201: %s", rr.Code, rr.Body.String())
+	}
+
+	f, err := os.Open(filepath.Join(dir, "acme.png"))
+	if err != nil {
+		t.Fatalf("❌ Logo not stored: %v", err)
+	}
+	config, format, err := image.DecodeConfig(f)
+	f.Close()
+	if err != nil || format != "png" || config.Width != imageSize || config.Height != imageSize {
+		t.Errorf("❌ Stored logo = %s %dx%d (%v), want a 220x220 png", format, config.Width, config.Height, err)
+	}
+
+	saved, err := LoadManifest(manifestPath)
+	if err != nil {
+		t.Fatalf("❌ Saved manifest doesn't load: %v", err)
+	}
+	silver, ok := saved.Tier("silver")
+	if !ok || len(silver.Sponsors) != 1 || !reflect.DeepEqual(silver.Sponsors[0], Sponsor{Image: "acme.png", Name: "Acme", Website: "https://acme.example"}) {
+		t.Errorf("❌ Saved tiers = %+v, want acme.png in a new silver tier", saved.Tiers)
+	}
+	if got := manager.Manifest().Names(); !slices.Equal(got, []string{"gold", "silver"}) {
+		t.Errorf("❌ Served tiers = %v, want [gold silver]", got)
+	}
+	if !slices.Contains(allSponsors(t, manager), "acme.png") {
+		t.Errorf("❌ /sponsors/all doesn't list the uploaded logo")
+	}
+	if manager.Version() == version {
+		t.Errorf("❌ Version didn't change with an upload")
+	}
+
+	// A replacement keeps its place, and a tier moves it
+	rr = adminRequest(t, admin.HandleLogo, http.MethodPut, AdminLogosPath+"/acme.png", testJPEG(t, 100, 100))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("❌ Replace = %d, want 200: %s", rr.Code, rr.Body.String())
+	}
+	if tier, _ := manager.Manifest().Tier("silver"); !slices.Equal(tier.Images(), []string{"acme.png"}) {
+		t.Errorf("❌ Replaced logo left its tier: %+v", manager.Manifest().Tiers)
+	}
+	rr = adminRequest(t, admin.HandleLogo, http.MethodPut, AdminLogosPath+"/acme.png?tier=gold", testJPEG(t, 100, 100))
+	gold, _ := manager.Manifest().Tier("gold")
+	silver, _ = manager.Manifest().Tier("silver")
+	if rr.Code != http.StatusOK || !slices.Equal(gold.Images(), []string{"aws.png", "acme.png"}) || len(silver.Sponsors) != 0 {
+		t.Errorf("❌ Move to gold = %d, tiers %+v", rr.Code, manager.Manifest().Tiers)
+	}
+	if gold.Sponsors[1].Name != "Acme" {
+		t.Errorf("❌ Moved logo lost its name: %+v", gold.Sponsors[1])
+	}
+
+	for _, tt := range []struct {
+		name string
+		path string
+		body []byte
+		code int
+	}{
+		{name: "JPEG name", path: "/acme.jpg", body: testJPEG(t, 10, 10), code: http.StatusBadRequest},
+		{name: "Hidden name", path: "/.acme.png", body: testJPEG(t, 10, 10), code: http.StatusBadRequest},
+		{name: "Not an image", path: "/text.png", body: []byte("hello"), code: http.StatusUnprocessableEntity},
+		{name: "Huge image", path: "/huge.png", body: testJPEG(t, 2049, 2048), code: http.StatusUnprocessableEntity},
+		{name: "Too large", path: "/big.png", body: make([]byte, maxLogoSize+1), code: http.StatusRequestEntityTooLarge},
+		{name: "Invalid tier", path: "/other.png?tier=Gold", body: testJPEG(t, 10, 10), code: http.StatusBadRequest},
+		{name: "Name without a tier", path: "/other.png?name=Other", body: testJPEG(t, 10, 10), code: http.StatusBadRequest},
+		{name: "SVG without a size", path: "/other.svg", body: []byte(`<svg xmlns="http://www.w3.org/2000/svg"/>`), code: http.StatusUnprocessableEntity},
+	} {
+		if rr := adminRequest(t, admin.HandleLogo, http.MethodPut, AdminLogosPath+tt.path, tt.body); rr.Code != tt.code {
+			t.Errorf("❌ %s = %d, want %d: %s", tt.name, rr.Code, tt.code, rr.Body.String())
+		}
+	}
+	if _, err := os.Stat(filepath.Join(dir, "other.png")); err == nil {
+		t.Errorf("❌ Rejected upload was stored")
+	}
+
+	rr = adminRequest(t, admin.HandleLogo, http.MethodPut, AdminLogosPath+"/vector.svg?tier=gold", []byte(`<svg xmlns="http://www.w3.org/2000/svg" viewBox="0 0 220 220" onload="alert(1)"><rect width="220" height="220"/></svg>`))
+	if rr.Code != http.StatusCreated {
+		t.Fatalf("❌ SVG upload = %d, want 201: %s", rr.Code, rr.Body.String())
+	}
+	if data, _ := os.ReadFile(filepath.Join(dir, "vector.svg")); strings.Contains(string(data), "alert") {
+		t.Errorf("❌ Stored SVG was not sanitized: %s", data)
+	}
+
+	rr = adminRequest(t, admin.HandleLogo, http.MethodDelete, AdminLogosPath+"/acme.png", nil)
+	if rr.Code != http.StatusNoContent {
+		t.Fatalf("❌ Delete = %d, want 204: %s", rr.Code, rr.Body.String())
+	}
+	if _, err := os.Stat(filepath.Join(dir, "acme.png")); err == nil {
+		t.Errorf("❌ Deleted logo is still on disk")
+	}
+	saved, _ = LoadManifest(manifestPath)
+	if gold, _ := saved.Tier("gold"); !slices.Equal(gold.Images(), []string{"aws.png", "vector.svg"}) {
+		t.Errorf("❌ Saved gold tier = %v, want [aws.png vector.svg]", gold.Images())
+	}
+	if rr := adminRequest(t, admin.HandleLogo, http.MethodDelete, AdminLogosPath+"/acme.png", nil); rr.Code != http.StatusNotFound {
+		t.Errorf("❌ Deleting a deleted logo = %d, want 404", rr.Code)
+	} else {
+		t.Logf("✅ Logos uploaded, normalized, placed and deleted")
+	}
+}
+
+func TestAdminTiers(t *testing.T) {
+	dir := t.TempDir()
+	manifestPath := filepath.Join(t.TempDir(), "sponsors.json")
+	if err := os.WriteFile(manifestPath, []byte(`{"tiers":[{"name":"gold","sponsors":["aws.png"]}]}`), 0600); err != nil {
+		t.Fatalf("❌ Failed to write manifest: %v", err)
+	}
+	writeTestImage(t, filepath.Join(dir, "acme.png"), imageSize)
+	if err := os.WriteFile(filepath.Join(dir, "broken.png"), []byte("not a png"), 0600); err != nil {
+		t.Fatalf("❌ Failed to write fixture: %v", err)
+	}
+
+	manager, err := NewManager(dir)
+	if err != nil {
+		t.Fatalf("❌ Failed to create sponsor manager: %v", err)
+	}
+	manifest, _ := LoadManifest(manifestPath)
+	manager.SetManifest(manifest)
+	admin := NewAdmin(manager, dir, manifestPath, testAdminToken)
+
+	rr := adminRequest(t, admin.HandleTier, http.MethodPut, AdminTiersPath+"/silver", []byte(`{"weight":2,"sponsors":["acme.png",{"image":"aws.png","name":"AWS"}]}`))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("❌ Put tier = %d, want 200: %s", rr.Code, rr.Body.String())
+	}
+	saved, err := LoadManifest(manifestPath)
+	if err != nil {
+		t.Fatalf("❌ Saved manifest doesn't load: %v", err)
+	}
+	if silver, ok := saved.Tier("silver"); !ok || silver.Weight != 2 || !slices.Equal(silver.Images(), []string{"acme.png", "aws.png"}) {
+		t.Errorf("❌ Saved tiers = %+v, want silver after gold", saved.Tiers)
+	}
+
+	for _, tt := range []struct {
+		name   string
+		method string
+		tier   string
+		body   string
+		code   int
+	}{
+		{name: "Missing image", method: http.MethodPut, tier: "silver", body: `{"sponsors":["missing.png"]}`, code: http.StatusUnprocessableEntity},
+		{name: "Broken image", method: http.MethodPut, tier: "silver", body: `{"sponsors":["broken.png"]}`, code: http.StatusUnprocessableEntity},
+		{name: "Name mismatch", method: http.MethodPut, tier: "silver", body: `{"name":"gold","sponsors":[]}`, code: http.StatusBadRequest},
+		{name: "Unknown field", method: http.MethodPut, tier: "silver", body: `{"sponsor":[]}`, code: http.StatusBadRequest},
+		{name: "Reserved name", method: http.MethodPut, tier: "version", body: `{"sponsors":[]}`, code: http.StatusBadRequest},
+		{name: "Unknown tier", method: http.MethodDelete, tier: "bronze", code: http.StatusNotFound},
+	} {
+		if rr := adminRequest(t, admin.HandleTier, tt.method, AdminTiersPath+"/"+tt.tier, []byte(tt.body)); rr.Code != tt.code {
+			t.Errorf("❌ %s = %d, want %d: %s", tt.name, rr.Code, tt.code, rr.Body.String())
+		}
+	}
+
+	if rr := adminRequest(t, admin.HandleTier, http.MethodDelete, AdminTiersPath+"/gold", nil); rr.Code != http.StatusNoContent {
+		t.Fatalf("❌ Delete tier = %d, want 204: %s", rr.Code, rr.Body.String())
+	}
+	if got := manager.Manifest().Names(); !slices.Equal(got, []string{"silver"}) {
+		t.Errorf("❌ Served tiers = %v, want [silver]", got)
+	}
+	if rr := adminRequest(t, admin.HandleTier, http.MethodDelete, AdminTiersPath+"/silver", nil); rr.Code != http.StatusConflict {
+		t.Errorf("❌ Deleting the last tier = %d, want 409", rr.Code)
+	} else {
+		t.Logf("✅ Tiers put and deleted")
+	}
+}
+
+func TestNormalizeLogo(t *testing.T) {
+	var buf bytes.Buffer
+	png.Encode(&buf, image.NewRGBA(image.Rect(0, 0, 600, 300)))
+	data, err := normalizeLogo("wide.png", buf.Bytes())
+	if err != nil {
+		t.Fatalf("❌ normalizeLogo() unexpected error: %v", err)
+	}
+	img, err := png.Decode(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("❌ Normalized logo isn't a PNG: %v", err)
+	}
+	if b := img.Bounds(); b.Dx() != imageSize || b.Dy() != imageSize {
+		t.Errorf("❌ Normalized logo is %dx%d, want 220x220", b.Dx(), b.Dy())
+	}
+	// Padding above and below the wide logo is white
+	if r, g, b, _ := img.At(imageSize/2, 2).RGBA(); r != 0xffff || g != 0xffff || b != 0xffff {
+		t.Errorf("❌ Padding is not white")
+	} else {
+		t.Logf("✅ Wide logo padded to 220x220")
+	}
+}
+
+func TestAdminLogoRestoredOnManifestFailure(t *testing.T) {
+	dir := t.TempDir()
+	original := testJPEG(t, 220, 220)
+	if err := os.WriteFile(filepath.Join(dir, "acme.png"), original, 0600); err != nil {
+		t.Fatalf("❌ Failed to write logo: %v", err)
+	}
+	// A directory with a file in it can't be replaced by the manifest
+	manifestPath := filepath.Join(t.TempDir(), "sponsors.json")
+	if err := os.MkdirAll(filepath.Join(manifestPath, "busy"), 0700); err != nil {
+		t.Fatalf("❌ Failed to create directory: %v", err)
+	}
+
+	manager, err := NewManager(dir)
+	if err != nil {
+		t.Fatalf("❌ Failed to create sponsor manager: %v", err)
+	}
+	admin := NewAdmin(manager, dir, manifestPath, testAdminToken)
+
+	tests := []struct {
+		name  string
+		image string
+		want  []byte
+	}{
+		{"replaced logo", "acme.png", original},
+		{"created logo", "newco.png", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rr := adminRequest(t, admin.HandleLogo, http.MethodPut, AdminLogosPath+"/"+tt.image+"?tier=gold", testJPEG(t, 400, 200))
+			if rr.Code != http.StatusInternalServerError {
+				t.Fatalf("❌ Upload = %d, want 500: %s", rr.Code, rr.Body.String())
+			}
+			got, err := os.ReadFile(filepath.Join(dir, tt.image))
+			if tt.want == nil {
+				if !errors.Is(err, os.ErrNotExist) {
+					t.Fatalf("❌ Created logo left behind: %v", err)
+				}
+			} else if !bytes.Equal(got, tt.want) {
+				t.Fatalf("❌ Previous logo not restored: %v", err)
+			}
+			if _, err := fs.Stat(manager.images, tt.image); (err == nil) != (tt.want != nil) {
+				t.Fatalf("❌ Manager sees %s after a failed upload: %v", tt.image, err)
+			}
+			t.Logf("✅ %s put back when the manifest can't be saved", tt.name)
+		})
+	}
+}
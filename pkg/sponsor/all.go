@@ -4,8 +4,6 @@ import (
 	"encoding/json"
 	"io/fs"
 	"net/http"
-	"path"
-	"strings"
 )
 
 // HandleAllSponsors returns a list of all sponsor image files
@@ -24,8 +22,7 @@ func (m *Manager) HandleAllSponsors(w http.ResponseWriter, r *http.Request) {
 		}
 
 		// Check file extension to make sure it's an image
-		ext := strings.ToLower(path.Ext(filePath))
-		if ext == ".jpg" || ext == ".jpeg" || ext == ".png" || ext == ".gif" {
+		if isImage(filePath) {
 			sponsorFiles = append(sponsorFiles, filePath)
 		}
 
@@ -33,11 +30,14 @@ func (m *Manager) HandleAllSponsors(w http.ResponseWriter, r *http.Request) {
 	})
 
 	if err != nil {
+		logger().Error("unable to read sponsor files", "err", err)
 		http.Error(w, "Error reading sponsor files: "+err.Error(), http.StatusInternalServerError)
 		return
 	}
 
 	// Set content type and return JSON
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(sponsorFiles)
+	if err := json.NewEncoder(w).Encode(sponsorFiles); err != nil {
+		logger().Error("unable to encode sponsors", "tier", "all", "err", err)
+	}
 }
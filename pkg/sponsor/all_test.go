@@ -12,7 +12,7 @@ import (
 
 func TestHandleAllSponsors(t *testing.T) {
 	// Create a new manager
-	manager, err := NewManager()
+	manager, err := NewManager("")
 	if err != nil {
 		t.Fatalf("❌ Failed to create sponsor manager: %v", err)
 	}
@@ -61,7 +61,7 @@ func TestHandleAllSponsors(t *testing.T) {
 
 		// Count only image files
 		ext := strings.ToLower(path.Ext(filePath))
-		if ext == ".jpg" || ext == ".jpeg" || ext == ".png" || ext == ".gif" {
+		if ext == ".jpg" || ext == ".jpeg" || ext == ".png" || ext == ".gif" || ext == ".svg" {
 			actualImageCount++
 		}
 
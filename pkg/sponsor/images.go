@@ -0,0 +1,230 @@
+package sponsor
+
+import (
+	"fmt"
+	_ "image/gif"  // Register GIF format
+	_ "image/jpeg" // Register JPEG format
+	_ "image/png"  // Register PNG format
+	"io/fs"
+	"os"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// imagesCheckInterval limits how often the images directory is scanned
+const imagesCheckInterval = 5 * time.Second
+
+// imageSize is the width and height the display lays sponsor logos out for
+const imageSize = 220
+
+// isImage reports whether the file name has a supported image extension
+func isImage(name string) bool {
+	switch strings.ToLower(path.Ext(name)) {
+	case ".jpg", ".jpeg", ".png", ".gif", ".svg":
+		return true
+	}
+	return false
+}
+
+// diskImage is the state of a file in the images directory when last checked
+type diskImage struct {
+	modTime time.Time
+	size    int64
+	valid   bool
+	err     error // Why the image is not valid
+}
+
+// imageDir layers the valid images of a directory over the embedded images.
+// The directory is scanned for changes at most every imagesCheckInterval when
+// the images are read.
+type imageDir struct {
+	embedded fs.FS
+	dir      string
+	disk     fs.FS
+
+	scanning  sync.Mutex // Held while scanning, so a slow scan can't undo a newer one
+	mutex     sync.Mutex
+	files     map[string]diskImage
+	lastCheck time.Time
+}
+
+// newImageDir serves the images in dir over embedded, failing if dir cannot be
+// read
+func newImageDir(embedded fs.FS, dir string) (*imageDir, error) {
+	d := &imageDir{
+		embedded: embedded,
+		dir:      dir,
+		disk:     os.DirFS(dir),
+		files:    make(map[string]diskImage),
+	}
+	if err := d.scan(); err != nil {
+		return nil, err
+	}
+	d.lastCheck = time.Now()
+	return d, nil
+}
+
+// refresh rescans the directory if it was last checked long enough ago
+func (d *imageDir) refresh() {
+	d.mutex.Lock()
+	if time.Since(d.lastCheck) < imagesCheckInterval {
+		d.mutex.Unlock()
+		return
+	}
+	d.lastCheck = time.Now()
+	d.mutex.Unlock()
+
+	if err := d.scan(); err != nil {
+		logger().Error("unable to read sponsor images, keeping the last good set", "dir", d.dir, "err", err)
+	}
+}
+
+// scan validates new and changed images in the directory and forgets removed
+// ones
+func (d *imageDir) scan() error {
+	d.scanning.Lock()
+	defer d.scanning.Unlock()
+
+	entries, err := os.ReadDir(d.dir)
+	if err != nil {
+		return err
+	}
+
+	d.mutex.Lock()
+	old := d.files
+	d.mutex.Unlock()
+
+	files := make(map[string]diskImage)
+	for _, e := range entries {
+		name := e.Name()
+		if e.IsDir() || strings.HasPrefix(name, ".") || !isImage(name) {
+			continue
+		}
+		info, err := e.Info()
+		if err != nil {
+			continue
+		}
+
+		f := diskImage{modTime: info.ModTime(), size: info.Size()}
+		if prev, ok := old[name]; ok && prev.modTime.Equal(f.modTime) && prev.size == f.size {
+			files[name] = prev
+			continue
+		}
+
+		if err := validateImage(d.disk, name); err != nil {
+			f.err = err
+			logger().Warn("ignoring invalid sponsor image", "image", name, "err", err)
+		} else {
+			f.valid = true
+			logger().Info("sponsor image loaded", "image", name, "dir", d.dir)
+		}
+		files[name] = f
+	}
+
+	for name, f := range old {
+		if _, ok := files[name]; !ok && f.valid {
+			logger().Info("sponsor image removed", "image", name, "dir", d.dir)
+		}
+	}
+
+	d.mutex.Lock()
+	d.files = files
+	d.mutex.Unlock()
+	return nil
+}
+
+// validateImage checks that name decodes as an image, or parses as an SVG,
+// warning when it is not the size the display expects
+func validateImage(fsys fs.FS, name string) error {
+	f, err := fsys.Open(name)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	config, format, err := decodeImageConfig(f, name)
+	if err != nil {
+		return fmt.Errorf("unable to decode image: %w", err)
+	}
+
+	// SVGs scale to the display, so only need a size to scale from
+	if format == "svg" {
+		if config.Width == 0 || config.Height == 0 {
+			logger().Warn("sponsor SVG has no width, height or viewBox", "image", name)
+		}
+		return nil
+	}
+	if config.Width != imageSize || config.Height != imageSize {
+		logger().Warn("sponsor image is not the expected size", "image", name, "format", format,
+			"width", config.Width, "height", config.Height, "want", imageSize)
+	}
+	return nil
+}
+
+// onDisk reports whether name is a valid image in the directory
+func (d *imageDir) onDisk(name string) bool {
+	d.refresh()
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	return d.files[name].valid
+}
+
+// rejected returns why name in the directory is not served, nil when it is
+// valid or not there
+func (d *imageDir) rejected(name string) error {
+	d.refresh()
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	return d.files[name].err
+}
+
+// Open implements fs.FS, preferring the directory over the embedded images
+func (d *imageDir) Open(name string) (fs.File, error) {
+	if d.onDisk(name) {
+		return d.disk.Open(name)
+	}
+	return d.embedded.Open(name)
+}
+
+// ReadDir implements fs.ReadDirFS, merging the valid images of the directory
+// into the embedded ones
+func (d *imageDir) ReadDir(name string) ([]fs.DirEntry, error) {
+	entries, err := fs.ReadDir(d.embedded, name)
+	if err != nil || name != "." {
+		return entries, err
+	}
+
+	d.refresh()
+	d.mutex.Lock()
+	var names []string
+	for n, f := range d.files {
+		if f.valid {
+			names = append(names, n)
+		}
+	}
+	d.mutex.Unlock()
+
+	merged := make(map[string]fs.DirEntry, len(entries)+len(names))
+	for _, e := range entries {
+		merged[e.Name()] = e
+	}
+	for _, n := range names {
+		info, err := fs.Stat(d.disk, n)
+		if err != nil {
+			continue
+		}
+		merged[n] = fs.FileInfoToDirEntry(info)
+	}
+
+	entries = make([]fs.DirEntry, 0, len(merged))
+	for _, e := range merged {
+		entries = append(entries, e)
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].Name() < entries[j].Name()
+	})
+	return entries, nil
+}
@@ -0,0 +1,132 @@
+package sponsor
+
+import (
+	"encoding/json"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+)
+
+// writeTestImage writes a solid size x size PNG to path
+func writeTestImage(t *testing.T, path string, size int) {
+	t.Helper()
+	img := image.NewRGBA(image.Rect(0, 0, size, size))
+	for x := range size {
+		for y := range size {
+			img.Set(x, y, color.RGBA{R: 0x20, G: 0x54, B: 0x93, A: 0xff})
+		}
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatalf("❌ Failed to create image: %v", err)
+	}
+	defer f.Close()
+	if err := png.Encode(f, img); err != nil {
+		t.Fatalf("❌ Failed to encode image: %v", err)
+	}
+}
+
+// allSponsors returns the images listed at /sponsors/all
+func allSponsors(t *testing.T, m *Manager) []string {
+	t.Helper()
+	rr := httptest.NewRecorder()
+	m.HandleAllSponsors(rr, httptest.NewRequest(http.MethodGet, "/sponsors/all", nil))
+	var images []string
+	if err := json.NewDecoder(rr.Body).Decode(&images); err != nil {
+		t.Fatalf("❌ Failed to decode /sponsors/all: %v", err)
+	}
+	return images
+}
+
+func TestImagesDirectory(t *testing.T) {
+	dir := t.TempDir()
+	writeTestImage(t, filepath.Join(dir, "latesponsor.png"), 220)
+	writeTestImage(t, filepath.Join(dir, "aws.png"), 220)
+	if err := os.WriteFile(filepath.Join(dir, "broken.png"), []byte("not an image"), 0600); err != nil {
+		t.Fatalf("❌ Failed to write fixture: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "notes.txt"), []byte("not a sponsor"), 0600); err != nil {
+		t.Fatalf("❌ Failed to write fixture: %v", err)
+	}
+
+	if _, err := NewManager(filepath.Join(dir, "missing")); err == nil {
+		t.Errorf("❌ NewManager() with a missing directory expected error, got nil")
+	}
+
+	manager, err := NewManager(dir)
+	if err != nil {
+		t.Fatalf("❌ NewManager() unexpected error: %v", err)
+	}
+	embedded, _ := NewManager("")
+	embeddedCount := len(allSponsors(t, embedded))
+
+	images := allSponsors(t, manager)
+	if !slices.Contains(images, "latesponsor.png") {
+		t.Errorf("❌ /sponsors/all is missing the image from disk")
+	}
+	if slices.Contains(images, "broken.png") || slices.Contains(images, "notes.txt") {
+		t.Errorf("❌ /sponsors/all lists an invalid file: %v", images)
+	}
+	if len(images) != embeddedCount+1 {
+		t.Errorf("❌ /sponsors/all has %d images, want the %d embedded plus one", len(images), embeddedCount)
+	} else {
+		t.Logf("✅ %d images with one from disk", len(images))
+	}
+
+	server := httptest.NewServer(http.StripPrefix("/sponsors/images/", manager.ImageHandler()))
+	defer server.Close()
+	get := func(name string) (int, []byte) {
+		resp, err := http.Get(server.URL + "/sponsors/images/" + name)
+		if err != nil {
+			t.Fatalf("❌ image request failed: %v", err)
+		}
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(resp.Body)
+		return resp.StatusCode, body
+	}
+
+	// The disk copy replaces the embedded one
+	onDisk, _ := os.ReadFile(filepath.Join(dir, "aws.png"))
+	if code, body := get("aws.png"); code != http.StatusOK || string(body) != string(onDisk) {
+		t.Errorf("❌ aws.png = %d, %d bytes, want the %d byte copy from disk", code, len(body), len(onDisk))
+	}
+	if code, _ := get("broken.png"); code != http.StatusNotFound {
+		t.Errorf("❌ broken.png = %d, want 404", code)
+	}
+	if code, _ := get("microsoft.png"); code != http.StatusOK {
+		t.Errorf("❌ embedded microsoft.png = %d, want 200", code)
+	}
+
+	t.Run("Changes", func(t *testing.T) {
+		writeTestImage(t, filepath.Join(dir, "latersponsor.png"), 220)
+		if err := os.Remove(filepath.Join(dir, "latesponsor.png")); err != nil {
+			t.Fatalf("❌ Failed to remove fixture: %v", err)
+		}
+		// Fixing a broken image changes its size
+		writeTestImage(t, filepath.Join(dir, "broken.png"), 220)
+
+		// Skip the wait between scans
+		d := manager.images.(*imageDir)
+		d.mutex.Lock()
+		d.lastCheck = time.Time{}
+		d.mutex.Unlock()
+
+		images := allSponsors(t, manager)
+		if !slices.Contains(images, "latersponsor.png") || !slices.Contains(images, "broken.png") {
+			t.Errorf("❌ /sponsors/all is missing new images: %v", images)
+		}
+		if slices.Contains(images, "latesponsor.png") {
+			t.Errorf("❌ /sponsors/all still lists a removed image")
+		} else {
+			t.Logf("✅ directory changes picked up without a restart")
+		}
+	})
+}
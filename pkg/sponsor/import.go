@@ -0,0 +1,296 @@
+package sponsor
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"image"
+	"image/png"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultImportBaseURL is the SCaLE site serving the sponsor feed and logos
+const DefaultImportBaseURL = "https://www.socallinuxexpo.org"
+
+// importTier holds imported sponsors whose feed entry has no level
+const importTier = "sponsors"
+
+// Limits on what an import downloads
+const (
+	maxFeedSize = 5 << 20
+	maxLogoSize = 10 << 20
+)
+
+// feedSponsor is one entry of the SCaLE sponsor feed. Only the sponsor name is
+// always present.
+type feedSponsor struct {
+	Sponsor string `json:"sponsor"`
+	LogoURL string `json:"logo_url"` // Relative to the site, null or missing when there is no logo
+	Level   string `json:"level"`    // Sponsorship level, such as Gold
+	URL     string `json:"url"`      // Sponsor's website
+}
+
+// Importer builds sponsor tiers and logos from the SCaLE sponsor feed
+type Importer struct {
+	BaseURL string       // Site serving /rest/sponsor/<event>/json, DefaultImportBaseURL when empty
+	Client  *http.Client // Client for the feed and logos, one with a timeout when nil
+}
+
+// Skipped is a sponsor left out of an import and why
+type Skipped struct {
+	Sponsor string
+	Reason  string
+}
+
+// ImportResult is the manifest and normalized logos built by an import
+type ImportResult struct {
+	Manifest *Manifest
+	Images   map[string][]byte // PNG and SVG logos by file name
+	Skipped  []Skipped
+}
+
+// Import fetches the sponsor feed for eventID, such as 23x, and downloads and
+// normalizes each logo to a 220x220 PNG on white, or sanitizes it if it is an
+// SVG. Sponsors without a usable logo are skipped; an import with no sponsors
+// left fails.
+func (im *Importer) Import(eventID string) (*ImportResult, error) {
+	if eventID == "" || strings.ContainsAny(eventID, "/?#") {
+		return nil, fmt.Errorf("invalid event ID %q", eventID)
+	}
+
+	baseURL := im.BaseURL
+	if baseURL == "" {
+		baseURL = DefaultImportBaseURL
+	}
+	base, err := url.Parse(baseURL)
+	if err != nil || (base.Scheme != "http" && base.Scheme != "https") || base.Host == "" {
+		return nil, fmt.Errorf("base URL must be an http or https URL, got %q", baseURL)
+	}
+
+	client := im.Client
+	if client == nil {
+		client = &http.Client{Timeout: 30 * time.Second}
+	}
+
+	feedURL := base.JoinPath("rest", "sponsor", eventID, "json")
+	data, err := fetch(client, feedURL.String(), maxFeedSize)
+	if err != nil {
+		return nil, fmt.Errorf("unable to fetch sponsor feed: %w", err)
+	}
+
+	var entries []feedSponsor
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return nil, fmt.Errorf("invalid sponsor feed %s: %w", feedURL, err)
+	}
+
+	result := &ImportResult{
+		Manifest: &Manifest{},
+		Images:   make(map[string][]byte),
+	}
+	tiers := make(map[string]int)
+	for _, e := range entries {
+		name := strings.TrimSpace(e.Sponsor)
+		if name == "" {
+			result.Skipped = append(result.Skipped, Skipped{Sponsor: "(unnamed)", Reason: "no sponsor name"})
+			continue
+		}
+		if strings.TrimSpace(e.LogoURL) == "" {
+			result.Skipped = append(result.Skipped, Skipped{Sponsor: name, Reason: "no logo_url"})
+			continue
+		}
+
+		logoURL, err := base.Parse(strings.TrimSpace(e.LogoURL))
+		if err != nil {
+			result.Skipped = append(result.Skipped, Skipped{Sponsor: name, Reason: fmt.Sprintf("invalid logo_url %q", e.LogoURL)})
+			continue
+		}
+
+		logo, ext, err := fetchLogo(client, logoURL.String())
+		if err != nil {
+			result.Skipped = append(result.Skipped, Skipped{Sponsor: name, Reason: err.Error()})
+			continue
+		}
+
+		file := uniqueFileName(imageFileName(name, ext), result.Images)
+		result.Images[file] = logo
+		logger().Info("sponsor logo imported", "sponsor", name, "image", file)
+
+		s := Sponsor{Image: file, Name: name}
+		if u, err := url.Parse(e.URL); err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != "" {
+			s.Website = e.URL
+		}
+
+		tier := tierName(e.Level)
+		i, ok := tiers[tier]
+		if !ok {
+			i = len(result.Manifest.Tiers)
+			tiers[tier] = i
+			result.Manifest.Tiers = append(result.Manifest.Tiers, Tier{Name: tier})
+		}
+		result.Manifest.Tiers[i].Sponsors = append(result.Manifest.Tiers[i].Sponsors, s)
+	}
+
+	if len(result.Images) == 0 {
+		return nil, fmt.Errorf("no sponsor logos imported from %s, %d skipped", feedURL, len(result.Skipped))
+	}
+	if err := result.Manifest.Validate(); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// fetch returns the body of a GET, failing on a non-200 status or a body over
+// limit bytes
+func fetch(client *http.Client, rawURL string, limit int64) ([]byte, error) {
+	resp, err := client.Get(rawURL)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s returned %s", rawURL, resp.Status)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, limit+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(data)) > limit {
+		return nil, fmt.Errorf("%s is larger than %d bytes", rawURL, limit)
+	}
+	return data, nil
+}
+
+// fetchLogo downloads a logo and returns it normalized, along with the
+// extension to save it under. SVGs are kept as vectors and sanitized, and
+// anything else is decoded and padded to a PNG.
+func fetchLogo(client *http.Client, rawURL string) ([]byte, string, error) {
+	data, err := fetch(client, rawURL, maxLogoSize)
+	if err != nil {
+		return nil, "", fmt.Errorf("download failed: %w", err)
+	}
+
+	u, err := url.Parse(rawURL)
+	if err == nil && isSVG(u.Path) || bytes.Contains(data[:min(len(data), 1024)], []byte("<svg")) {
+		svg, _, err := sanitizeSVG(data)
+		if err != nil {
+			return nil, "", fmt.Errorf("unable to parse %s: %w", rawURL, err)
+		}
+		return svg, ".svg", nil
+	}
+
+	// Check the size first, as a small file can claim huge dimensions
+	config, format, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, "", fmt.Errorf("unable to decode %s: %w", rawURL, err)
+	}
+	if err := checkLogoPixels(config); err != nil {
+		return nil, "", fmt.Errorf("%s is a %s of %w", rawURL, format, err)
+	}
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, "", fmt.Errorf("unable to decode %s: %w", rawURL, err)
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, padLogo(img)); err != nil {
+		return nil, "", err
+	}
+	return buf.Bytes(), ".png", nil
+}
+
+// imageFileName returns the sponsor name lowercased and reduced to letters and
+// digits, as the images have always been named, with the extension ext
+func imageFileName(sponsor, ext string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(sponsor) {
+		if r >= 'a' && r <= 'z' || r >= '0' && r <= '9' {
+			b.WriteRune(r)
+		}
+	}
+	if b.Len() == 0 {
+		b.WriteString("sponsor")
+	}
+	return b.String() + ext
+}
+
+// uniqueFileName numbers name when it is already taken in images
+func uniqueFileName(name string, images map[string][]byte) string {
+	if _, ok := images[name]; !ok {
+		return name
+	}
+	ext := path.Ext(name)
+	stem := strings.TrimSuffix(name, ext)
+	for i := 2; ; i++ {
+		n := stem + "-" + strconv.Itoa(i) + ext
+		if _, ok := images[n]; !ok {
+			return n
+		}
+	}
+}
+
+// tierName turns a sponsorship level such as "Gold Sponsor" into a tier name,
+// importTier when there is none
+func tierName(level string) string {
+	var b strings.Builder
+	dash := false
+	for _, r := range strings.ToLower(strings.TrimSpace(level)) {
+		switch {
+		case r >= 'a' && r <= 'z' || r >= '0' && r <= '9':
+			if dash && b.Len() > 0 {
+				b.WriteByte('-')
+			}
+			dash = false
+			b.WriteRune(r)
+		default:
+			dash = true
+		}
+	}
+
+	name := strings.TrimSuffix(b.String(), "-sponsor")
+	if name == "" || validateTierName(name) != nil {
+		return importTier
+	}
+	return name
+}
+
+// Write replaces the images in imagesDir with the imported logos and writes
+// the manifest to manifestPath. Other files in imagesDir are left alone.
+func (r *ImportResult) Write(imagesDir, manifestPath string) error {
+	if err := os.MkdirAll(imagesDir, 0755); err != nil {
+		return err
+	}
+
+	entries, err := os.ReadDir(imagesDir)
+	if err != nil {
+		return err
+	}
+	for _, e := range entries {
+		if _, ok := r.Images[e.Name()]; ok || e.IsDir() || !isImage(e.Name()) {
+			continue
+		}
+		if err := os.Remove(filepath.Join(imagesDir, e.Name())); err != nil {
+			return err
+		}
+	}
+
+	for name, data := range r.Images {
+		if err := os.WriteFile(filepath.Join(imagesDir, name), data, 0644); err != nil {
+			return err
+		}
+	}
+
+	data, err := json.MarshalIndent(r.Manifest, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(manifestPath, append(data, '\n'), 0644)
+}
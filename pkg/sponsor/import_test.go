@@ -0,0 +1,236 @@
+package sponsor
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+// encodeTestLogo returns a solid w x h logo encoded with encode
+func encodeTestLogo(t *testing.T, w, h int, encode func(*bytes.Buffer, image.Image) error) []byte {
+	t.Helper()
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	for x := range w {
+		for y := range h {
+			img.Set(x, y, color.RGBA{R: 0x20, G: 0x54, B: 0x93, A: 0xff})
+		}
+	}
+	var buf bytes.Buffer
+	if err := encode(&buf, img); err != nil {
+		t.Fatalf("❌ Failed to encode logo: %v", err)
+	}
+	return buf.Bytes()
+}
+
+func TestImport(t *testing.T) {
+	wide := encodeTestLogo(t, 400, 100, func(b *bytes.Buffer, img image.Image) error { return png.Encode(b, img) })
+	small := encodeTestLogo(t, 50, 50, func(b *bytes.Buffer, img image.Image) error { return jpeg.Encode(b, img, nil) })
+	var huge bytes.Buffer
+	if err := png.Encode(&huge, image.NewGray(image.Rect(0, 0, 2049, 2048))); err != nil {
+		t.Fatalf("❌ Failed to encode logo: %v", err)
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/rest/sponsor/23x/json", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`[
+			{"sponsor": "Acme Corp.", "logo_url": "/files/acme.PNG", "level": "Diamond Sponsor", "url": "https://acme.example"},
+			{"sponsor": "Tiny", "logo_url": "/files/tiny.jpg", "level": "Gold"},
+			{"sponsor": "Acme-Corp", "logo_url": "/files/acme.PNG", "level": "Gold", "url": "not a url"},
+			{"sponsor": "No Logo", "logo_url": null},
+			{"sponsor": "Empty Logo", "logo_url": ""},
+			{"sponsor": "Missing Logo"},
+			{"sponsor": "Gone", "logo_url": "/files/gone.png"},
+			{"sponsor": "Garbled", "logo_url": "/files/garbled.png"},
+			{"sponsor": "Huge", "logo_url": "/files/huge.png", "level": "Gold"},
+			{"sponsor": "Unleveled", "logo_url": "/files/tiny.jpg"},
+			{"sponsor": "Vector", "logo_url": "/files/vector.svg", "level": "Gold"}
+		]`))
+	})
+	mux.HandleFunc("/files/acme.PNG", func(w http.ResponseWriter, r *http.Request) { w.Write(wide) })
+	mux.HandleFunc("/files/tiny.jpg", func(w http.ResponseWriter, r *http.Request) { w.Write(small) })
+	mux.HandleFunc("/files/vector.svg", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`<svg xmlns="http://www.w3.org/2000/svg" viewBox="0 0 100 100"><script>alert(1)</script><circle r="50"/></svg>`))
+	})
+	mux.HandleFunc("/files/huge.png", func(w http.ResponseWriter, r *http.Request) { w.Write(huge.Bytes()) })
+	mux.HandleFunc("/files/garbled.png", func(w http.ResponseWriter, r *http.Request) { w.Write([]byte("not an image")) })
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	importer := &Importer{BaseURL: server.URL}
+	if _, err := importer.Import("24x"); err == nil {
+		t.Errorf("❌ Import() of a missing event expected error, got nil")
+	}
+	if _, err := importer.Import("../23x"); err == nil {
+		t.Errorf("❌ Import() with a path in the event ID expected error, got nil")
+	}
+
+	result, err := importer.Import("23x")
+	if err != nil {
+		t.Fatalf("❌ Import() unexpected error: %v", err)
+	}
+
+	wantTiers := map[string][]Sponsor{
+		"diamond":  {{Image: "acmecorp.png", Name: "Acme Corp.", Website: "https://acme.example"}},
+		"gold":     {{Image: "tiny.png", Name: "Tiny"}, {Image: "acmecorp-2.png", Name: "Acme-Corp"}, {Image: "vector.svg", Name: "Vector"}},
+		"sponsors": {{Image: "unleveled.png", Name: "Unleveled"}},
+	}
+	if got := result.Manifest.Names(); len(got) != 3 || got[0] != "diamond" || got[1] != "gold" || got[2] != "sponsors" {
+		t.Errorf("❌ Tiers = %v, want [diamond gold sponsors] in feed order", got)
+	}
+	for name, want := range wantTiers {
+		tier, _ := result.Manifest.Tier(name)
+		if len(tier.Sponsors) != len(want) {
+			t.Errorf("❌ Tier %s has %v, want %v", name, tier.Sponsors, want)
+			continue
+		}
+		for i := range want {
+			if !reflect.DeepEqual(tier.Sponsors[i], want[i]) {
+				t.Errorf("❌ Tier %s sponsor %d = %+v, want %+v", name, i, tier.Sponsors[i], want[i])
+			}
+		}
+	}
+
+	skipped := make(map[string]string)
+	for _, s := range result.Skipped {
+		skipped[s.Sponsor] = s.Reason
+	}
+	for _, name := range []string{"No Logo", "Empty Logo", "Missing Logo"} {
+		if skipped[name] != "no logo_url" {
+			t.Errorf("❌ %s skipped for %q, want no logo_url", name, skipped[name])
+		}
+	}
+	if !strings.Contains(skipped["Huge"], "more than") {
+		t.Errorf("❌ Huge skipped for %q, want more than", skipped["Huge"])
+	}
+	if skipped["Gone"] == "" || skipped["Garbled"] == "" || len(skipped) != 6 {
+		t.Errorf("❌ Skipped = %v, want the three without a logo, Gone, Garbled and Huge", skipped)
+	} else {
+		t.Logf("✅ Skipped %d sponsors", len(skipped))
+	}
+
+	// SVGs are kept as vectors, without their scripts
+	if svg := string(result.Images["vector.svg"]); !strings.Contains(svg, "<circle") || strings.Contains(svg, "script") {
+		t.Errorf("❌ vector.svg = %q, want the sanitized SVG", svg)
+	}
+	for name, data := range result.Images {
+		if isSVG(name) {
+			continue
+		}
+		img, err := png.Decode(bytes.NewReader(data))
+		if err != nil {
+			t.Fatalf("❌ %s is not a PNG: %v", name, err)
+		}
+		if b := img.Bounds(); b.Dx() != imageSize || b.Dy() != imageSize {
+			t.Errorf("❌ %s is %dx%d, want %dx%d", name, b.Dx(), b.Dy(), imageSize, imageSize)
+		}
+	}
+
+	// The 4:1 logo is scaled to 200x50 and centred, leaving white around it
+	img, _ := png.Decode(bytes.NewReader(result.Images["acmecorp.png"]))
+	for _, p := range []struct {
+		x, y  int
+		white bool
+	}{{5, 110, true}, {110, 5, true}, {110, 80, true}, {110, 110, false}, {15, 110, false}, {204, 110, false}} {
+		r, g, b, _ := img.At(p.x, p.y).RGBA()
+		isWhite := r == 0xffff && g == 0xffff && b == 0xffff
+		if isWhite != p.white {
+			t.Errorf("❌ Pixel (%d,%d) white = %v, want %v", p.x, p.y, isWhite, p.white)
+		}
+	}
+
+	// Writing replaces old images but leaves other files alone
+	dir := t.TempDir()
+	writeTestImage(t, filepath.Join(dir, "oldsponsor.png"), 220)
+	if err := os.WriteFile(filepath.Join(dir, "README"), []byte("keep"), 0600); err != nil {
+		t.Fatalf("❌ Failed to write fixture: %v", err)
+	}
+	manifestPath := filepath.Join(t.TempDir(), "sponsors.json")
+	if err := result.Write(dir, manifestPath); err != nil {
+		t.Fatalf("❌ Write() unexpected error: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "oldsponsor.png")); !os.IsNotExist(err) {
+		t.Errorf("❌ Old image was not removed")
+	}
+	if _, err := os.Stat(filepath.Join(dir, "README")); err != nil {
+		t.Errorf("❌ Non-image file was removed: %v", err)
+	}
+
+	m, err := LoadManifest(manifestPath)
+	if err != nil {
+		t.Fatalf("❌ Written manifest does not load: %v", err)
+	}
+	manager, err := NewManager(dir)
+	if err != nil {
+		t.Fatalf("❌ NewManager() unexpected error: %v", err)
+	}
+	manager.SetManifest(m)
+	for _, tier := range m.Tiers {
+		for _, s := range tier.Sponsors {
+			if !manager.images.(*imageDir).onDisk(s.Image) {
+				t.Errorf("❌ %s is not a valid image on disk", s.Image)
+			}
+		}
+	}
+	t.Logf("✅ Wrote %d images and %d tiers", len(result.Images), len(m.Tiers))
+}
+
+func TestImportNoLogos(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`[{"sponsor": "No Logo", "logo_url": null}]`))
+	}))
+	defer server.Close()
+
+	importer := &Importer{BaseURL: server.URL}
+	if _, err := importer.Import("23x"); err == nil {
+		t.Errorf("❌ Import() without any logos expected error, got nil")
+	} else {
+		t.Logf("✅ %v", err)
+	}
+}
+
+func TestPadLogoFilter(t *testing.T) {
+	// Imported logos are interpolated linearly, without the sharpening of
+	// logos scaled on request
+	got := filterWeights(4, 2, triangle)[1]
+	want := []contribution{{index: 0, weight: 0.75}, {index: 1, weight: 0.25}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("❌ triangle weights = %+v, want %+v", got, want)
+	}
+	for _, c := range filterWeights(4, 2, catmullRom)[1] {
+		if c.weight < 0 {
+			t.Logf("✅ only the on request filter sharpens, weight %g", c.weight)
+			return
+		}
+	}
+	t.Errorf("❌ Catmull-Rom weights have no negative lobe")
+}
+
+func TestResampleMemory(t *testing.T) {
+	img := image.NewRGBA(image.Rect(0, 0, 2048, 2048))
+	for i := range img.Pix {
+		img.Pix[i] = byte(i)
+	}
+
+	var before, after runtime.MemStats
+	runtime.GC()
+	runtime.ReadMemStats(&before)
+	out := resample(img, 200, 200, triangle)
+	runtime.ReadMemStats(&after)
+
+	// The whole source as floats would be 64 MB
+	if allocated := after.TotalAlloc - before.TotalAlloc; allocated > 2<<20 {
+		t.Errorf("❌ resample() of a 2048x2048 logo allocated %d bytes, want at most 2 MB", allocated)
+	} else {
+		t.Logf("✅ resample() to %v allocated %d bytes", out.Bounds().Size(), allocated)
+	}
+}
@@ -0,0 +1,509 @@
+package sponsor
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"path"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/kylerisse/go-signs/pkg/fleet"
+	bolt "go.etcd.io/bbolt"
+)
+
+// Paths for reporting impressions and reading them back
+const (
+	ImpressionsPath = "/sponsors/impressions"
+	ReportPath      = "/sponsors/report"
+)
+
+// Limits on what a sign may report at once
+const (
+	maxImpressionsBody    = 1 << 20
+	maxImpressionsPerPost = 5000
+	maxImpressionSeconds  = 3600
+	maxImpressionAge      = 7 * 24 * time.Hour
+)
+
+// ErrInvalidImpressions is returned for a report that was refused as a whole
+var ErrInvalidImpressions = errors.New("invalid impressions")
+
+// ImpressionRecorder takes the impressions a display reports
+type ImpressionRecorder interface {
+	Record(ImpressionReport) error
+}
+
+// impressionsBucket holds one impressionCount per hour, sign, tier and image
+var impressionsBucket = []byte("impressions")
+
+// Impression is a sponsor logo shown on a sign, as reported by the display.
+// Signs forward them to the fleet controller in the same form.
+type Impression = fleet.Impression
+
+// ImpressionReport is what the display posts to ImpressionsPath
+type ImpressionReport struct {
+	Sign        string       `json:"sign"`
+	Impressions []Impression `json:"impressions"`
+}
+
+// impressionCount is the stored total for one key
+type impressionCount struct {
+	Shown   int     `json:"shown"`
+	Seconds float64 `json:"seconds"`
+}
+
+// ImpressionRow is the total for one sponsor on one sign in one hour
+type ImpressionRow struct {
+	Hour    time.Time `json:"hour"`
+	Sign    string    `json:"sign"`
+	Tier    string    `json:"tier"` // Tier when shown, empty for images in no tier
+	Sponsor string    `json:"sponsor"`
+	Image   string    `json:"image"`
+	Shown   int       `json:"shown"`
+	Seconds float64   `json:"seconds"`
+}
+
+// ImpressionTotal is the total for one sponsor over the whole report
+type ImpressionTotal struct {
+	Sponsor string  `json:"sponsor"`
+	Tier    string  `json:"tier"`
+	Image   string  `json:"image"`
+	Signs   int     `json:"signs"`
+	Shown   int     `json:"shown"`
+	Seconds float64 `json:"seconds"`
+}
+
+// ImpressionSummary is the report served as JSON
+type ImpressionSummary struct {
+	From   *time.Time        `json:"from,omitempty"`
+	To     *time.Time        `json:"to,omitempty"`
+	Totals []ImpressionTotal `json:"totals"`
+	Rows   []ImpressionRow   `json:"rows"`
+}
+
+// ImpressionFilter narrows a report. Zero values match everything.
+type ImpressionFilter struct {
+	From time.Time // First hour included
+	To   time.Time // Hours before this are included
+	Sign string
+	Tier string
+}
+
+// Impressions counts how often and how long each sponsor was shown, by hour,
+// sign and tier, in a BoltDB file so the counts survive restarts
+type Impressions struct {
+	db      *bolt.DB
+	manager *Manager
+}
+
+// OpenImpressions opens or creates the impressions database at path. Tiers
+// and names are looked up in manager's current manifest.
+func OpenImpressions(path string, manager *Manager) (*Impressions, error) {
+	db, err := bolt.Open(path, 0600, &bolt.Options{Timeout: 1 * time.Second})
+	if err != nil {
+		return nil, fmt.Errorf("failed to open impressions database: %w", err)
+	}
+
+	err = db.Update(func(tx *bolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists(impressionsBucket)
+		return err
+	})
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to create impressions bucket: %w", err)
+	}
+
+	logger().Info("opened impressions database", "path", path)
+	return &Impressions{db: db, manager: manager}, nil
+}
+
+// Close closes the database
+func (im *Impressions) Close() error {
+	return im.db.Close()
+}
+
+// impressionKey joins the fields of a count's key, hour first so a report can
+// seek to the first hour it covers
+func impressionKey(hour time.Time, sign, tier, image string) []byte {
+	return []byte(strings.Join([]string{hour.UTC().Format(time.RFC3339), sign, tier, image}, "\x00"))
+}
+
+// splitImpressionKey is the inverse of impressionKey
+func splitImpressionKey(key []byte) (time.Time, string, string, string, bool) {
+	parts := strings.Split(string(key), "\x00")
+	if len(parts) != 4 {
+		return time.Time{}, "", "", "", false
+	}
+	hour, err := time.Parse(time.RFC3339, parts[0])
+	if err != nil {
+		return time.Time{}, "", "", "", false
+	}
+	return hour, parts[1], parts[2], parts[3], true
+}
+
+// validate checks a report before any of it is stored, filling in the time of
+// impressions without one
+func (r *ImpressionReport) validate(now time.Time) error {
+	if r.Sign == "" || len(r.Sign) > 63 {
+		return fmt.Errorf("sign must be 1 to 63 characters")
+	}
+	for _, c := range r.Sign {
+		if !(c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '-' || c == '_' || c == '.') {
+			return fmt.Errorf("sign may only contain letters, digits, '-', '_' and '.', got %q", r.Sign)
+		}
+	}
+	if len(r.Impressions) > maxImpressionsPerPost {
+		return fmt.Errorf("at most %d impressions may be reported at once, got %d", maxImpressionsPerPost, len(r.Impressions))
+	}
+
+	for i := range r.Impressions {
+		imp := &r.Impressions[i]
+		if imp.Image != path.Base(imp.Image) || !isImage(imp.Image) || strings.ContainsRune(imp.Image, 0) {
+			return fmt.Errorf("image %q must be an image file name", imp.Image)
+		}
+		if imp.Seconds <= 0 || imp.Seconds > maxImpressionSeconds {
+			return fmt.Errorf("image %s: seconds must be above 0 and at most %d, got %g", imp.Image, maxImpressionSeconds, imp.Seconds)
+		}
+		if imp.At.IsZero() {
+			imp.At = now
+		}
+		if imp.At.After(now.Add(5*time.Minute)) || imp.At.Before(now.Add(-maxImpressionAge)) {
+			return fmt.Errorf("image %s: time %s is in the future or too old", imp.Image, imp.At.Format(time.RFC3339))
+		}
+	}
+	return nil
+}
+
+// Record adds the impressions of a report to the hourly counts, filed under
+// the tier each image is in now
+func (im *Impressions) Record(r ImpressionReport) error {
+	if err := r.validate(time.Now()); err != nil {
+		return fmt.Errorf("%w: %w", ErrInvalidImpressions, err)
+	}
+
+	tiers := make(map[string]string)
+	for _, t := range im.manager.Manifest().Tiers {
+		for _, s := range t.Sponsors {
+			if _, ok := tiers[s.Image]; !ok {
+				tiers[s.Image] = t.Name
+			}
+		}
+	}
+
+	return im.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(impressionsBucket)
+		for _, imp := range r.Impressions {
+			key := impressionKey(imp.At.Truncate(time.Hour), r.Sign, tiers[imp.Image], imp.Image)
+
+			var c impressionCount
+			if v := b.Get(key); v != nil {
+				if err := json.Unmarshal(v, &c); err != nil {
+					return fmt.Errorf("corrupt impression count %q: %w", key, err)
+				}
+			}
+			c.Shown++
+			c.Seconds += imp.Seconds
+
+			v, err := json.Marshal(c)
+			if err != nil {
+				return err
+			}
+			if err := b.Put(key, v); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// Report returns the hourly counts matching f, oldest first, with the totals
+// per sponsor
+func (im *Impressions) Report(f ImpressionFilter) (ImpressionSummary, error) {
+	s := ImpressionSummary{Totals: []ImpressionTotal{}, Rows: []ImpressionRow{}}
+	if !f.From.IsZero() {
+		s.From = &f.From
+	}
+	if !f.To.IsZero() {
+		s.To = &f.To
+	}
+
+	manifest := im.manager.Manifest()
+	names := make(map[string]string)
+	for _, t := range manifest.Tiers {
+		for _, sp := range t.Sponsors {
+			names[sp.Image] = sp.DisplayName()
+		}
+	}
+
+	err := im.db.View(func(tx *bolt.Tx) error {
+		c := tx.Bucket(impressionsBucket).Cursor()
+		k, v := c.First()
+		if !f.From.IsZero() {
+			k, v = c.Seek([]byte(f.From.Truncate(time.Hour).UTC().Format(time.RFC3339)))
+		}
+
+		for ; k != nil; k, v = c.Next() {
+			hour, sign, tier, image, ok := splitImpressionKey(k)
+			if !ok {
+				continue
+			}
+			if !f.From.IsZero() && hour.Before(f.From.Truncate(time.Hour)) {
+				continue
+			}
+			if !f.To.IsZero() && !hour.Before(f.To) {
+				break
+			}
+			if (f.Sign != "" && sign != f.Sign) || (f.Tier != "" && tier != f.Tier) {
+				continue
+			}
+
+			var count impressionCount
+			if err := json.Unmarshal(v, &count); err != nil {
+				logger().Warn("skipping corrupt impression count", "key", string(k), "err", err)
+				continue
+			}
+
+			name, ok := names[image]
+			if !ok {
+				name = Sponsor{Image: image}.DisplayName()
+			}
+			s.Rows = append(s.Rows, ImpressionRow{
+				Hour:    hour,
+				Sign:    sign,
+				Tier:    tier,
+				Sponsor: name,
+				Image:   image,
+				Shown:   count.Shown,
+				Seconds: count.Seconds,
+			})
+		}
+		return nil
+	})
+	if err != nil {
+		return s, err
+	}
+
+	s.Totals = totalImpressions(s.Rows)
+	return s, nil
+}
+
+// totalImpressions sums rows per sponsor and tier, most seconds first
+func totalImpressions(rows []ImpressionRow) []ImpressionTotal {
+	type key struct{ tier, image string }
+	totals := make(map[key]*ImpressionTotal)
+	signs := make(map[key]map[string]bool)
+	var order []key
+	for _, r := range rows {
+		k := key{r.Tier, r.Image}
+		t, ok := totals[k]
+		if !ok {
+			t = &ImpressionTotal{Sponsor: r.Sponsor, Tier: r.Tier, Image: r.Image}
+			totals[k] = t
+			signs[k] = make(map[string]bool)
+			order = append(order, k)
+		}
+		t.Shown += r.Shown
+		t.Seconds += r.Seconds
+		signs[k][r.Sign] = true
+	}
+
+	result := make([]ImpressionTotal, 0, len(order))
+	for _, k := range order {
+		t := totals[k]
+		t.Signs = len(signs[k])
+		result = append(result, *t)
+	}
+	sort.SliceStable(result, func(i, j int) bool {
+		return result[i].Seconds > result[j].Seconds
+	})
+	return result
+}
+
+// HandleImpressions returns a handler passing the impressions a display posts
+// to each recorder. Posts from other machines must carry the fleet token.
+func HandleImpressions(token string, recorders ...ImpressionRecorder) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			w.Header().Set("Allow", http.MethodPost)
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		if !fleet.Local(r) && !fleet.Authorized(r, token) {
+			logger().Warn("rejected unauthorized impressions", "client", r.RemoteAddr)
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		var report ImpressionReport
+		dec := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxImpressionsBody))
+		dec.DisallowUnknownFields()
+		if err := dec.Decode(&report); err != nil {
+			http.Error(w, "invalid impressions: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		for _, rec := range recorders {
+			err := rec.Record(report)
+			if errors.Is(err, ErrInvalidImpressions) {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			if err != nil {
+				logger().Error("unable to record impressions", "sign", report.Sign, "err", err)
+				http.Error(w, "unable to record impressions", http.StatusInternalServerError)
+				return
+			}
+		}
+
+		logger().Debug("impressions recorded", "sign", report.Sign, "count", len(report.Impressions))
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// ImpressionQueue holds the impressions a sign has yet to forward to the fleet
+// controller, dropping the oldest beyond what fits in one heartbeat
+type ImpressionQueue struct {
+	mutex   sync.Mutex
+	pending []Impression
+}
+
+// NewImpressionQueue produces an empty ImpressionQueue
+func NewImpressionQueue() *ImpressionQueue {
+	return &ImpressionQueue{}
+}
+
+// Record queues the impressions of a report. The controller files them under
+// the sign ID of the heartbeat they arrive with.
+func (q *ImpressionQueue) Record(r ImpressionReport) error {
+	if err := r.validate(time.Now()); err != nil {
+		return fmt.Errorf("%w: %w", ErrInvalidImpressions, err)
+	}
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+	q.pending = q.trim(append(q.pending, r.Impressions...))
+	return nil
+}
+
+// Take returns and clears the queued impressions
+func (q *ImpressionQueue) Take() []Impression {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+	imps := q.pending
+	q.pending = nil
+	return imps
+}
+
+// Requeue puts back impressions that could not be forwarded, ahead of any
+// queued since
+func (q *ImpressionQueue) Requeue(imps []Impression) {
+	if len(imps) == 0 {
+		return
+	}
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+	q.pending = q.trim(append(slices.Clip(imps), q.pending...))
+}
+
+// trim drops the oldest impressions beyond maxImpressionsPerPost
+func (q *ImpressionQueue) trim(imps []Impression) []Impression {
+	if extra := len(imps) - maxImpressionsPerPost; extra > 0 {
+		logger().Warn("dropping impressions not yet forwarded to the controller", "count", extra)
+		return slices.Delete(imps, 0, extra)
+	}
+	return imps
+}
+
+// parseReportTime reads a report bound as RFC 3339 or a date in UTC
+func parseReportTime(v string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, v); err == nil {
+		return t, nil
+	}
+	return time.Parse(time.DateOnly, v)
+}
+
+// HandleReport returns the impressions as JSON, or as CSV with format=csv.
+// from and to bound the hours as RFC 3339 times or dates, with to exclusive,
+// and sign and tier narrow the report to one of each.
+func (im *Impressions) HandleReport(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	f := ImpressionFilter{Sign: q.Get("sign"), Tier: q.Get("tier")}
+	for _, p := range []struct {
+		name  string
+		value *time.Time
+	}{{"from", &f.From}, {"to", &f.To}} {
+		if v := q.Get(p.name); v != "" {
+			t, err := parseReportTime(v)
+			if err != nil {
+				http.Error(w, fmt.Sprintf("invalid %s %q, want a date or RFC 3339 time", p.name, v), http.StatusBadRequest)
+				return
+			}
+			*p.value = t
+		}
+	}
+
+	format := q.Get("format")
+	if format != "" && format != "json" && format != "csv" {
+		http.Error(w, "format must be json or csv", http.StatusBadRequest)
+		return
+	}
+
+	summary, err := im.Report(f)
+	if err != nil {
+		logger().Error("unable to read impressions", "err", err)
+		http.Error(w, "unable to read impressions", http.StatusInternalServerError)
+		return
+	}
+
+	if format == "csv" {
+		var buf bytes.Buffer
+		if err := writeImpressionsCSV(&buf, summary.Rows); err != nil {
+			logger().Error("unable to encode impressions", "format", "csv", "err", err)
+			http.Error(w, "unable to encode impressions", http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+		w.Header().Set("Content-Disposition", `attachment; filename="sponsor-impressions.csv"`)
+		w.Write(buf.Bytes())
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(summary); err != nil {
+		logger().Error("unable to encode impressions", "format", "json", "err", err)
+	}
+}
+
+// csvText keeps spreadsheets from reading a name as a formula
+func csvText(s string) string {
+	if s != "" && strings.ContainsRune("=+-@", rune(s[0])) {
+		return "'" + s
+	}
+	return s
+}
+
+// writeImpressionsCSV writes one line per row with a header
+func writeImpressionsCSV(buf *bytes.Buffer, rows []ImpressionRow) error {
+	cw := csv.NewWriter(buf)
+	cw.Write([]string{"hour", "sign", "tier", "sponsor", "image", "shown", "seconds"})
+	for _, r := range rows {
+		cw.Write([]string{
+			r.Hour.UTC().Format(time.RFC3339),
+			r.Sign,
+			r.Tier,
+			csvText(r.Sponsor),
+			csvText(r.Image),
+			strconv.Itoa(r.Shown),
+			strconv.FormatFloat(r.Seconds, 'f', -1, 64),
+		})
+	}
+	cw.Flush()
+	return cw.Error()
+}
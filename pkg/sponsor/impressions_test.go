@@ -0,0 +1,202 @@
+package sponsor
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// testFleetToken authorizes impressions posted from another machine
+const testFleetToken = "0123456789abcdef"
+
+// postImpressions posts a report body from the display on the same machine
+// and returns the status
+func postImpressions(t *testing.T, im *Impressions, body string) int {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodPost, ImpressionsPath, strings.NewReader(body))
+	req.RemoteAddr = "127.0.0.1:51000"
+	rr := httptest.NewRecorder()
+	HandleImpressions(testFleetToken, im)(rr, req)
+	return rr.Code
+}
+
+func TestImpressions(t *testing.T) {
+	manager, err := NewManager("")
+	if err != nil {
+		t.Fatalf("❌ Failed to create sponsor manager: %v", err)
+	}
+	path := filepath.Join(t.TempDir(), "impressions.db")
+	im, err := OpenImpressions(path, manager)
+	if err != nil {
+		t.Fatalf("❌ OpenImpressions() unexpected error: %v", err)
+	}
+
+	hour := time.Now().UTC().Truncate(time.Hour)
+	earlier := hour.Add(-time.Hour).Add(10 * time.Minute).Format(time.RFC3339)
+	// Times within this hour, so the test can't straddle two
+	at := hour.Add(time.Minute).Format(time.RFC3339)
+	if code := postImpressions(t, im, `{"sign":"hall-1","impressions":[
+		{"image":"microsoft.png","seconds":10,"at":"`+at+`"},
+		{"image":"microsoft.png","seconds":10,"at":"`+at+`"},
+		{"image":"aws.png","seconds":10,"at":"`+at+`"},
+		{"image":"debian.png","seconds":5.5,"at":"`+earlier+`"}
+	]}`); code != http.StatusNoContent {
+		t.Fatalf("❌ POST %s = %d, want 204", ImpressionsPath, code)
+	}
+	if code := postImpressions(t, im, `{"sign":"ballroom-a","impressions":[{"image":"microsoft.png","seconds":10,"at":"`+at+`"}]}`); code != http.StatusNoContent {
+		t.Fatalf("❌ POST %s = %d, want 204", ImpressionsPath, code)
+	}
+
+	for name, body := range map[string]string{
+		"Not JSON":       `impressions`,
+		"Unknown field":  `{"sign":"hall-1","impressions":[],"extra":1}`,
+		"No sign":        `{"impressions":[{"image":"aws.png","seconds":10}]}`,
+		"Bad sign":       `{"sign":"hall 1","impressions":[{"image":"aws.png","seconds":10}]}`,
+		"Path":           `{"sign":"hall-1","impressions":[{"image":"../aws.png","seconds":10}]}`,
+		"Not an image":   `{"sign":"hall-1","impressions":[{"image":"notes.txt","seconds":10}]}`,
+		"No seconds":     `{"sign":"hall-1","impressions":[{"image":"aws.png","seconds":0}]}`,
+		"Too long":       `{"sign":"hall-1","impressions":[{"image":"aws.png","seconds":7200}]}`,
+		"In the future":  `{"sign":"hall-1","impressions":[{"image":"aws.png","seconds":10,"at":"` + hour.Add(2*time.Hour).Format(time.RFC3339) + `"}]}`,
+		"Too long ago":   `{"sign":"hall-1","impressions":[{"image":"aws.png","seconds":10,"at":"2020-01-01T00:00:00Z"}]}`,
+		"One bad of two": `{"sign":"hall-1","impressions":[{"image":"aws.png","seconds":10},{"image":"","seconds":10}]}`,
+	} {
+		if code := postImpressions(t, im, body); code != http.StatusBadRequest {
+			t.Errorf("❌ %s: POST %s = %d, want 400", name, ImpressionsPath, code)
+		}
+	}
+	rr := httptest.NewRecorder()
+	HandleImpressions(testFleetToken, im)(rr, httptest.NewRequest(http.MethodGet, ImpressionsPath, nil))
+	if rr.Code != http.StatusMethodNotAllowed {
+		t.Errorf("❌ GET %s = %d, want 405", ImpressionsPath, rr.Code)
+	}
+
+	// Other machines need the fleet token
+	q := NewImpressionQueue()
+	remote := `{"sign":"hall-2","impressions":[{"image":"aws.png","seconds":10,"at":"` + at + `"}]}`
+	rr = httptest.NewRecorder()
+	HandleImpressions(testFleetToken, q)(rr, httptest.NewRequest(http.MethodPost, ImpressionsPath, strings.NewReader(remote)))
+	if rr.Code != http.StatusUnauthorized {
+		t.Errorf("❌ POST %s from another machine without a token = %d, want 401", ImpressionsPath, rr.Code)
+	}
+	req := httptest.NewRequest(http.MethodPost, ImpressionsPath, strings.NewReader(remote))
+	req.Header.Set("Authorization", "Bearer "+testFleetToken)
+	rr = httptest.NewRecorder()
+	HandleImpressions(testFleetToken, q)(rr, req)
+	if rr.Code != http.StatusNoContent || len(q.Take()) != 1 {
+		t.Errorf("❌ POST %s from another machine with the token = %d, want 204 and one impression queued", ImpressionsPath, rr.Code)
+	}
+
+	// Counts survive reopening the database
+	im.Close()
+	if im, err = OpenImpressions(path, manager); err != nil {
+		t.Fatalf("❌ OpenImpressions() again unexpected error: %v", err)
+	}
+	defer im.Close()
+
+	s, err := im.Report(ImpressionFilter{})
+	if err != nil {
+		t.Fatalf("❌ Report() unexpected error: %v", err)
+	}
+	if len(s.Rows) != 4 || !s.Rows[0].Hour.Equal(hour.Add(-time.Hour)) || s.Rows[0].Image != "debian.png" || s.Rows[0].Tier != "" {
+		t.Fatalf("❌ Report() rows = %+v, want 4 with debian.png in no tier first", s.Rows)
+	}
+	if top := s.Totals[0]; top.Image != "microsoft.png" || top.Sponsor != "Microsoft" || top.Tier != "diamond" ||
+		top.Shown != 3 || top.Seconds != 30 || top.Signs != 2 {
+		t.Errorf("❌ Top total = %+v, want Microsoft shown 3 times for 30s on 2 signs", top)
+	} else {
+		t.Logf("✅ %s shown %d times for %gs on %d signs", top.Sponsor, top.Shown, top.Seconds, top.Signs)
+	}
+
+	for _, tt := range []struct {
+		name string
+		f    ImpressionFilter
+		rows int
+	}{
+		{"Sign", ImpressionFilter{Sign: "ballroom-a"}, 1},
+		{"Tier", ImpressionFilter{Tier: "platinum"}, 1},
+		{"From", ImpressionFilter{From: hour}, 3},
+		{"To", ImpressionFilter{To: hour}, 1},
+		{"Nothing", ImpressionFilter{From: hour.Add(time.Hour)}, 0},
+	} {
+		s, err := im.Report(tt.f)
+		if err != nil || len(s.Rows) != tt.rows {
+			t.Errorf("❌ %s: Report() = %d rows, %v, want %d", tt.name, len(s.Rows), err, tt.rows)
+		}
+	}
+
+	rr = httptest.NewRecorder()
+	im.HandleReport(rr, httptest.NewRequest(http.MethodGet, ReportPath+"?format=csv&sign=hall-1", nil))
+	if rr.Code != http.StatusOK || !strings.HasPrefix(rr.Header().Get("Content-Type"), "text/csv") {
+		t.Fatalf("❌ CSV report = %d %s", rr.Code, rr.Header().Get("Content-Type"))
+	}
+	records, err := csv.NewReader(rr.Body).ReadAll()
+	if err != nil || len(records) != 4 || strings.Join(records[0], ",") != "hour,sign,tier,sponsor,image,shown,seconds" {
+		t.Fatalf("❌ CSV report = %v, %v", records, err)
+	}
+	if got := strings.Join(records[1], ","); got != hour.Add(-time.Hour).Format(time.RFC3339)+",hall-1,,debian,debian.png,1,5.5" {
+		t.Errorf("❌ First CSV row = %s", got)
+	} else {
+		t.Logf("✅ CSV report with %d rows", len(records)-1)
+	}
+
+	rr = httptest.NewRecorder()
+	im.HandleReport(rr, httptest.NewRequest(http.MethodGet, ReportPath+"?from="+hour.Format(time.DateOnly), nil))
+	var summary ImpressionSummary
+	if err := json.NewDecoder(rr.Body).Decode(&summary); err != nil || summary.From == nil || len(summary.Totals) == 0 {
+		t.Errorf("❌ JSON report = %+v, %v", summary, err)
+	}
+
+	for _, query := range []string{"?format=xml", "?from=yesterday", "?to=2026-13-01"} {
+		rr = httptest.NewRecorder()
+		im.HandleReport(rr, httptest.NewRequest(http.MethodGet, ReportPath+query, nil))
+		if rr.Code != http.StatusBadRequest {
+			t.Errorf("❌ %s%s = %d, want 400", ReportPath, query, rr.Code)
+		}
+	}
+}
+
+func TestImpressionQueue(t *testing.T) {
+	q := NewImpressionQueue()
+	if err := q.Record(ImpressionReport{Sign: "hall-1", Impressions: []Impression{{Image: "notes.txt", Seconds: 10}}}); !errors.Is(err, ErrInvalidImpressions) {
+		t.Errorf("❌ Record() of an invalid report error = %v, want ErrInvalidImpressions", err)
+	}
+
+	if err := q.Record(ImpressionReport{Sign: "hall-1", Impressions: []Impression{{Image: "aws.png", Seconds: 10}}}); err != nil {
+		t.Fatalf("❌ Record() unexpected error: %v", err)
+	}
+	taken := q.Take()
+	if len(taken) != 1 || taken[0].At.IsZero() {
+		t.Fatalf("❌ Take() = %+v, want aws.png with the time filled in", taken)
+	}
+	if again := q.Take(); len(again) != 0 {
+		t.Errorf("❌ Take() after Take() = %+v, want nothing", again)
+	}
+
+	// A failed heartbeat puts its impressions back ahead of newer ones
+	if err := q.Record(ImpressionReport{Sign: "hall-1", Impressions: []Impression{{Image: "debian.png", Seconds: 5}}}); err != nil {
+		t.Fatalf("❌ Record() unexpected error: %v", err)
+	}
+	q.Requeue(taken)
+	if got := q.Take(); len(got) != 2 || got[0].Image != "aws.png" || got[1].Image != "debian.png" {
+		t.Errorf("❌ Take() after Requeue() = %+v, want aws.png then debian.png", got)
+	}
+
+	// The oldest are dropped beyond what fits in one heartbeat
+	many := make([]Impression, maxImpressionsPerPost)
+	for i := range many {
+		many[i] = Impression{Image: "aws.png", Seconds: 1}
+	}
+	q.Requeue([]Impression{{Image: "debian.png", Seconds: 1}})
+	q.Requeue(many)
+	if got := q.Take(); len(got) != maxImpressionsPerPost || got[len(got)-1].Image != "debian.png" {
+		t.Errorf("❌ Take() after overflow has %d impressions, want %d ending with the newest", len(got), maxImpressionsPerPost)
+	} else {
+		t.Logf("✅ queue kept the newest %d impressions", len(got))
+	}
+}
@@ -0,0 +1,65 @@
+package sponsor
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+)
+
+// ImagesPath is where the sponsor images are served
+const ImagesPath = "/sponsors/images"
+
+// Listing is every sponsor of every tier as served at /sponsors
+type Listing struct {
+	Tiers    []string `json:"tiers"` // Tier names, highest first
+	Sponsors []Entry  `json:"sponsors"`
+}
+
+// Entry is a sponsor with its tier and resolved display values
+type Entry struct {
+	Name     string    `json:"name"` // Also the alt text for the image
+	Tier     string    `json:"tier"`
+	Image    string    `json:"image"`
+	ImageURL string    `json:"imageUrl"`
+	Website  string    `json:"website,omitempty"`
+	QR       string    `json:"qr,omitempty"`
+	Weight   int       `json:"weight"`
+	Rooms    []string  `json:"rooms,omitempty"`
+	Topics   []string  `json:"topics,omitempty"`
+	From     time.Time `json:"from,omitzero"`
+	Until    time.Time `json:"until,omitzero"`
+}
+
+// Listing returns every sponsor in tier order
+func (m *Manifest) Listing() Listing {
+	l := Listing{
+		Tiers:    m.Names(),
+		Sponsors: []Entry{},
+	}
+	for _, t := range m.Tiers {
+		for _, s := range t.Sponsors {
+			l.Sponsors = append(l.Sponsors, Entry{
+				Name:     s.DisplayName(),
+				Tier:     t.Name,
+				Image:    s.Image,
+				ImageURL: ImagesPath + "/" + s.Image,
+				Website:  s.Website,
+				QR:       s.QR,
+				Weight:   s.DisplayWeight(),
+				Rooms:    s.Rooms,
+				Topics:   s.Topics,
+				From:     s.From,
+				Until:    s.Until,
+			})
+		}
+	}
+	return l
+}
+
+// HandleSponsors returns the sponsors of every tier with their metadata
+func (m *Manager) HandleSponsors(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(m.Manifest().Listing()); err != nil {
+		logger().Error("unable to encode sponsors", "tier", "manifest", "err", err)
+	}
+}
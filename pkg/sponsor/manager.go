@@ -2,8 +2,12 @@ package sponsor
 
 import (
 	"embed"
+	"encoding/json"
 	"io/fs"
+	"log/slog"
 	"net/http"
+	"path"
+	"sync"
 )
 
 // Embed all sponsor images
@@ -11,26 +15,92 @@ import (
 //go:embed images/*
 var imagesFS embed.FS
 
+// logger returns the default logger tagged for this package
+func logger() *slog.Logger {
+	return slog.Default().With("component", "sponsor")
+}
+
 type Manager struct {
-	images fs.FS
+	images    fs.FS
+	scaled    *scaledCache
+	rotations *rotationCache
+
+	mutex    sync.RWMutex
+	manifest *Manifest
 }
 
-func NewManager() (*Manager, error) {
-	imagesDir, err := fs.Sub(imagesFS, "images")
+// NewManager serves the embedded sponsor images, overlaid by the images in
+// imagesDir when it is not empty. Images added to, replaced in or removed from
+// imagesDir are picked up without a restart. It starts with the embedded tiers,
+// unchecked until SetManifest.
+func NewManager(imagesDir string) (*Manager, error) {
+	var images fs.FS
+	images, err := fs.Sub(imagesFS, "images")
+	if err != nil {
+		return nil, err
+	}
+	if imagesDir != "" {
+		if images, err = newImageDir(images, imagesDir); err != nil {
+			return nil, err
+		}
+	}
+
+	manifest, err := DefaultManifest()
 	if err != nil {
 		return nil, err
 	}
 	return &Manager{
-		images: imagesDir,
+		images:    images,
+		scaled:    newScaledCache(scaledCacheSize),
+		rotations: &rotationCache{},
+		manifest:  manifest,
 	}, nil
 }
 
-// ImageHandler returns a http.Handler for the embedded images
+// SetManifest replaces the sponsor tiers, such as with one loaded from disk,
+// and returns the result of checking them against the images. Problems are
+// logged but the tiers are applied regardless.
+func (m *Manager) SetManifest(manifest *Manifest) Status {
+	st := m.Check(manifest)
+	logStatus(st)
+
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	m.manifest = manifest
+	logger().Info("sponsor tiers loaded", "tiers", len(manifest.Tiers), "errors", len(st.Errors()))
+	return st
+}
+
+// Manifest returns the current sponsor tiers
+func (m *Manager) Manifest() *Manifest {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+	return m.manifest
+}
+
+// HandleTier returns the image files of the tier named by the last path
+// element, as in /sponsors/gold. It is a view over the same data as
+// HandleSponsors for displays that only need the logos.
+func (m *Manager) HandleTier(w http.ResponseWriter, r *http.Request) {
+	name := path.Base(r.URL.Path)
+	tier, ok := m.Manifest().Tier(name)
+	if !ok {
+		http.Error(w, "unknown sponsor tier "+name, http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(tier.Images()); err != nil {
+		logger().Error("unable to encode sponsors", "tier", name, "err", err)
+	}
+}
+
+// ImageHandler returns a http.Handler for the sponsor images
 func (m *Manager) ImageHandler() http.Handler {
 	return http.FileServer(http.FS(m.images))
 }
 
-// GetFS returns the embedded file system
+// GetFS returns the sponsor images file system
 func (m *Manager) GetFS() http.FileSystem {
 	return http.FS(m.images)
 }
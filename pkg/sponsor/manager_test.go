@@ -12,7 +12,7 @@ import (
 
 func TestSponsorImagesAre220x220(t *testing.T) {
 	// Create a new manager to access the embedded files
-	manager, err := NewManager()
+	manager, err := NewManager("")
 	if err != nil {
 		t.Fatalf("Failed to create manager: %v", err)
 	}
@@ -31,6 +31,16 @@ func TestSponsorImagesAre220x220(t *testing.T) {
 		// Check file extension to make sure it's an image
 		ext := path.Ext(filePath)
 		switch ext {
+		case ".svg":
+			// SVGs scale to fit, so only need to parse and have a size
+			data, err := fs.ReadFile(manager.images, filePath)
+			if err != nil {
+				t.Errorf("❌ Failed to read image %s: %v", filePath, err)
+				return nil
+			}
+			if _, config, err := sanitizeSVG(data); err != nil || config.Width == 0 || config.Height == 0 {
+				t.Errorf("❌ SVG %s has no size or doesn't parse: %v", filePath, err)
+			}
 		case ".jpg", ".jpeg", ".png", ".gif":
 			// Open the file
 			file, err := manager.images.Open(filePath)
@@ -70,7 +80,7 @@ func TestSponsorImagesAre220x220(t *testing.T) {
 // TestNoImageFiles tests that the images directory is not empty
 func TestNoImageFiles(t *testing.T) {
 	// Create a new manager to access the embedded files
-	manager, err := NewManager()
+	manager, err := NewManager("")
 	if err != nil {
 		t.Fatalf("Failed to create manager: %v", err)
 	}
@@ -89,7 +99,7 @@ func TestNoImageFiles(t *testing.T) {
 
 		// Count files with image extensions
 		ext := path.Ext(filePath)
-		if ext == ".jpg" || ext == ".jpeg" || ext == ".png" || ext == ".gif" {
+		if ext == ".jpg" || ext == ".jpeg" || ext == ".png" || ext == ".gif" || ext == ".svg" {
 			fileCount++
 		}
 
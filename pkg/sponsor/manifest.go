@@ -0,0 +1,239 @@
+package sponsor
+
+import (
+	"bytes"
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"path"
+	"slices"
+	"strings"
+	"time"
+)
+
+// defaultManifest lists the tiers of the embedded images
+//
+//go:embed sponsors.json
+var defaultManifest []byte
+
+// reservedTiers are paths under /sponsors that are not tiers
+var reservedTiers = []string{"all", "images", "status", "rotation", "impressions", "report", "version"}
+
+// Tier is a named group of sponsors shown together, in manifest order
+type Tier struct {
+	Name       string    `json:"name"`
+	Weight     int       `json:"weight,omitempty"`     // Multiplies the weight of each sponsor in the rotation, 1 by default
+	MinPerHour int       `json:"minPerHour,omitempty"` // Rotation slots each sponsor is guaranteed per hour
+	Sponsors   []Sponsor `json:"sponsors"`
+}
+
+// DisplayWeight returns the tier's weight in the rotation
+func (t Tier) DisplayWeight() int {
+	if t.Weight == 0 {
+		return 1
+	}
+	return t.Weight
+}
+
+// Sponsor is one sponsor in a tier. In the manifest it is either an object or
+// just the image file name.
+type Sponsor struct {
+	Image   string    `json:"image"`             // Image file name
+	Name    string    `json:"name,omitempty"`    // Display name, the image name without extension by default
+	Website string    `json:"website,omitempty"` // Sponsor's website
+	QR      string    `json:"qr,omitempty"`      // Where a QR code next to the logo should lead
+	Weight  int       `json:"weight,omitempty"`  // Relative display share, 1 by default
+	Rooms   []string  `json:"rooms,omitempty"`   // Rooms the sponsor has bought, matched to session locations
+	Topics  []string  `json:"topics,omitempty"`  // Session topics the sponsor has bought, such as Kubernetes
+	From    time.Time `json:"from,omitzero"`     // Start of the sessions the sponsor is tied to
+	Until   time.Time `json:"until,omitzero"`    // End of the sessions the sponsor is tied to
+}
+
+// UnmarshalJSON accepts a sponsor object or a bare image file name
+func (s *Sponsor) UnmarshalJSON(data []byte) error {
+	var image string
+	if err := json.Unmarshal(data, &image); err == nil {
+		*s = Sponsor{Image: image}
+		return nil
+	}
+
+	// A distinct type so decoding doesn't recurse into this method
+	type sponsor Sponsor
+	var v sponsor
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&v); err != nil {
+		return err
+	}
+	*s = Sponsor(v)
+	return nil
+}
+
+// DisplayName returns the name to show for the sponsor
+func (s Sponsor) DisplayName() string {
+	if s.Name != "" {
+		return s.Name
+	}
+	return strings.TrimSuffix(s.Image, path.Ext(s.Image))
+}
+
+// DisplayWeight returns the sponsor's relative display share
+func (s Sponsor) DisplayWeight() int {
+	if s.Weight == 0 {
+		return 1
+	}
+	return s.Weight
+}
+
+// validate checks the image is a plain file name, any links are absolute
+// http or https URLs and any targets are usable
+func (s Sponsor) validate() error {
+	if s.Image == "" || s.Image != path.Base(s.Image) || s.Image == "." || s.Image == ".." {
+		return fmt.Errorf("sponsor image %q must be a file name", s.Image)
+	}
+
+	if s.Weight < 0 {
+		return fmt.Errorf("sponsor %s: weight must not be negative, got %d", s.Image, s.Weight)
+	}
+
+	for _, target := range slices.Concat(s.Rooms, s.Topics) {
+		if strings.TrimSpace(target) == "" {
+			return fmt.Errorf("sponsor %s: rooms and topics must not be empty", s.Image)
+		}
+	}
+	if !s.From.IsZero() && !s.Until.IsZero() && !s.Until.After(s.From) {
+		return fmt.Errorf("sponsor %s: until must be after from", s.Image)
+	}
+
+	for _, link := range []string{s.Website, s.QR} {
+		if link == "" {
+			continue
+		}
+		u, err := url.Parse(link)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("sponsor %s: %q must be an http or https URL", s.Image, link)
+		}
+	}
+
+	return nil
+}
+
+// Manifest lists the sponsor tiers, highest first
+type Manifest struct {
+	Tiers []Tier `json:"tiers"`
+}
+
+// DefaultManifest returns the manifest embedded in the binary
+func DefaultManifest() (*Manifest, error) {
+	return parseManifest(defaultManifest)
+}
+
+// LoadManifest reads a manifest from disk in the format of the embedded
+// sponsors.json
+func LoadManifest(path string) (*Manifest, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	m, err := parseManifest(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return m, nil
+}
+
+// parseManifest decodes and validates a manifest
+func parseManifest(data []byte) (*Manifest, error) {
+	var m Manifest
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&m); err != nil {
+		return nil, fmt.Errorf("invalid manifest: %w", err)
+	}
+
+	if err := m.Validate(); err != nil {
+		return nil, err
+	}
+	return &m, nil
+}
+
+// Validate checks that tier names are unique and usable in a URL and that
+// every sponsor is valid
+func (m *Manifest) Validate() error {
+	if len(m.Tiers) == 0 {
+		return fmt.Errorf("manifest must have at least one tier")
+	}
+
+	seen := make(map[string]bool)
+	for _, t := range m.Tiers {
+		if err := validateTierName(t.Name); err != nil {
+			return err
+		}
+		if seen[t.Name] {
+			return fmt.Errorf("tier %s is listed twice", t.Name)
+		}
+		seen[t.Name] = true
+
+		if t.Weight < 0 || t.MinPerHour < 0 {
+			return fmt.Errorf("tier %s: weight and minPerHour must not be negative", t.Name)
+		}
+
+		for _, s := range t.Sponsors {
+			if err := s.validate(); err != nil {
+				return fmt.Errorf("tier %s: %w", t.Name, err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// validateTierName checks that a tier name is a lowercase URL path segment
+func validateTierName(name string) error {
+	if name == "" {
+		return fmt.Errorf("tier name must not be empty")
+	}
+
+	for _, r := range name {
+		if !(r >= 'a' && r <= 'z' || r >= '0' && r <= '9' || r == '-' || r == '_') {
+			return fmt.Errorf("tier name may only contain lowercase letters, digits, '-' and '_', got %q", name)
+		}
+	}
+
+	if slices.Contains(reservedTiers, name) {
+		return fmt.Errorf("tier name %s is reserved", name)
+	}
+
+	return nil
+}
+
+// Tier returns the tier called name
+func (m *Manifest) Tier(name string) (Tier, bool) {
+	for _, t := range m.Tiers {
+		if t.Name == name {
+			return t, true
+		}
+	}
+	return Tier{}, false
+}
+
+// Names returns the tier names in order
+func (m *Manifest) Names() []string {
+	names := make([]string, len(m.Tiers))
+	for i, t := range m.Tiers {
+		names[i] = t.Name
+	}
+	return names
+}
+
+// Images returns the image file names of the tier's sponsors in order
+func (t Tier) Images() []string {
+	images := make([]string, len(t.Sponsors))
+	for i, s := range t.Sponsors {
+		images[i] = s.Image
+	}
+	return images
+}
@@ -0,0 +1,169 @@
+package sponsor
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestDefaultManifest(t *testing.T) {
+	m, err := DefaultManifest()
+	if err != nil {
+		t.Fatalf("❌ DefaultManifest() unexpected error: %v", err)
+	}
+
+	if got := strings.Join(m.Names(), ","); got != "diamond,platinum,gold" {
+		t.Errorf("❌ default tiers = %s, want diamond,platinum,gold", got)
+	}
+
+	manager, err := NewManager("")
+	if err != nil {
+		t.Fatalf("❌ Failed to create sponsor manager: %v", err)
+	}
+	for _, tier := range m.Tiers {
+		for _, s := range tier.Sponsors {
+			if _, err := manager.images.Open(s.Image); err != nil {
+				t.Errorf("❌ %s sponsor %s has no embedded image", tier.Name, s.Image)
+			}
+		}
+	}
+}
+
+func TestLoadManifest(t *testing.T) {
+	tests := []struct {
+		name        string
+		manifest    string
+		wantTiers   string
+		errContains string
+	}{
+		{name: "Any number of tiers", manifest: `{"tiers":[{"name":"title","sponsors":["aws.png"]},{"name":"silver","sponsors":[]},{"name":"community-partners","sponsors":["kernelorg.png"]}]}`,
+			wantTiers: "title,silver,community-partners"},
+		{name: "No tiers", manifest: `{"tiers":[]}`, errContains: "at least one tier"},
+		{name: "Duplicate tier", manifest: `{"tiers":[{"name":"gold"},{"name":"gold"}]}`, errContains: "listed twice"},
+		{name: "Reserved tier", manifest: `{"tiers":[{"name":"images"}]}`, errContains: "reserved"},
+		{name: "Bad tier name", manifest: `{"tiers":[{"name":"Gold Level"}]}`, errContains: "lowercase"},
+		{name: "Path in sponsor", manifest: `{"tiers":[{"name":"gold","sponsors":["../secret.png"]}]}`, errContains: "file name"},
+		{name: "Unknown field", manifest: `{"tiers":[{"name":"gold","logos":[]}]}`, errContains: "unknown field"},
+		{name: "Sponsor objects", manifest: `{"tiers":[{"name":"gold","sponsors":["aws.png",{"image":"google.png","name":"Google","weight":2}]}]}`,
+			wantTiers: "gold"},
+		{name: "Unknown sponsor field", manifest: `{"tiers":[{"name":"gold","sponsors":[{"image":"aws.png","url":"https://aws.amazon.com"}]}]}`,
+			errContains: "unknown field"},
+		{name: "Bad website", manifest: `{"tiers":[{"name":"gold","sponsors":[{"image":"aws.png","website":"aws.amazon.com"}]}]}`,
+			errContains: "http or https URL"},
+		{name: "Negative weight", manifest: `{"tiers":[{"name":"gold","sponsors":[{"image":"aws.png","weight":-1}]}]}`,
+			errContains: "must not be negative"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "sponsors.json")
+			if err := os.WriteFile(path, []byte(tt.manifest), 0600); err != nil {
+				t.Fatalf("❌ Failed to write manifest: %v", err)
+			}
+
+			m, err := LoadManifest(path)
+			if tt.errContains != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.errContains) {
+					t.Errorf("❌ error = %v, want error containing %s", err, tt.errContains)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("❌ LoadManifest() unexpected error: %v", err)
+			}
+			if got := strings.Join(m.Names(), ","); got != tt.wantTiers {
+				t.Errorf("❌ tiers = %s, want %s", got, tt.wantTiers)
+			} else {
+				t.Logf("✅ loaded tiers %s", got)
+			}
+		})
+	}
+}
+
+func TestSetManifest(t *testing.T) {
+	manager, err := NewManager("")
+	if err != nil {
+		t.Fatalf("❌ Failed to create sponsor manager: %v", err)
+	}
+
+	m, err := parseManifest([]byte(`{"tiers":[{"name":"title","sponsors":["aws.png","google.png"]},{"name":"silver"}]}`))
+	if err != nil {
+		t.Fatalf("❌ parseManifest() unexpected error: %v", err)
+	}
+	manager.SetManifest(m)
+
+	get := func(path string) (int, []string) {
+		rr := httptest.NewRecorder()
+		manager.HandleTier(rr, httptest.NewRequest(http.MethodGet, path, nil))
+		var sponsors []string
+		json.NewDecoder(rr.Body).Decode(&sponsors)
+		return rr.Code, sponsors
+	}
+
+	if code, sponsors := get("/sponsors/title"); code != http.StatusOK || strings.Join(sponsors, ",") != "aws.png,google.png" {
+		t.Errorf("❌ /sponsors/title = %d %v, want 200 [aws.png google.png]", code, sponsors)
+	}
+	if code, sponsors := get("/sponsors/silver"); code != http.StatusOK || sponsors == nil || len(sponsors) != 0 {
+		t.Errorf("❌ /sponsors/silver = %d %v, want 200 and an empty list", code, sponsors)
+	}
+	if code, _ := get("/sponsors/gold"); code != http.StatusNotFound {
+		t.Errorf("❌ /sponsors/gold after replacing the manifest = %d, want 404", code)
+	} else {
+		t.Logf("✅ tiers follow the new manifest")
+	}
+}
+
+func TestHandleSponsors(t *testing.T) {
+	manager, err := NewManager("")
+	if err != nil {
+		t.Fatalf("❌ Failed to create sponsor manager: %v", err)
+	}
+
+	m, err := parseManifest([]byte(`{"tiers":[
+		{"name":"platinum","sponsors":[{"image":"aws.png","name":"AWS","website":"https://aws.amazon.com","qr":"https://example.com/aws-booth","weight":3}]},
+		{"name":"gold","sponsors":["google.png"]}
+	]}`))
+	if err != nil {
+		t.Fatalf("❌ parseManifest() unexpected error: %v", err)
+	}
+	manager.SetManifest(m)
+
+	rr := httptest.NewRecorder()
+	manager.HandleSponsors(rr, httptest.NewRequest(http.MethodGet, "/sponsors", nil))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("❌ /sponsors status %d, want 200", rr.Code)
+	}
+
+	var listing Listing
+	if err := json.NewDecoder(rr.Body).Decode(&listing); err != nil {
+		t.Fatalf("❌ Failed to decode listing: %v", err)
+	}
+	if strings.Join(listing.Tiers, ",") != "platinum,gold" || len(listing.Sponsors) != 2 {
+		t.Fatalf("❌ unexpected listing: %+v", listing)
+	}
+
+	want := []Entry{
+		{Name: "AWS", Tier: "platinum", Image: "aws.png", ImageURL: "/sponsors/images/aws.png",
+			Website: "https://aws.amazon.com", QR: "https://example.com/aws-booth", Weight: 3},
+		{Name: "google", Tier: "gold", Image: "google.png", ImageURL: "/sponsors/images/google.png", Weight: 1},
+	}
+	for i, e := range listing.Sponsors {
+		if !reflect.DeepEqual(e, want[i]) {
+			t.Errorf("❌ sponsor %d = %+v, want %+v", i, e, want[i])
+		} else {
+			t.Logf("✅ %s (%s) at %s", e.Name, e.Tier, e.ImageURL)
+		}
+	}
+
+	// The per-tier endpoints stay views over the same data
+	rr = httptest.NewRecorder()
+	manager.HandleTier(rr, httptest.NewRequest(http.MethodGet, "/sponsors/platinum", nil))
+	if body := strings.TrimSpace(rr.Body.String()); body != `["aws.png"]` {
+		t.Errorf("❌ /sponsors/platinum = %s, want [\"aws.png\"]", body)
+	}
+}
@@ -0,0 +1,204 @@
+package sponsor
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"math"
+)
+
+// logoWidth leaves a margin either side of imported logos, as the display
+// crowds logos that reach the edge of their 220x220 box
+const logoWidth = 200
+
+// maxLogoPixels bounds the logos decoded to be imported or uploaded, many
+// times what a 200x220 logo needs yet a fraction of a Pi's memory
+const maxLogoPixels = 2048 * 2048
+
+// checkLogoPixels refuses a logo too large to decode
+func checkLogoPixels(config image.Config) error {
+	if config.Width*config.Height > maxLogoPixels {
+		return fmt.Errorf("%dx%d is more than %d pixels", config.Width, config.Height, maxLogoPixels)
+	}
+	return nil
+}
+
+// padLogo scales img to fit within logoWidth by imageSize, keeping its aspect
+// ratio, and centres it on a white imageSize square
+func padLogo(img image.Image) *image.RGBA {
+	b := img.Bounds()
+	w, h := fitSize(b.Dx(), b.Dy(), logoWidth, imageSize)
+
+	dst := image.NewRGBA(image.Rect(0, 0, imageSize, imageSize))
+	draw.Draw(dst, dst.Bounds(), image.White, image.Point{}, draw.Src)
+
+	offset := image.Pt((imageSize-w)/2, (imageSize-h)/2)
+	draw.Draw(dst, image.Rectangle{Min: offset, Max: offset.Add(image.Pt(w, h))}, resample(img, w, h, triangle), image.Point{}, draw.Over)
+	return dst
+}
+
+// fitSize returns the largest size within maxW by maxH with the aspect ratio of
+// w by h, at least one pixel each way
+func fitSize(w, h, maxW, maxH int) (int, int) {
+	if w <= 0 || h <= 0 {
+		return 1, 1
+	}
+	scale := math.Min(float64(maxW)/float64(w), float64(maxH)/float64(h))
+	fw := max(1, int(math.Round(float64(w)*scale)))
+	fh := max(1, int(math.Round(float64(h)*scale)))
+	return min(fw, maxW), min(fh, maxH)
+}
+
+// contribution is the weight of one source pixel in a destination pixel
+type contribution struct {
+	index  int
+	weight float64
+}
+
+// filter is a resampling kernel that is zero from support pixels out
+type filter struct {
+	kernel  func(x float64) float64
+	support float64
+}
+
+// triangle interpolates linearly, which imported logos are padded with
+var triangle = filter{
+	kernel: func(x float64) float64 {
+		return max(1-math.Abs(x), 0)
+	},
+	support: 1,
+}
+
+// catmullRom is the Catmull-Rom cubic, which keeps edges sharp with little
+// ringing, for logos scaled on request
+var catmullRom = filter{
+	kernel: func(x float64) float64 {
+		x = math.Abs(x)
+		switch {
+		case x < 1:
+			return (1.5*x-2.5)*x*x + 1
+		case x < 2:
+			return ((-0.5*x+2.5)*x-4)*x + 2
+		}
+		return 0
+	},
+	support: 2,
+}
+
+// filterWeights returns, for each of dstLen output pixels, the source pixels
+// of a row or column of srcLen that make it up. The filter is widened to the
+// scale factor when shrinking so every source pixel counts.
+func filterWeights(dstLen, srcLen int, f filter) [][]contribution {
+	scale := float64(srcLen) / float64(dstLen)
+	radius := math.Max(scale, 1)
+
+	weights := make([][]contribution, dstLen)
+	for i := range weights {
+		centre := (float64(i)+0.5)*scale - 0.5
+		lo := int(math.Floor(centre - f.support*radius))
+		hi := int(math.Ceil(centre + f.support*radius))
+
+		var total float64
+		var cs []contribution
+		for j := lo; j <= hi; j++ {
+			w := f.kernel((float64(j) - centre) / radius)
+			if w == 0 {
+				continue
+			}
+			cs = append(cs, contribution{index: min(max(j, 0), srcLen-1), weight: w})
+			total += w
+		}
+		for k := range cs {
+			cs[k].weight /= total
+		}
+		weights[i] = cs
+	}
+	return weights
+}
+
+// resample scales img to w by h with f, first across and then down. Colours
+// are averaged premultiplied so transparent pixels don't bleed into the edges.
+// Source rows are scaled across as the rows below need them and dropped once
+// none do, so only a few rows of w pixels are held besides img and the result.
+func resample(img image.Image, w, h int, f filter) *image.RGBA64 {
+	b := img.Bounds()
+	sw, sh := b.Dx(), b.Dy()
+	across := filterWeights(w, sw, f)
+	down := filterWeights(h, sh, f)
+
+	// The decoded formats read without allocating a color per pixel
+	fast, _ := img.(image.RGBA64Image)
+	src := make([][4]float32, sw)
+	rows := make(map[int][][4]float32)
+	var spare [][][4]float32 // Rows no longer needed, reused for the next ones
+	scaledRow := func(y int) [][4]float32 {
+		if row, ok := rows[y]; ok {
+			return row
+		}
+		for x := range src {
+			if fast != nil {
+				c := fast.RGBA64At(b.Min.X+x, b.Min.Y+y)
+				src[x] = [4]float32{float32(c.R), float32(c.G), float32(c.B), float32(c.A)}
+				continue
+			}
+			r, g, bl, a := img.At(b.Min.X+x, b.Min.Y+y).RGBA()
+			src[x] = [4]float32{float32(r), float32(g), float32(bl), float32(a)}
+		}
+		var row [][4]float32
+		if n := len(spare); n > 0 {
+			row, spare = spare[n-1], spare[:n-1]
+			clear(row)
+		} else {
+			row = make([][4]float32, w)
+		}
+		for x, cs := range across {
+			for _, c := range cs {
+				for k := range row[x] {
+					row[x][k] += src[c.index][k] * float32(c.weight)
+				}
+			}
+		}
+		rows[y] = row
+		return row
+	}
+
+	dst := image.NewRGBA64(image.Rect(0, 0, w, h))
+	for y, cs := range down {
+		// Rows above this window are below every later one too
+		for i, row := range rows {
+			if i < cs[0].index {
+				spare = append(spare, row)
+				delete(rows, i)
+			}
+		}
+
+		window := make([][][4]float32, len(cs))
+		for i, c := range cs {
+			window[i] = scaledRow(c.index)
+		}
+
+		for x := 0; x < w; x++ {
+			var p [4]float64
+			for i, c := range cs {
+				s := window[i][x]
+				for k := range p {
+					p[k] += float64(s[k]) * c.weight
+				}
+			}
+			a := clamp16(p[3])
+			dst.SetRGBA64(x, y, color.RGBA64{
+				R: min(clamp16(p[0]), a),
+				G: min(clamp16(p[1]), a),
+				B: min(clamp16(p[2]), a),
+				A: a,
+			})
+		}
+	}
+	return dst
+}
+
+// clamp16 rounds v to a 16-bit colour channel
+func clamp16(v float64) uint16 {
+	return uint16(math.Min(math.Max(math.Round(v), 0), 0xffff))
+}
@@ -0,0 +1,445 @@
+package sponsor
+
+import (
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"io/fs"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// RotationPath is where a sign fetches the order to show sponsor logos in
+const RotationPath = "/sponsors/rotation"
+
+// Rotation defaults match the display: three logos on screen, each replaced
+// every 10 seconds
+const (
+	defaultRotationInterval = 10
+	defaultRotationShown    = 3
+	minRotationInterval     = 5
+	maxRotationShown        = 6
+	maxRotationSlots        = 100000
+	maxRotationPlans        = 64 // Hourly plans cached before starting over
+)
+
+// Rotation is a plan of which logo fills each slot, where a slot is one logo
+// on screen for one interval. A sign shows the slots in order, replacing all
+// Shown logos on screen each interval, and starts over at the end. An hour of
+// slots gives every sponsor its share of the screen.
+type Rotation struct {
+	Sign         string          `json:"sign,omitempty"`
+	Interval     int             `json:"interval"`     // Seconds each logo stays on screen
+	Shown        int             `json:"shown"`        // Logos on screen at once
+	SlotsPerHour int             `json:"slotsPerHour"` // Slots in the hour the shares are planned over
+	Sponsors     []RotationShare `json:"sponsors"`
+	Unmet        []string        `json:"unmet,omitempty"` // Images whose minimum could not be met
+	Slots        []string        `json:"slots"`           // Image URLs in the order to show them
+}
+
+// RotationShare is what one sponsor gets on screen each hour
+type RotationShare struct {
+	Name       string `json:"name"`
+	Tier       string `json:"tier,omitempty"` // Empty for images in no tier
+	Image      string `json:"image"`
+	ImageURL   string `json:"imageUrl"`
+	Weight     int    `json:"weight"` // The tier weight times the sponsor weight
+	MinPerHour int    `json:"minPerHour,omitempty"`
+	PerHour    int    `json:"perHour"` // Slots in each hour of the plan
+}
+
+// RotationOptions are the inputs to a rotation plan
+type RotationOptions struct {
+	Sign     string   // Offsets where the sign starts in the plan
+	Tiers    []string // Tiers to include, or every tier
+	Slots    int      // Slots to return, an hour of them when 0
+	Interval int      // Seconds each logo stays on screen
+	Shown    int      // Logos on screen at once
+}
+
+// validate checks the options are within the limits of a plan
+func (o RotationOptions) validate() error {
+	if o.Interval < minRotationInterval || o.Interval > 3600 {
+		return fmt.Errorf("interval must be between %d and 3600 seconds, got %d", minRotationInterval, o.Interval)
+	}
+	if o.Shown < 1 || o.Shown > maxRotationShown {
+		return fmt.Errorf("shown must be between 1 and %d, got %d", maxRotationShown, o.Shown)
+	}
+	if o.Slots < 0 || o.Slots > maxRotationSlots {
+		return fmt.Errorf("slots must be between 0 and %d, got %d", maxRotationSlots, o.Slots)
+	}
+	return nil
+}
+
+// rotationHour is the part of a rotation plan shared by every sign: the
+// sponsors' shares and the order of an hour of slots, as indexes into sponsors
+type rotationHour struct {
+	slotsPerHour int
+	sponsors     []RotationShare
+	unmet        []string
+	order        []int
+}
+
+// PlanRotation shares an hour of slots between the sponsors of the chosen
+// tiers in proportion to their weights. Sponsors of a tier with a minimum are
+// raised to it, at the expense of everyone else's share, and the slots are
+// interleaved so each sponsor's appearances are spread evenly over the hour.
+// Images in extra join the plan at a weight of 1 when no tiers are chosen.
+// The plan is the same for every sign except where each one starts.
+func PlanRotation(manifest *Manifest, extra []string, o RotationOptions) (*Rotation, error) {
+	if err := o.validate(); err != nil {
+		return nil, err
+	}
+	hour, err := planHour(manifest, extra, o)
+	if err != nil {
+		return nil, err
+	}
+	return hour.rotation(o), nil
+}
+
+// planHour shares out and orders an hour of slots
+func planHour(manifest *Manifest, extra []string, o RotationOptions) (*rotationHour, error) {
+	r := &rotationHour{
+		slotsPerHour: max(1, 3600/o.Interval*o.Shown),
+		sponsors:     []RotationShare{},
+	}
+
+	tiers := manifest.Tiers
+	if len(o.Tiers) > 0 {
+		tiers = nil
+		for _, name := range o.Tiers {
+			t, ok := manifest.Tier(name)
+			if !ok {
+				return nil, fmt.Errorf("unknown sponsor tier %s", name)
+			}
+			tiers = append(tiers, t)
+		}
+	}
+
+	seen := make(map[string]bool)
+	for _, t := range tiers {
+		for _, s := range t.Sponsors {
+			if seen[s.Image] {
+				continue
+			}
+			seen[s.Image] = true
+			r.sponsors = append(r.sponsors, RotationShare{
+				Name:       s.DisplayName(),
+				Tier:       t.Name,
+				Image:      s.Image,
+				ImageURL:   ImagesPath + "/" + s.Image,
+				Weight:     t.DisplayWeight() * s.DisplayWeight(),
+				MinPerHour: t.MinPerHour,
+			})
+		}
+	}
+	if len(o.Tiers) == 0 {
+		for _, image := range extra {
+			if seen[image] {
+				continue
+			}
+			seen[image] = true
+			s := Sponsor{Image: image}
+			r.sponsors = append(r.sponsors, RotationShare{
+				Name:     s.DisplayName(),
+				Image:    image,
+				ImageURL: ImagesPath + "/" + image,
+				Weight:   1,
+			})
+		}
+	}
+	if len(r.sponsors) == 0 {
+		return r, nil
+	}
+
+	shares, unmet := allocate(r.sponsors, r.slotsPerHour, o.Shown)
+	for _, i := range unmet {
+		r.unmet = append(r.unmet, r.sponsors[i].Image)
+	}
+
+	r.order = interleave(shares, r.slotsPerHour, o.Shown)
+	for _, i := range r.order {
+		r.sponsors[i].PerHour++
+	}
+	return r, nil
+}
+
+// rotation returns the plan for the sign and number of slots in o
+func (h *rotationHour) rotation(o RotationOptions) *Rotation {
+	r := &Rotation{
+		Sign:         o.Sign,
+		Interval:     o.Interval,
+		Shown:        o.Shown,
+		SlotsPerHour: h.slotsPerHour,
+		Sponsors:     slices.Clone(h.sponsors),
+		Unmet:        slices.Clone(h.unmet),
+		Slots:        []string{},
+	}
+	if len(h.order) == 0 {
+		return r
+	}
+
+	slots := o.Slots
+	if slots == 0 {
+		slots = h.slotsPerHour
+	}
+	// Start on a screenful so the groups of logos are the same on every sign
+	start := int(signOffset(o.Sign)%uint32(len(h.order))) / o.Shown * o.Shown
+	for i := range slots {
+		r.Slots = append(r.Slots, h.sponsors[h.order[(start+i)%len(h.order)]].ImageURL)
+	}
+	return r
+}
+
+// rotationCache keeps the hourly plans asked for since the sponsors last
+// changed, so a request only costs picking out its slots
+type rotationCache struct {
+	mutex   sync.Mutex
+	version string
+	plans   map[string]*rotationHour
+}
+
+// get returns the plan cached under key for the sponsors at version
+func (c *rotationCache) get(version, key string) (*rotationHour, bool) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if c.version != version {
+		return nil, false
+	}
+	h, ok := c.plans[key]
+	return h, ok
+}
+
+// put caches a plan, dropping every plan for other versions of the sponsors
+func (c *rotationCache) put(version, key string, h *rotationHour) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if c.version != version || len(c.plans) >= maxRotationPlans {
+		c.version = version
+		c.plans = make(map[string]*rotationHour)
+	}
+	c.plans[key] = h
+}
+
+// allocate divides slots between the sponsors by weight. A sponsor is never
+// given more than the slots it can fill while showing once at a time, and
+// any below their minimum are raised to it with what is left shared between
+// the rest. When the minimums can't all be met they are scaled down, and the
+// sponsors short of theirs are returned as unmet.
+func allocate(sponsors []RotationShare, slots, shown int) ([]float64, []int) {
+	shares := make([]float64, len(sponsors))
+	limit := float64(slots) / float64(shown)
+
+	var unmet []int
+	minimums := make([]float64, len(sponsors))
+	var total float64
+	for i, s := range sponsors {
+		minimums[i] = min(float64(s.MinPerHour), limit)
+		if float64(s.MinPerHour) > limit {
+			unmet = append(unmet, i)
+		}
+		total += minimums[i]
+	}
+	if total > float64(slots) {
+		unmet = unmet[:0]
+		for i, s := range sponsors {
+			minimums[i] *= float64(slots) / total
+			if s.MinPerHour > 0 {
+				unmet = append(unmet, i)
+			}
+		}
+	}
+
+	// Pin sponsors to their minimum or the limit until the weighted shares of
+	// everyone else fall between the two
+	pinned := make([]bool, len(sponsors))
+	for {
+		remaining := float64(slots)
+		var weights float64
+		for i, s := range sponsors {
+			if pinned[i] {
+				remaining -= shares[i]
+			} else {
+				weights += float64(s.Weight)
+			}
+		}
+
+		// Raising sponsors to their minimum shrinks everyone else's share, so
+		// those are pinned before any are held to the limit
+		var low, high []int
+		for i, s := range sponsors {
+			if pinned[i] {
+				continue
+			}
+			shares[i] = 0
+			if weights > 0 {
+				shares[i] = max(remaining, 0) * float64(s.Weight) / weights
+			}
+			if shares[i] < minimums[i] {
+				low = append(low, i)
+			} else if shares[i] > limit {
+				high = append(high, i)
+			}
+		}
+
+		switch {
+		case len(low) > 0:
+			for _, i := range low {
+				shares[i], pinned[i] = minimums[i], true
+			}
+		case len(high) > 0:
+			for _, i := range high {
+				shares[i], pinned[i] = limit, true
+			}
+		default:
+			return shares, unmet
+		}
+	}
+}
+
+// interleave orders slots so each sponsor appears in proportion to its share
+// and as evenly spaced as possible, using smooth weighted round robin. Each
+// run of shown slots is on screen together, so a sponsor already in the run
+// is passed over unless there is no one else to show.
+func interleave(shares []float64, slots, shown int) []int {
+	var total float64
+	for _, s := range shares {
+		total += s
+	}
+
+	current := make([]float64, len(shares))
+	order := make([]int, 0, slots)
+	for range slots {
+		for i, s := range shares {
+			current[i] += s
+		}
+
+		recent := order[len(order)/shown*shown:]
+		best, fallback := -1, -1
+		for i := range shares {
+			if shares[i] <= 0 {
+				continue
+			}
+			if fallback < 0 || current[i] > current[fallback] {
+				fallback = i
+			}
+			if !slices.Contains(recent, i) && (best < 0 || current[i] > current[best]) {
+				best = i
+			}
+		}
+		if best < 0 {
+			best = fallback
+		}
+		if best < 0 {
+			break
+		}
+
+		current[best] -= total
+		order = append(order, best)
+	}
+	return order
+}
+
+// signOffset spreads signs over the plan so neighbouring signs don't show
+// the same logos at the same time
+func signOffset(sign string) uint32 {
+	h := fnv.New32a()
+	h.Write([]byte(sign))
+	return h.Sum32()
+}
+
+// HandleRotation returns the rotation plan for a sign. The query takes the
+// sign ID, the number of slots, the tiers as a comma-separated list, the
+// interval in seconds, the number of logos shown at once and whether to add
+// the images in no tier, all optional. Without tiers the plan covers every
+// tier. Hourly plans are cached until the sponsors change.
+func (m *Manager) HandleRotation(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	o := RotationOptions{
+		Sign:     q.Get("sign"),
+		Interval: defaultRotationInterval,
+		Shown:    defaultRotationShown,
+	}
+	for _, p := range []struct {
+		name  string
+		value *int
+	}{{"slots", &o.Slots}, {"interval", &o.Interval}, {"shown", &o.Shown}} {
+		if v := q.Get(p.name); v != "" {
+			n, err := strconv.Atoi(v)
+			if err != nil {
+				http.Error(w, fmt.Sprintf("invalid %s %q", p.name, v), http.StatusBadRequest)
+				return
+			}
+			*p.value = n
+		}
+	}
+	for _, v := range q["tiers"] {
+		for _, tier := range strings.Split(v, ",") {
+			if tier != "" {
+				o.Tiers = append(o.Tiers, tier)
+			}
+		}
+	}
+
+	orphans := false
+	if v := q.Get("orphans"); v != "" {
+		var err error
+		if orphans, err = strconv.ParseBool(v); err != nil {
+			http.Error(w, fmt.Sprintf("invalid orphans %q", v), http.StatusBadRequest)
+			return
+		}
+	}
+	if err := o.validate(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// The version is taken before the manifest, so a plan is never cached
+	// under a newer version than the sponsors it was made from
+	version := m.Version()
+	key := fmt.Sprintf("%s|%d|%d|%t", strings.Join(o.Tiers, ","), o.Interval, o.Shown, orphans)
+	if hour, ok := m.rotations.get(version, key); ok {
+		writeRotation(w, hour.rotation(o))
+		return
+	}
+
+	var extra []string
+	if orphans && len(o.Tiers) == 0 {
+		entries, err := fs.ReadDir(m.images, ".")
+		if err != nil {
+			logger().Error("unable to list sponsor images", "err", err)
+		}
+		for _, e := range entries {
+			if !e.IsDir() && isImage(e.Name()) {
+				extra = append(extra, e.Name())
+			}
+		}
+	}
+
+	manifest := m.Manifest()
+	for _, tier := range o.Tiers {
+		if _, ok := manifest.Tier(tier); !ok {
+			http.Error(w, "unknown sponsor tier "+tier, http.StatusNotFound)
+			return
+		}
+	}
+
+	hour, err := planHour(manifest, extra, o)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	m.rotations.put(version, key, hour)
+	writeRotation(w, hour.rotation(o))
+}
+
+// writeRotation sends a rotation plan as JSON
+func writeRotation(w http.ResponseWriter, rotation *Rotation) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(rotation); err != nil {
+		logger().Error("unable to encode sponsor rotation", "sign", rotation.Sign, "err", err)
+	}
+}
@@ -0,0 +1,203 @@
+package sponsor
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+)
+
+// getRotation requests a rotation plan from the manager
+func getRotation(t *testing.T, m *Manager, query string) (int, *Rotation) {
+	t.Helper()
+	rr := httptest.NewRecorder()
+	m.HandleRotation(rr, httptest.NewRequest(http.MethodGet, RotationPath+query, nil))
+	if rr.Code != http.StatusOK {
+		return rr.Code, nil
+	}
+	var r Rotation
+	if err := json.NewDecoder(rr.Body).Decode(&r); err != nil {
+		t.Fatalf("❌ Failed to decode rotation: %v", err)
+	}
+	return rr.Code, &r
+}
+
+func TestPlanRotation(t *testing.T) {
+	manifest, err := parseManifest([]byte(`{"tiers":[
+		{"name":"diamond","weight":4,"minPerHour":100,"sponsors":["d.png"]},
+		{"name":"platinum","weight":2,"sponsors":["p1.png","p2.png"]},
+		{"name":"gold","sponsors":["g1.png","g2.png","g3.png",{"image":"g4.png","weight":3}]}
+	]}`))
+	if err != nil {
+		t.Fatalf("❌ parseManifest() unexpected error: %v", err)
+	}
+
+	o := RotationOptions{Sign: "hall-1", Interval: 10, Shown: 3}
+	r, err := PlanRotation(manifest, []string{"d.png", "spare.png"}, o)
+	if err != nil {
+		t.Fatalf("❌ PlanRotation() unexpected error: %v", err)
+	}
+	if r.SlotsPerHour != 1080 || len(r.Slots) != 1080 {
+		t.Fatalf("❌ Plan has %d of %d slots per hour, want 1080", len(r.Slots), r.SlotsPerHour)
+	}
+
+	perHour := make(map[string]int)
+	for _, s := range r.Sponsors {
+		perHour[s.Image] = s.PerHour
+	}
+	counted := make(map[string]int)
+	for _, url := range r.Slots {
+		counted[url]++
+	}
+	for _, s := range r.Sponsors {
+		if counted[s.ImageURL] != s.PerHour {
+			t.Errorf("❌ %s is in %d slots, reported %d per hour", s.Image, counted[s.ImageURL], s.PerHour)
+		}
+	}
+
+	// Weights 4, 2, 2, 1, 1, 1, 3 and 1 for the spare image share 1080
+	// slots as 288, 144, 144, 72, 72, 72, 216 and 72
+	want := map[string]int{"d.png": 288, "p1.png": 144, "g1.png": 72, "g4.png": 216, "spare.png": 72}
+	for image, n := range want {
+		if perHour[image] < n-1 || perHour[image] > n+1 {
+			t.Errorf("❌ %s has %d slots per hour, want about %d", image, perHour[image], n)
+		}
+	}
+
+	for i := 0; i < len(r.Slots); i += r.Shown {
+		screen := r.Slots[i:min(i+r.Shown, len(r.Slots))]
+		for j, url := range screen {
+			if slices.Contains(screen[j+1:], url) {
+				t.Fatalf("❌ %s is on screen twice from slot %d: %v", url, i, screen)
+			}
+		}
+	}
+	t.Logf("✅ %d slots shared by weight with no logo on screen twice", len(r.Slots))
+
+	// Other signs follow the same plan from elsewhere in it
+	again, _ := PlanRotation(manifest, nil, RotationOptions{Sign: "hall-1", Interval: 10, Shown: 3, Tiers: []string{"diamond", "platinum", "gold"}})
+	other, _ := PlanRotation(manifest, nil, RotationOptions{Sign: "ballroom-a", Interval: 10, Shown: 3, Tiers: []string{"diamond", "platinum", "gold"}, Slots: 30})
+	if slices.Equal(again.Slots, r.Slots) {
+		t.Errorf("❌ Choosing tiers should leave out the spare image")
+	}
+	again2, _ := PlanRotation(manifest, nil, RotationOptions{Sign: "hall-1", Interval: 10, Shown: 3, Tiers: []string{"diamond", "platinum", "gold"}})
+	if !slices.Equal(again.Slots, again2.Slots) {
+		t.Errorf("❌ The same sign got two different plans")
+	}
+	if len(other.Slots) != 30 {
+		t.Errorf("❌ Another sign got %d slots, want 30", len(other.Slots))
+	}
+	doubled := append(slices.Clone(again.Slots), again.Slots...)
+	found := false
+	for i := 0; i < len(again.Slots); i += again.Shown {
+		if slices.Equal(doubled[i:i+30], other.Slots) {
+			found = true
+			break
+		}
+	}
+	if !found {
+		t.Errorf("❌ Another sign's slots are not part of the same plan")
+	} else {
+		t.Logf("✅ Signs share one plan from different starting points")
+	}
+
+	// A minimum raises the diamond sponsor above its weighted share
+	manifest.Tiers[0].MinPerHour = 500
+	r, _ = PlanRotation(manifest, nil, RotationOptions{Interval: 10, Shown: 3})
+	if r.Sponsors[0].PerHour < 360-1 || len(r.Unmet) != 1 {
+		t.Errorf("❌ A minimum above a third of the slots should be held to 360 and unmet, got %d %v", r.Sponsors[0].PerHour, r.Unmet)
+	}
+	manifest.Tiers[0].MinPerHour = 340
+	r, _ = PlanRotation(manifest, nil, RotationOptions{Interval: 10, Shown: 3})
+	if r.Sponsors[0].PerHour < 340 || len(r.Unmet) != 0 {
+		t.Errorf("❌ Diamond has %d slots per hour, want its minimum of 340", r.Sponsors[0].PerHour)
+	} else {
+		t.Logf("✅ Minimum of 340 slots per hour met")
+	}
+
+	for _, o := range []RotationOptions{{Interval: 4, Shown: 3}, {Interval: 10, Shown: 0}, {Interval: 10, Shown: 7}, {Interval: 10, Shown: 3, Slots: -1}, {Interval: 10, Shown: 3, Tiers: []string{"bronze"}}} {
+		if _, err := PlanRotation(manifest, nil, o); err == nil {
+			t.Errorf("❌ PlanRotation(%+v) expected error, got nil", o)
+		}
+	}
+}
+
+func TestHandleRotation(t *testing.T) {
+	manager, err := NewManager("")
+	if err != nil {
+		t.Fatalf("❌ Failed to create sponsor manager: %v", err)
+	}
+
+	code, r := getRotation(t, manager, "?sign=hall-1&slots=12")
+	if code != http.StatusOK || len(r.Slots) != 12 || r.Sign != "hall-1" {
+		t.Fatalf("❌ %s = %d %+v, want 12 slots", RotationPath, code, r)
+	}
+	inTiers := 0
+	for _, tier := range manager.Manifest().Tiers {
+		inTiers += len(tier.Sponsors)
+	}
+	if len(r.Sponsors) != inTiers {
+		t.Errorf("❌ Rotation without tiers has %d sponsors, want the %d in the manifest", len(r.Sponsors), inTiers)
+	}
+	if _, all := getRotation(t, manager, "?orphans=true"); all == nil || len(all.Sponsors) != len(allSponsors(t, manager)) {
+		t.Errorf("❌ Rotation with orphans = %+v, want the %d logos of /sponsors/all", all, len(allSponsors(t, manager)))
+	} else {
+		t.Logf("✅ Images in no tier only planned when asked for")
+	}
+	for _, s := range r.Sponsors {
+		if s.PerHour < s.MinPerHour {
+			t.Errorf("❌ %s has %d slots per hour, below its minimum of %d", s.Image, s.PerHour, s.MinPerHour)
+		}
+	}
+
+	code, r = getRotation(t, manager, "?tiers=diamond,platinum&interval=15&shown=2")
+	if code != http.StatusOK || len(r.Sponsors) != 3 || r.SlotsPerHour != 480 {
+		t.Errorf("❌ Rotation of two tiers = %d %+v", code, r)
+	} else {
+		t.Logf("✅ Rotation of two tiers: %d slots per hour", r.SlotsPerHour)
+	}
+
+	for query, want := range map[string]int{
+		"?tiers=bronze":  http.StatusNotFound,
+		"?slots=many":    http.StatusBadRequest,
+		"?interval=4":    http.StatusBadRequest,
+		"?shown=7":       http.StatusBadRequest,
+		"?orphans=maybe": http.StatusBadRequest,
+		"?slots=1000000": http.StatusBadRequest,
+	} {
+		if code, _ := getRotation(t, manager, query); code != want {
+			t.Errorf("❌ %s%s = %d, want %d", RotationPath, query, code, want)
+		}
+	}
+}
+
+func TestHandleRotationCache(t *testing.T) {
+	manager, err := NewManager("")
+	if err != nil {
+		t.Fatalf("❌ Failed to create sponsor manager: %v", err)
+	}
+	manifest, err := parseManifest([]byte(`{"tiers":[{"name":"gold","sponsors":["aws.png","google.png"]}]}`))
+	if err != nil {
+		t.Fatalf("❌ parseManifest() unexpected error: %v", err)
+	}
+	manager.SetManifest(manifest)
+
+	_, first := getRotation(t, manager, "?sign=hall-1")
+	_, second := getRotation(t, manager, "?sign=hall-2&slots=6")
+	if first == nil || second == nil || len(manager.rotations.plans) != 1 {
+		t.Fatalf("❌ Two signs with the same options cached %d plans, want 1", len(manager.rotations.plans))
+	}
+	if len(second.Slots) != 6 || !slices.Contains(first.Slots, second.Slots[0]) {
+		t.Errorf("❌ Cached plan gave hall-2 %v", second.Slots)
+	}
+
+	// Changing the sponsors replaces the cached plans
+	manifest, _ = parseManifest([]byte(`{"tiers":[{"name":"gold","sponsors":["aws.png","google.png","meta.png"]}]}`))
+	manager.SetManifest(manifest)
+	if _, r := getRotation(t, manager, "?sign=hall-1"); r == nil || len(r.Sponsors) != 3 {
+		t.Errorf("❌ Rotation after a manifest change = %+v, want 3 sponsors", r)
+	} else {
+		t.Logf("✅ Hourly plan cached across signs and replaced when the sponsors change")
+	}
+}
@@ -0,0 +1,350 @@
+package sponsor
+
+import (
+	"bytes"
+	"container/list"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"image"
+	"image/draw"
+	"image/png"
+	"io"
+	"io/fs"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ScaledSizes are the widths and heights images may be resized to. 220 is the
+// size of the logos, with multiples for high density displays.
+var ScaledSizes = []int{55, 110, 165, 220, 330, 440, 660, 880}
+
+// Fit modes for resized images
+const (
+	FitContain = "contain" // Scale to fit within the size, keeping the aspect ratio
+	FitPad     = "pad"     // Fit within the size and centre on white to fill it
+	FitCover   = "cover"   // Scale to fill the size and crop what overflows
+)
+
+// Bounds on resizing
+const (
+	maxScaling        = 2        // Images resized at once, each holding a decoded source
+	scaledCacheSize   = 32 << 20 // Bytes of resized images kept in memory
+	scaledCacheMaxAge = time.Hour
+)
+
+// scaledParams is a validated resize request. A zero width or height follows
+// the aspect ratio.
+type scaledParams struct {
+	width, height int
+	fit           string
+}
+
+// parseScaledParams reads w, h and fit, which must name sizes in ScaledSizes.
+// It returns false when the request has none of them.
+func parseScaledParams(r *http.Request) (scaledParams, bool, error) {
+	q := r.URL.Query()
+	if !q.Has("w") && !q.Has("h") && !q.Has("fit") {
+		return scaledParams{}, false, nil
+	}
+
+	p := scaledParams{fit: q.Get("fit")}
+	for _, d := range []struct {
+		name  string
+		value *int
+	}{{"w", &p.width}, {"h", &p.height}} {
+		v := q.Get(d.name)
+		if v == "" {
+			continue
+		}
+		n, err := strconv.Atoi(v)
+		if err != nil || !slices.Contains(ScaledSizes, n) {
+			return p, true, fmt.Errorf("%s must be one of %v, got %q", d.name, ScaledSizes, v)
+		}
+		*d.value = n
+	}
+
+	if p.width == 0 && p.height == 0 {
+		return p, true, fmt.Errorf("w or h is required")
+	}
+	switch p.fit {
+	case "":
+		p.fit = FitContain
+	case FitContain:
+	case FitPad, FitCover:
+		if p.width == 0 || p.height == 0 {
+			return p, true, fmt.Errorf("fit %s needs both w and h", p.fit)
+		}
+	default:
+		return p, true, fmt.Errorf("fit must be %s, %s or %s, got %q", FitContain, FitPad, FitCover, p.fit)
+	}
+	return p, true, nil
+}
+
+// scale resizes img as p asks
+func (p scaledParams) scale(img image.Image) image.Image {
+	b := img.Bounds()
+	sw, sh := b.Dx(), b.Dy()
+
+	switch p.fit {
+	case FitPad:
+		w, h := fitSize(sw, sh, p.width, p.height)
+		dst := image.NewRGBA(image.Rect(0, 0, p.width, p.height))
+		draw.Draw(dst, dst.Bounds(), image.White, image.Point{}, draw.Src)
+		offset := image.Pt((p.width-w)/2, (p.height-h)/2)
+		draw.Draw(dst, image.Rectangle{Min: offset, Max: offset.Add(image.Pt(w, h))}, resample(img, w, h, catmullRom), image.Point{}, draw.Over)
+		return dst
+
+	case FitCover:
+		// Crop the source to the target aspect ratio, then scale what's left
+		cw, ch := sw, sh
+		if sw*p.height > sh*p.width {
+			cw = max(1, sh*p.width/p.height)
+		} else {
+			ch = max(1, sw*p.height/p.width)
+		}
+		origin := b.Min.Add(image.Pt((sw-cw)/2, (sh-ch)/2))
+		crop := image.Rectangle{Min: origin, Max: origin.Add(image.Pt(cw, ch))}
+		if sub, ok := img.(interface {
+			SubImage(image.Rectangle) image.Image
+		}); ok {
+			return resample(sub.SubImage(crop), p.width, p.height, catmullRom)
+		}
+		copied := image.NewRGBA(image.Rect(0, 0, cw, ch))
+		draw.Draw(copied, copied.Bounds(), img, crop.Min, draw.Src)
+		return resample(copied, p.width, p.height, catmullRom)
+	}
+
+	// An open side is still held to the sizes images are resized to
+	maxW, maxH := p.width, p.height
+	if maxW == 0 {
+		maxW = slices.Max(ScaledSizes)
+	}
+	if maxH == 0 {
+		maxH = slices.Max(ScaledSizes)
+	}
+	w, h := fitSize(sw, sh, maxW, maxH)
+	return resample(img, w, h, catmullRom)
+}
+
+// scaledCache keeps the most recently used resized images and sanitized SVGs
+// up to a total size, and makes each of them once however many requests want
+// it at the same time
+type scaledCache struct {
+	mutex   sync.Mutex
+	limit   int
+	size    int
+	entries map[string]*list.Element
+	order   *list.List // Most recently used first
+	pending map[string]*scaledCall
+	slots   chan struct{} // Held while making an image
+}
+
+// scaledCall is an image being made for the requests waiting on done
+type scaledCall struct {
+	done chan struct{}
+	data []byte
+	err  error
+}
+
+// scaledEntry is one resized image in the cache
+type scaledEntry struct {
+	key  string
+	data []byte
+}
+
+// newScaledCache returns an empty cache holding up to limit bytes
+func newScaledCache(limit int) *scaledCache {
+	return &scaledCache{
+		limit:   limit,
+		entries: make(map[string]*list.Element),
+		order:   list.New(),
+		pending: make(map[string]*scaledCall),
+		slots:   make(chan struct{}, maxScaling),
+	}
+}
+
+// do returns the image cached under key, or makes and caches it. Requests
+// for an image already being made wait for it, and only maxScaling images are
+// made at once.
+func (c *scaledCache) do(key string, build func() ([]byte, error)) ([]byte, error) {
+	if data, ok := c.get(key); ok {
+		return data, nil
+	}
+
+	c.mutex.Lock()
+	if call, ok := c.pending[key]; ok {
+		c.mutex.Unlock()
+		<-call.done
+		return call.data, call.err
+	}
+	call := &scaledCall{done: make(chan struct{})}
+	c.pending[key] = call
+	c.mutex.Unlock()
+
+	c.slots <- struct{}{}
+	call.data, call.err = build()
+	<-c.slots
+	if call.err == nil {
+		c.put(key, call.data)
+	}
+
+	c.mutex.Lock()
+	delete(c.pending, key)
+	c.mutex.Unlock()
+	close(call.done)
+	return call.data, call.err
+}
+
+// get returns the image cached under key
+func (c *scaledCache) get(key string) ([]byte, bool) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	e, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+	c.order.MoveToFront(e)
+	return e.Value.(*scaledEntry).data, true
+}
+
+// put caches data under key, evicting the least recently used images to stay
+// within the limit. Images larger than the whole cache aren't kept.
+func (c *scaledCache) put(key string, data []byte) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if len(data) > c.limit {
+		return
+	}
+	if e, ok := c.entries[key]; ok {
+		c.size -= len(e.Value.(*scaledEntry).data)
+		c.order.Remove(e)
+	}
+
+	c.entries[key] = c.order.PushFront(&scaledEntry{key: key, data: data})
+	c.size += len(data)
+	for c.size > c.limit {
+		e := c.order.Back()
+		entry := e.Value.(*scaledEntry)
+		c.order.Remove(e)
+		delete(c.entries, entry.key)
+		c.size -= len(entry.data)
+	}
+}
+
+// sourceKey identifies the source file of an image, which changes when an
+// image in the images directory is replaced
+func sourceKey(name string, info fs.FileInfo) string {
+	return fmt.Sprintf("%s|%d|%d", name, info.ModTime().UnixNano(), info.Size())
+}
+
+// scaledKey identifies a resized image by its source file and the resize
+// parameters
+func scaledKey(name string, info fs.FileInfo, p scaledParams) string {
+	return fmt.Sprintf("%s|%dx%d|%s", sourceKey(name, info), p.width, p.height, p.fit)
+}
+
+// scaledImage returns the image called name resized as p asks, from the cache
+// when it has been resized before, and the key it is cached under
+func (m *Manager) scaledImage(name string, p scaledParams) ([]byte, string, error) {
+	info, err := fs.Stat(m.images, name)
+	if err != nil || info.IsDir() || !isImage(name) || isSVG(name) {
+		return nil, "", fs.ErrNotExist
+	}
+
+	key := scaledKey(name, info, p)
+	data, err := m.scaled.do(key, func() ([]byte, error) {
+		return m.scale(name, p)
+	})
+	if err != nil {
+		return nil, "", err
+	}
+	return data, key, nil
+}
+
+// scale decodes the image called name and resizes it as p asks
+func (m *Manager) scale(name string, p scaledParams) ([]byte, error) {
+	f, err := m.images.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var buf bytes.Buffer
+	config, _, err := image.DecodeConfig(io.TeeReader(f, &buf))
+	if err != nil {
+		return nil, fmt.Errorf("unable to decode %s: %w", name, err)
+	}
+	if err := checkLogoPixels(config); err != nil {
+		return nil, fmt.Errorf("%s is %w", name, err)
+	}
+	img, _, err := image.Decode(io.MultiReader(&buf, f))
+	if err != nil {
+		return nil, fmt.Errorf("unable to decode %s: %w", name, err)
+	}
+
+	var out bytes.Buffer
+	if err := png.Encode(&out, p.scale(img)); err != nil {
+		return nil, err
+	}
+	logger().Debug("sponsor image resized", "image", name, "width", p.width, "height", p.height, "fit", p.fit, "bytes", out.Len())
+	return out.Bytes(), nil
+}
+
+// HandleImage serves a sponsor image as it is, or resized when the query has
+// w, h or fit. Resized images are PNGs cached in memory and revalidated by an
+// ETag derived from the source file and the parameters. SVGs are always served
+// sanitized, and as they scale themselves the parameters only have to be valid.
+func (m *Manager) HandleImage(w http.ResponseWriter, r *http.Request) {
+	p, resize, err := parseScaledParams(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	name := strings.TrimPrefix(r.URL.Path, ImagesPath+"/")
+	if !resize && !isSVG(name) {
+		http.StripPrefix(ImagesPath, m.ImageHandler()).ServeHTTP(w, r)
+		return
+	}
+	if name == "" || strings.Contains(name, "/") {
+		http.NotFound(w, r)
+		return
+	}
+
+	contentType := "image/png"
+	var data []byte
+	var key string
+	if isSVG(name) {
+		contentType = "image/svg+xml"
+		data, key, err = m.svgImage(name)
+	} else {
+		data, key, err = m.scaledImage(name, p)
+	}
+	if errors.Is(err, fs.ErrNotExist) {
+		http.NotFound(w, r)
+		return
+	}
+	if err != nil {
+		logger().Warn("unable to prepare sponsor image", "image", name, "err", err)
+		http.Error(w, "unable to prepare image", http.StatusUnprocessableEntity)
+		return
+	}
+
+	h := fnv.New64a()
+	h.Write([]byte(key))
+	etag := fmt.Sprintf(`"%x"`, h.Sum64())
+	w.Header().Set("ETag", etag)
+	w.Header().Set("Cache-Control", fmt.Sprintf("public, max-age=%d", int(scaledCacheMaxAge.Seconds())))
+	if contentType == "image/svg+xml" {
+		w.Header().Set("Content-Security-Policy", svgContentSecurityPolicy)
+		w.Header().Set("X-Content-Type-Options", "nosniff")
+	}
+	w.Header().Set("Content-Type", contentType)
+	// ServeContent answers If-None-Match against the ETag, HEAD and ranges
+	http.ServeContent(w, r, "", time.Time{}, bytes.NewReader(data))
+}
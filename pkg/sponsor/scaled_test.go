@@ -0,0 +1,204 @@
+package sponsor
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/png"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// getImage requests a sponsor image from the manager
+func getImage(t *testing.T, m *Manager, path string, header http.Header) *httptest.ResponseRecorder {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodGet, ImagesPath+path, nil)
+	for k, v := range header {
+		req.Header[k] = v
+	}
+	rr := httptest.NewRecorder()
+	m.HandleImage(rr, req)
+	return rr
+}
+
+func TestHandleImage(t *testing.T) {
+	dir := t.TempDir()
+	writeTestImage(t, filepath.Join(dir, "wide.png"), 440)
+	manager, err := NewManager(dir)
+	if err != nil {
+		t.Fatalf("❌ Failed to create sponsor manager: %v", err)
+	}
+
+	rr := getImage(t, manager, "/wide.png", nil)
+	if rr.Code != http.StatusOK || rr.Header().Get("ETag") != "" {
+		t.Fatalf("❌ Original image = %d, want 200 without an ETag", rr.Code)
+	}
+
+	tests := []struct {
+		name          string
+		query         string
+		width, height int
+	}{
+		{name: "width only", query: "?w=110", width: 110, height: 110},
+		{name: "height only", query: "?h=220", width: 220, height: 220},
+		{name: "contain", query: "?w=330&h=110", width: 110, height: 110},
+		{name: "pad", query: "?w=330&h=110&fit=pad", width: 330, height: 110},
+		{name: "cover", query: "?w=330&h=110&fit=cover", width: 330, height: 110},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rr := getImage(t, manager, "/wide.png"+tt.query, nil)
+			if rr.Code != http.StatusOK {
+				t.Fatalf("❌ %s = %d, want 200: %s", tt.query, rr.Code, rr.Body.String())
+			}
+			img, err := png.Decode(bytes.NewReader(rr.Body.Bytes()))
+			if err != nil {
+				t.Fatalf("❌ Failed to decode resized image: %v", err)
+			}
+			if b := img.Bounds(); b.Dx() != tt.width || b.Dy() != tt.height {
+				t.Errorf("❌ %s is %dx%d, want %dx%d", tt.query, b.Dx(), b.Dy(), tt.width, tt.height)
+			}
+			r, g, b, _ := img.At(img.Bounds().Dx()/2, img.Bounds().Dy()/2).RGBA()
+			if r>>8 != 0x20 || g>>8 != 0x54 || b>>8 != 0x93 {
+				t.Errorf("❌ %s centre is %02x%02x%02x, want 205493", tt.query, r>>8, g>>8, b>>8)
+			}
+		})
+	}
+
+	rr = getImage(t, manager, "/wide.png?w=220", nil)
+	etag := rr.Header().Get("ETag")
+	if etag == "" || !strings.Contains(rr.Header().Get("Cache-Control"), "max-age") {
+		t.Fatalf("❌ Resized image headers = %v, want an ETag and max-age", rr.Header())
+	}
+	rr = getImage(t, manager, "/wide.png?w=220", http.Header{"If-None-Match": {etag}})
+	if rr.Code != http.StatusNotModified {
+		t.Errorf("❌ Request with a matching ETag = %d, want 304", rr.Code)
+	} else {
+		t.Logf("✅ Resized image revalidated by ETag %s", etag)
+	}
+
+	for name, match := range map[string]string{
+		"list":     `"0000", ` + etag,
+		"weak":     "W/" + etag,
+		"wildcard": "*",
+	} {
+		if rr = getImage(t, manager, "/wide.png?w=220", http.Header{"If-None-Match": {match}}); rr.Code != http.StatusNotModified {
+			t.Errorf("❌ If-None-Match %s = %d, want 304", name, rr.Code)
+		}
+	}
+	// A tag that only contains the ETag doesn't match it
+	if rr = getImage(t, manager, "/wide.png?w=220", http.Header{"If-None-Match": {`"x` + etag[1:]}}); rr.Code != http.StatusOK {
+		t.Errorf("❌ If-None-Match with a longer tag = %d, want 200", rr.Code)
+	}
+
+	// Replacing the image changes the ETag rather than serving the cached one
+	writeTestImage(t, filepath.Join(dir, "wide.png"), 330)
+	later := time.Now().Add(time.Hour)
+	if err := os.Chtimes(filepath.Join(dir, "wide.png"), later, later); err != nil {
+		t.Fatalf("❌ Failed to set modification time: %v", err)
+	}
+	rr = getImage(t, manager, "/wide.png?w=220", http.Header{"If-None-Match": {etag}})
+	if rr.Code != http.StatusOK || rr.Header().Get("ETag") == etag {
+		t.Errorf("❌ Replaced image = %d with ETag %s, want 200 and a new ETag", rr.Code, rr.Header().Get("ETag"))
+	}
+
+	for query, want := range map[string]int{
+		"/wide.png?w=100":             http.StatusBadRequest,
+		"/wide.png?w=big":             http.StatusBadRequest,
+		"/wide.png?fit=pad":           http.StatusBadRequest,
+		"/wide.png?w=220&fit=cover":   http.StatusBadRequest,
+		"/wide.png?w=220&fit=stretch": http.StatusBadRequest,
+		"/missing.png?w=220":          http.StatusNotFound,
+		"/../wide.png?w=220":          http.StatusNotFound,
+	} {
+		if rr := getImage(t, manager, query, nil); rr.Code != want {
+			t.Errorf("❌ %s%s = %d, want %d", ImagesPath, query, rr.Code, want)
+		}
+	}
+}
+
+func TestScaledCache(t *testing.T) {
+	c := newScaledCache(10)
+	c.put("a", make([]byte, 4))
+	c.put("b", make([]byte, 4))
+	c.get("a")
+	c.put("c", make([]byte, 4))
+	c.put("huge", make([]byte, 11))
+
+	if _, ok := c.get("b"); ok {
+		t.Errorf("❌ Least recently used image was kept")
+	}
+	for _, key := range []string{"a", "c"} {
+		if _, ok := c.get(key); !ok {
+			t.Errorf("❌ %s was evicted", key)
+		}
+	}
+	if _, ok := c.get("huge"); ok || c.size != 8 {
+		t.Errorf("❌ Cache holds %d bytes, want 8 without the oversized image", c.size)
+	} else {
+		t.Logf("✅ Cache held to %d of %d bytes", c.size, c.limit)
+	}
+}
+
+func TestScaledCacheDo(t *testing.T) {
+	c := newScaledCache(1 << 20)
+	var mutex sync.Mutex
+	builds, running, most := 0, 0, 0
+	build := func() ([]byte, error) {
+		mutex.Lock()
+		builds++
+		running++
+		most = max(most, running)
+		mutex.Unlock()
+		time.Sleep(20 * time.Millisecond)
+		mutex.Lock()
+		running--
+		mutex.Unlock()
+		return []byte("png"), nil
+	}
+
+	var wg sync.WaitGroup
+	for i := range 12 {
+		wg.Go(func() {
+			key := fmt.Sprintf("image-%d", i%4)
+			if data, err := c.do(key, build); err != nil || string(data) != "png" {
+				t.Errorf("❌ do(%s) = %q, %v", key, data, err)
+			}
+		})
+	}
+	wg.Wait()
+
+	if builds != 4 {
+		t.Errorf("❌ Built %d images for 4 keys", builds)
+	}
+	if most > maxScaling {
+		t.Errorf("❌ Built %d images at once, want at most %d", most, maxScaling)
+	} else {
+		t.Logf("✅ 12 requests built 4 images, %d at a time", most)
+	}
+}
+
+func TestScaledImageTooLarge(t *testing.T) {
+	dir := t.TempDir()
+	img := image.NewGray(image.Rect(0, 0, 2049, 2048))
+	f, err := os.Create(filepath.Join(dir, "huge.png"))
+	if err != nil {
+		t.Fatalf("❌ Failed to create image: %v", err)
+	}
+	png.Encode(f, img)
+	f.Close()
+
+	manager, err := NewManager(dir)
+	if err != nil {
+		t.Fatalf("❌ Failed to create sponsor manager: %v", err)
+	}
+	if rr := getImage(t, manager, "/huge.png?w=220", nil); rr.Code != http.StatusUnprocessableEntity {
+		t.Errorf("❌ Oversized source = %d, want 422", rr.Code)
+	}
+}
@@ -12,16 +12,14 @@ import (
 
 func TestSponsorEndpoints(t *testing.T) {
 	// Create a new manager
-	manager, err := NewManager()
+	manager, err := NewManager("")
 	if err != nil {
 		t.Fatalf("❌ Failed to create sponsor manager: %v", err)
 	}
 
 	// Create router for testing
 	mux := http.NewServeMux()
-	mux.HandleFunc("/sponsors/gold/", manager.HandleGold)
-	mux.HandleFunc("/sponsors/platinum/", manager.HandlePlatinum)
-	mux.HandleFunc("/sponsors/diamond/", manager.HandleDiamond)
+	mux.HandleFunc("/sponsors/", manager.HandleTier)
 	mux.Handle("/sponsors/images/", http.StripPrefix("/sponsors/images/", manager.ImageHandler()))
 
 	// Create test server
@@ -35,19 +33,22 @@ func TestSponsorEndpoints(t *testing.T) {
 	}
 	defer os.RemoveAll(tempDir)
 
-	// Test Gold sponsors endpoint
-	t.Run("Gold Sponsors", func(t *testing.T) {
-		testSponsorEndpoint(t, server.URL+"/sponsors/gold/", server.URL+"/sponsors/images/", tempDir)
-	})
-
-	// Test Platinum sponsors endpoint
-	t.Run("Platinum Sponsors", func(t *testing.T) {
-		testSponsorEndpoint(t, server.URL+"/sponsors/platinum/", server.URL+"/sponsors/images/", tempDir)
-	})
+	// Test every tier in the embedded manifest
+	for _, tier := range manager.Manifest().Names() {
+		t.Run(tier, func(t *testing.T) {
+			testSponsorEndpoint(t, server.URL+"/sponsors/"+tier+"/", server.URL+"/sponsors/images/", tempDir)
+		})
+	}
 
-	// Test Diamond sponsors endpoint
-	t.Run("Diamond Sponsors", func(t *testing.T) {
-		testSponsorEndpoint(t, server.URL+"/sponsors/diamond/", server.URL+"/sponsors/images/", tempDir)
+	t.Run("Unknown tier", func(t *testing.T) {
+		resp, err := http.Get(server.URL + "/sponsors/bronze")
+		if err != nil {
+			t.Fatalf("❌ Failed to request unknown tier: %v", err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusNotFound {
+			t.Errorf("❌ Expected status 404 for unknown tier, got %v", resp.Status)
+		}
 	})
 }
 
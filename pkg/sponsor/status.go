@@ -0,0 +1,175 @@
+package sponsor
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"net/http"
+	"slices"
+	"time"
+)
+
+// StatusPath is where the result of checking the sponsor assets is served
+const StatusPath = "/sponsors/status"
+
+// Problem kinds found by a check
+const (
+	ProblemMissing     = "missing"     // A tier lists an image that doesn't exist
+	ProblemUndecodable = "undecodable" // A listed image isn't a valid image
+	ProblemWrongSize   = "wrong-size"  // A listed image isn't 220x220, or an SVG has no size
+	ProblemOrphaned    = "orphaned"    // An image belongs to no tier
+)
+
+// Problem severities. Errors show up on the wall as broken or distorted logos,
+// warnings don't.
+const (
+	SeverityError   = "error"
+	SeverityWarning = "warning"
+)
+
+// Problem is one issue with the sponsor assets
+type Problem struct {
+	Severity string `json:"severity"`
+	Kind     string `json:"kind"`
+	Tier     string `json:"tier,omitempty"` // Empty for orphaned images
+	Image    string `json:"image"`
+	Detail   string `json:"detail,omitempty"`
+}
+
+// Status is the result of checking the manifest against the images
+type Status struct {
+	OK       bool      `json:"ok"` // No errors, though there may be warnings
+	Checked  time.Time `json:"checked"`
+	Tiers    int       `json:"tiers"`
+	Sponsors int       `json:"sponsors"`
+	Images   int       `json:"images"`
+	Problems []Problem `json:"problems"`
+}
+
+// Errors returns the problems that are errors
+func (st Status) Errors() []Problem {
+	var errs []Problem
+	for _, p := range st.Problems {
+		if p.Severity == SeverityError {
+			errs = append(errs, p)
+		}
+	}
+	return errs
+}
+
+// Err summarizes the errors, nil when there are none
+func (st Status) Err() error {
+	errs := st.Errors()
+	if len(errs) == 0 {
+		return nil
+	}
+	return fmt.Errorf("%d sponsor asset errors, first: %s %s in tier %s", len(errs), errs[0].Kind, errs[0].Image, errs[0].Tier)
+}
+
+// check verifies that every sponsor in manifest has a decodable 220x220 image
+// and finds the images that belong to no tier
+func check(manifest *Manifest, images fs.FS) Status {
+	st := Status{
+		Checked:  time.Now(),
+		Tiers:    len(manifest.Tiers),
+		Problems: []Problem{},
+	}
+
+	listed := make(map[string]bool)
+	for _, t := range manifest.Tiers {
+		for _, s := range t.Sponsors {
+			st.Sponsors++
+			listed[s.Image] = true
+			if p, ok := checkImage(images, s.Image); !ok {
+				p.Tier = t.Name
+				st.Problems = append(st.Problems, p)
+			}
+		}
+	}
+
+	entries, err := fs.ReadDir(images, ".")
+	if err != nil {
+		logger().Error("unable to list sponsor images", "err", err)
+	}
+	for _, e := range entries {
+		if e.IsDir() || !isImage(e.Name()) {
+			continue
+		}
+		st.Images++
+		if !listed[e.Name()] {
+			st.Problems = append(st.Problems, Problem{Severity: SeverityWarning, Kind: ProblemOrphaned, Image: e.Name()})
+		}
+	}
+
+	st.OK = len(st.Errors()) == 0
+	return st
+}
+
+// checkImage opens and decodes the header of one listed image
+func checkImage(images fs.FS, name string) (Problem, bool) {
+	f, err := images.Open(name)
+	if err != nil {
+		// A broken file in the images directory is hidden rather than served
+		if d, ok := images.(*imageDir); ok {
+			if err := d.rejected(name); err != nil {
+				return Problem{Severity: SeverityError, Kind: ProblemUndecodable, Image: name, Detail: err.Error()}, false
+			}
+		}
+		return Problem{Severity: SeverityError, Kind: ProblemMissing, Image: name}, false
+	}
+	defer f.Close()
+
+	config, format, err := decodeImageConfig(f, name)
+	if err != nil {
+		return Problem{Severity: SeverityError, Kind: ProblemUndecodable, Image: name, Detail: err.Error()}, false
+	}
+
+	// An SVG is scaled to fit, as long as it says how big it is
+	if format == "svg" {
+		if config.Width == 0 || config.Height == 0 {
+			return Problem{Severity: SeverityError, Kind: ProblemWrongSize, Image: name, Detail: "no width, height or viewBox"}, false
+		}
+		return Problem{}, true
+	}
+
+	if config.Width != imageSize || config.Height != imageSize {
+		return Problem{Severity: SeverityError, Kind: ProblemWrongSize, Image: name,
+			Detail: fmt.Sprintf("%dx%d, want %dx%d", config.Width, config.Height, imageSize, imageSize)}, false
+	}
+	return Problem{}, true
+}
+
+// logStatus logs each error and a summary of the orphaned images
+func logStatus(st Status) {
+	var orphans []string
+	for _, p := range st.Problems {
+		if p.Kind == ProblemOrphaned {
+			orphans = append(orphans, p.Image)
+			continue
+		}
+		logger().Warn("sponsor asset problem", "kind", p.Kind, "tier", p.Tier, "image", p.Image, "detail", p.Detail)
+	}
+	if len(orphans) > 0 {
+		slices.Sort(orphans)
+		logger().Info("sponsor images in no tier", "count", len(orphans), "images", orphans)
+	}
+}
+
+// Check verifies manifest against the served images without applying it
+func (m *Manager) Check(manifest *Manifest) Status {
+	return check(manifest, m.images)
+}
+
+// Status checks the current manifest against the images as they are now
+func (m *Manager) Status() Status {
+	return m.Check(m.Manifest())
+}
+
+// HandleStatus returns the result of checking the sponsor assets. The check
+// runs on each request so images changed on disk are reflected.
+func (m *Manager) HandleStatus(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(m.Status()); err != nil {
+		logger().Error("unable to encode sponsor status", "err", err)
+	}
+}
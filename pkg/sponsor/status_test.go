@@ -0,0 +1,88 @@
+package sponsor
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestEmbeddedSponsorsStatus(t *testing.T) {
+	manager, err := NewManager("")
+	if err != nil {
+		t.Fatalf("❌ Failed to create sponsor manager: %v", err)
+	}
+
+	st := manager.Status()
+	if !st.OK {
+		t.Errorf("❌ Embedded sponsors have errors: %v", st.Errors())
+	} else {
+		t.Logf("✅ %d sponsors in %d tiers, %d images", st.Sponsors, st.Tiers, st.Images)
+	}
+}
+
+func TestSponsorsStatus(t *testing.T) {
+	dir := t.TempDir()
+	writeTestImage(t, filepath.Join(dir, "good.png"), 220)
+	writeTestImage(t, filepath.Join(dir, "small.png"), 100)
+	writeTestImage(t, filepath.Join(dir, "spare.png"), 220)
+	if err := os.WriteFile(filepath.Join(dir, "broken.png"), []byte("not an image"), 0600); err != nil {
+		t.Fatalf("❌ Failed to write fixture: %v", err)
+	}
+
+	manager, err := NewManager(dir)
+	if err != nil {
+		t.Fatalf("❌ NewManager() unexpected error: %v", err)
+	}
+
+	manifest, err := parseManifest([]byte(`{"tiers":[
+		{"name":"gold","sponsors":["good.png","small.png","typo.png"]},
+		{"name":"silver","sponsors":["broken.png"]}
+	]}`))
+	if err != nil {
+		t.Fatalf("❌ parseManifest() unexpected error: %v", err)
+	}
+
+	if st := manager.Check(manifest); st.OK || len(manager.Status().Errors()) != 0 {
+		t.Errorf("❌ Check() should report errors without applying the manifest")
+	}
+
+	st := manager.SetManifest(manifest)
+	if st.OK || st.Err() == nil {
+		t.Errorf("❌ SetManifest() status is OK, want errors")
+	}
+
+	want := map[string]Problem{
+		"small.png":  {Severity: SeverityError, Kind: ProblemWrongSize, Tier: "gold"},
+		"typo.png":   {Severity: SeverityError, Kind: ProblemMissing, Tier: "gold"},
+		"broken.png": {Severity: SeverityError, Kind: ProblemUndecodable, Tier: "silver"},
+		"spare.png":  {Severity: SeverityWarning, Kind: ProblemOrphaned},
+	}
+	got := make(map[string]Problem)
+	for _, p := range st.Problems {
+		got[p.Image] = p
+	}
+	for image, w := range want {
+		p, ok := got[image]
+		if !ok || p.Severity != w.Severity || p.Kind != w.Kind || p.Tier != w.Tier {
+			t.Errorf("❌ Problem for %s = %+v, want %+v", image, p, w)
+		}
+	}
+	if _, ok := got["good.png"]; ok {
+		t.Errorf("❌ good.png reported as %+v", got["good.png"])
+	}
+
+	rr := httptest.NewRecorder()
+	manager.HandleStatus(rr, httptest.NewRequest(http.MethodGet, StatusPath, nil))
+	var served Status
+	if err := json.NewDecoder(rr.Body).Decode(&served); err != nil {
+		t.Fatalf("❌ Failed to decode %s: %v", StatusPath, err)
+	}
+	if served.OK || len(served.Errors()) != 3 || served.Sponsors != 4 {
+		t.Errorf("❌ %s = %+v, want 3 errors across 4 sponsors", StatusPath, served)
+	} else {
+		t.Logf("✅ %s reports %d problems", StatusPath, len(served.Problems))
+	}
+}
@@ -0,0 +1,314 @@
+package sponsor
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"image"
+	"io"
+	"io/fs"
+	"math"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// svgContentSecurityPolicy stops a sponsor SVG opened on its own from running
+// anything or loading anything that slipped past sanitizing
+const svgContentSecurityPolicy = "default-src 'none'; style-src 'unsafe-inline'; img-src data:"
+
+// maxSVGSize limits the SVGs that are parsed
+const maxSVGSize = 2 << 20
+
+// svgDropped are elements removed from sponsor SVGs along with everything in
+// them. Scripts and embedded documents can run code, and animations can set an
+// href or event handler after sanitizing.
+var svgDropped = map[string]bool{
+	"script":           true,
+	"foreignobject":    true,
+	"iframe":           true,
+	"embed":            true,
+	"object":           true,
+	"audio":            true,
+	"video":            true,
+	"handler":          true,
+	"listener":         true,
+	"set":              true,
+	"animate":          true,
+	"animatemotion":    true,
+	"animatetransform": true,
+	"animatecolor":     true,
+}
+
+// svgImageData are the data URLs an SVG may embed, as they can't reference
+// anything in turn
+var svgImageData = []string{"data:image/png", "data:image/jpeg", "data:image/gif"}
+
+// isSVG reports whether the file name is an SVG
+func isSVG(name string) bool {
+	return strings.EqualFold(path.Ext(name), ".svg")
+}
+
+// sanitizeSVG returns the SVG in data with scripts, event handlers and
+// references to anything outside the document removed, along with its size.
+// Comments, processing instructions and DOCTYPEs are dropped too, and anything
+// that isn't well-formed XML with an svg root element is refused.
+func sanitizeSVG(data []byte) ([]byte, image.Config, error) {
+	if len(data) > maxSVGSize {
+		return nil, image.Config{}, fmt.Errorf("SVG is larger than %d bytes", maxSVGSize)
+	}
+
+	d := xml.NewDecoder(bytes.NewReader(data))
+	var out bytes.Buffer
+	var config image.Config
+	var open []string // Names of the elements written and not yet closed
+	skip := 0         // Depth within a dropped element
+	root := false
+	inStyle := false     // Within a style element, whose text is held back
+	var css bytes.Buffer // Text of the style element, checked as a whole
+
+	for {
+		tok, err := d.RawToken()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, image.Config{}, fmt.Errorf("unable to parse SVG: %w", err)
+		}
+
+		switch t := tok.(type) {
+		case xml.StartElement:
+			local := strings.ToLower(t.Name.Local)
+			// Stylesheets are checked as a whole, so nothing may split them
+			if skip > 0 || svgDropped[local] || inStyle {
+				skip++
+				continue
+			}
+			if !root {
+				if t.Name.Local != "svg" {
+					return nil, image.Config{}, fmt.Errorf("root element is %s, not svg", t.Name.Local)
+				}
+				root = true
+				config = svgSize(t.Attr)
+			} else if len(open) == 0 {
+				return nil, image.Config{}, errors.New("SVG has more than one root element")
+			}
+
+			name := qualifiedName(t.Name)
+			out.WriteString("<" + name)
+			for _, a := range t.Attr {
+				if !safeSVGAttr(a) {
+					continue
+				}
+				out.WriteString(" " + qualifiedName(a.Name) + `="`)
+				xml.EscapeText(&out, []byte(a.Value))
+				out.WriteString(`"`)
+			}
+			out.WriteString(">")
+			open = append(open, name)
+			if local == "style" {
+				inStyle = true
+				css.Reset()
+			}
+
+		case xml.EndElement:
+			if skip > 0 {
+				skip--
+				continue
+			}
+			if len(open) == 0 || qualifiedName(t.Name) != open[len(open)-1] {
+				return nil, image.Config{}, fmt.Errorf("unexpected end element %s", qualifiedName(t.Name))
+			}
+			// Stylesheets can import or point at other documents
+			if inStyle && !externalCSS(css.String()) {
+				xml.EscapeText(&out, css.Bytes())
+			}
+			inStyle = false
+			out.WriteString("</" + open[len(open)-1] + ">")
+			open = open[:len(open)-1]
+
+		case xml.CharData:
+			if skip > 0 || len(open) == 0 {
+				continue
+			}
+			if inStyle {
+				css.Write(t)
+				continue
+			}
+			xml.EscapeText(&out, t)
+
+		case xml.Directive:
+			// Illustrator declares its namespaces as entities in the DOCTYPE
+			for name, value := range svgEntities(t) {
+				if d.Entity == nil {
+					d.Entity = make(map[string]string)
+				}
+				d.Entity[name] = value
+			}
+		}
+	}
+
+	if !root {
+		return nil, image.Config{}, errors.New("no svg element")
+	}
+	if len(open) > 0 || skip > 0 {
+		return nil, image.Config{}, errors.New("unexpected end of SVG")
+	}
+	return out.Bytes(), config, nil
+}
+
+// svgEntity matches an internal entity declaration in a DOCTYPE
+var svgEntity = regexp.MustCompile(`<!ENTITY\s+([A-Za-z_][\w.-]*)\s+"([^"&%<]*)"\s*>`)
+
+// svgEntities returns the plain text entities declared in a DOCTYPE. Entities
+// that refer to other entities or files are left out, so none can expand into
+// more than its own text.
+func svgEntities(directive xml.Directive) map[string]string {
+	entities := make(map[string]string)
+	for _, m := range svgEntity.FindAllSubmatch(directive, -1) {
+		entities[string(m[1])] = string(m[2])
+	}
+	return entities
+}
+
+// qualifiedName writes a name as it appeared, with its prefix
+func qualifiedName(n xml.Name) string {
+	if n.Space == "" {
+		return n.Local
+	}
+	return n.Space + ":" + n.Local
+}
+
+// safeSVGAttr reports whether an attribute can be kept: not an event handler,
+// not a link or URL outside the document and not a script URL
+func safeSVGAttr(a xml.Attr) bool {
+	local := strings.ToLower(a.Name.Local)
+	value := strings.ToLower(strings.TrimSpace(a.Value))
+	if strings.HasPrefix(local, "on") {
+		return false
+	}
+	if strings.Contains(strings.ReplaceAll(value, " ", ""), "javascript:") {
+		return false
+	}
+	if local == "href" || local == "src" {
+		if strings.HasPrefix(value, "#") {
+			return true
+		}
+		for _, prefix := range svgImageData {
+			if strings.HasPrefix(value, prefix) {
+				return true
+			}
+		}
+		return false
+	}
+	return !externalCSS(a.Value)
+}
+
+// cssComment matches a CSS comment, which may split a keyword
+var cssComment = regexp.MustCompile(`(?s)/\*.*?(\*/|$)`)
+
+// externalCSS reports whether CSS or an attribute value imports a stylesheet
+// or uses a url() other than a reference within the document. CSS escapes
+// can spell out either, so anything with a backslash counts as external.
+func externalCSS(css string) bool {
+	if strings.Contains(css, `\`) {
+		return true
+	}
+	lower := strings.ToLower(cssComment.ReplaceAllString(css, ""))
+	if strings.Contains(lower, "@import") || strings.Contains(lower, "image-set(") {
+		return true
+	}
+	for {
+		i := strings.Index(lower, "url(")
+		if i < 0 {
+			return false
+		}
+		lower = lower[i+len("url("):]
+		target := strings.TrimLeft(lower, " \t\r\n'\"")
+		if !strings.HasPrefix(target, "#") {
+			return true
+		}
+	}
+}
+
+// svgSize reads the size of an SVG from the width and height of its root
+// element, falling back to its viewBox. Sizes in units other than pixels
+// count as unknown, which leaves the size at zero.
+func svgSize(attrs []xml.Attr) image.Config {
+	var config image.Config
+	var viewBox string
+	for _, a := range attrs {
+		switch a.Name.Local {
+		case "width":
+			config.Width = svgLength(a.Value)
+		case "height":
+			config.Height = svgLength(a.Value)
+		case "viewBox":
+			viewBox = a.Value
+		}
+	}
+	if config.Width > 0 && config.Height > 0 {
+		return config
+	}
+
+	fields := strings.FieldsFunc(viewBox, func(r rune) bool { return r == ' ' || r == ',' })
+	if len(fields) == 4 {
+		w, errW := strconv.ParseFloat(fields[2], 64)
+		h, errH := strconv.ParseFloat(fields[3], 64)
+		if errW == nil && errH == nil && w > 0 && h > 0 {
+			return image.Config{Width: int(math.Round(w)), Height: int(math.Round(h))}
+		}
+	}
+	return image.Config{}
+}
+
+// svgLength parses a length in pixels, 0 when it isn't one
+func svgLength(v string) int {
+	n, err := strconv.ParseFloat(strings.TrimSuffix(strings.TrimSpace(v), "px"), 64)
+	if err != nil || n <= 0 {
+		return 0
+	}
+	return int(math.Round(n))
+}
+
+// decodeImageConfig returns the size of the image called name, sanitizing it
+// first if it is an SVG
+func decodeImageConfig(r io.Reader, name string) (image.Config, string, error) {
+	if !isSVG(name) {
+		return image.DecodeConfig(r)
+	}
+	data, err := io.ReadAll(io.LimitReader(r, maxSVGSize+1))
+	if err != nil {
+		return image.Config{}, "", err
+	}
+	_, config, err := sanitizeSVG(data)
+	return config, "svg", err
+}
+
+// svgImage returns the SVG called name sanitized, from the cache when it has
+// been sanitized before, and the key it is cached under
+func (m *Manager) svgImage(name string) ([]byte, string, error) {
+	info, err := fs.Stat(m.images, name)
+	if err != nil || info.IsDir() || !isSVG(name) {
+		return nil, "", fs.ErrNotExist
+	}
+
+	key := sourceKey(name, info) + "|svg"
+	data, err := m.scaled.do(key, func() ([]byte, error) {
+		raw, err := fs.ReadFile(m.images, name)
+		if err != nil {
+			return nil, err
+		}
+		data, _, err := sanitizeSVG(raw)
+		if err != nil {
+			return nil, fmt.Errorf("unable to sanitize %s: %w", name, err)
+		}
+		return data, nil
+	})
+	if err != nil {
+		return nil, "", err
+	}
+	return data, key, nil
+}
@@ -0,0 +1,173 @@
+package sponsor
+
+import (
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSanitizeSVG(t *testing.T) {
+	tests := []struct {
+		name          string
+		svg           string
+		want          []string // Substrings the sanitized SVG keeps
+		dropped       []string // Substrings it must not contain
+		width, height int
+		errContains   string
+	}{
+		{
+			name:   "plain logo",
+			svg:    `<?xml version="1.0"?><svg xmlns="http://www.w3.org/2000/svg" width="220" height="220"><rect width="10" height="10" fill="#205493"/></svg>`,
+			want:   []string{`<svg xmlns="http://www.w3.org/2000/svg" width="220" height="220">`, `<rect width="10" height="10" fill="#205493"></rect>`},
+			width:  220,
+			height: 220,
+		},
+		{
+			name:    "scripts and event handlers",
+			svg:     `<svg xmlns="http://www.w3.org/2000/svg" viewBox="0 0 100 50" onload="alert(1)"><script>alert(2)</script><g onclick="alert(3)"><circle r="5"/></g><foreignObject><iframe src="https://example.com"/></foreignObject></svg>`,
+			want:    []string{`<g><circle r="5"></circle></g>`},
+			dropped: []string{"alert", "script", "onload", "onclick", "iframe", "foreignObject"},
+			width:   100,
+			height:  50,
+		},
+		{
+			name:    "external references",
+			svg:     `<svg xmlns="http://www.w3.org/2000/svg" xmlns:xlink="http://www.w3.org/1999/xlink" viewBox="0,0,64,64"><defs><linearGradient id="g"/></defs><use xlink:href="#shape"/><use xlink:href="https://evil.example/sprite.svg#shape"/><image href="data:image/png;base64,AAAA"/><image href="http://evil.example/track.png"/><rect fill="url(#g)" style="fill:url(https://evil.example/x)"/><a href="javascript:alert(1)"><text>hi</text></a><style>@import url(https://evil.example/x.css);</style><style>.a{fill:red}</style></svg>`,
+			want:    []string{`<use xlink:href="#shape">`, `href="data:image/png;base64,AAAA"`, `fill="url(#g)"`, `.a{fill:red}`, `<text>hi</text>`},
+			dropped: []string{"evil.example", "javascript", "@import"},
+			width:   64,
+			height:  64,
+		},
+		{
+			name:    "CSS escapes and splits",
+			svg:     `<svg xmlns="http://www.w3.org/2000/svg" width="8" height="8"><style>@\69mport "http://evil.example/a.css";</style><style>.a{fill:\75 rl(http://evil.example/b)}</style><style>@im<![CDATA[port "http://evil.example/c.css";]]></style><style>@im<g/>port "http://evil.example/d.css";</style><style>.b{fill:u/**/rl(http://evil.example/e)}</style><rect style="fill:u\72l(http://evil.example/f)"/><rect style="fill:image-set('http://evil.example/g.png' 1x)"/><style>.c{fill:blue}</style></svg>`,
+			want:    []string{`.c{fill:blue}`, `<rect></rect>`},
+			dropped: []string{"evil.example", "mport", "<g>"},
+			width:   8,
+			height:  8,
+		},
+		{
+			name:    "animation setting a link",
+			svg:     `<svg xmlns="http://www.w3.org/2000/svg" width="10px" height="10px"><a><set attributeName="href" to="javascript:alert(1)"/><text>x</text></a></svg>`,
+			dropped: []string{"set", "javascript"},
+			width:   10,
+			height:  10,
+		},
+		{
+			name:   "Illustrator namespace entities",
+			svg:    `<?xml version="1.0"?><!DOCTYPE svg PUBLIC "-//W3C//DTD SVG 1.1//EN" "http://www.w3.org/Graphics/SVG/1.1/DTD/svg11.dtd" [<!ENTITY ns_svg "http://www.w3.org/2000/svg">]><!-- Generator: Adobe Illustrator --><svg xmlns="&ns_svg;" width="220mm" height="110mm" viewBox="0 0 440 220"></svg>`,
+			want:   []string{`<svg xmlns="http://www.w3.org/2000/svg"`},
+			width:  440,
+			height: 220,
+		},
+		{
+			name:        "recursive entities",
+			svg:         `<!DOCTYPE svg [<!ENTITY a "aaaa"><!ENTITY b "&a;&a;&a;">]><svg xmlns="http://www.w3.org/2000/svg"><text>&b;</text></svg>`,
+			errContains: "unable to parse SVG",
+		},
+		{
+			name:        "not an svg",
+			svg:         `<html><body>hello</body></html>`,
+			errContains: "not svg",
+		},
+		{
+			name:        "not XML",
+			svg:         `<svg width="1"`,
+			errContains: "unable to parse SVG",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, config, err := sanitizeSVG([]byte(tt.svg))
+			if tt.errContains != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.errContains) {
+					t.Fatalf("❌ sanitizeSVG() error = %v, want one containing %q", err, tt.errContains)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("❌ sanitizeSVG() unexpected error: %v", err)
+			}
+
+			out := string(data)
+			for _, s := range tt.want {
+				if !strings.Contains(out, s) {
+					t.Errorf("❌ Sanitized SVG is missing %q: %s", s, out)
+				}
+			}
+			for _, s := range tt.dropped {
+				if strings.Contains(out, s) {
+					t.Errorf("❌ Sanitized SVG still has %q: %s", s, out)
+				}
+			}
+			if config.Width != tt.width || config.Height != tt.height {
+				t.Errorf("❌ Size = %dx%d, want %dx%d", config.Width, config.Height, tt.width, tt.height)
+			}
+			if _, _, err := sanitizeSVG(data); err != nil {
+				t.Errorf("❌ Sanitized SVG doesn't parse again: %v", err)
+			}
+		})
+	}
+}
+
+func TestServeSVG(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "vector.svg"), []byte(`<svg xmlns="http://www.w3.org/2000/svg" viewBox="0 0 220 220" onload="alert(1)"><script>alert(2)</script><rect width="220" height="220"/></svg>`), 0600); err != nil {
+		t.Fatalf("❌ Failed to write fixture: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "sizeless.svg"), []byte(`<svg xmlns="http://www.w3.org/2000/svg"><rect width="1" height="1"/></svg>`), 0600); err != nil {
+		t.Fatalf("❌ Failed to write fixture: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "broken.svg"), []byte(`<svg><rect>`), 0600); err != nil {
+		t.Fatalf("❌ Failed to write fixture: %v", err)
+	}
+	manager, err := NewManager(dir)
+	if err != nil {
+		t.Fatalf("❌ Failed to create sponsor manager: %v", err)
+	}
+
+	for _, path := range []string{"/vector.svg", "/vector.svg?w=110"} {
+		rr := getImage(t, manager, path, nil)
+		if rr.Code != http.StatusOK {
+			t.Fatalf("❌ %s = %d, want 200", path, rr.Code)
+		}
+		if ct := rr.Header().Get("Content-Type"); ct != "image/svg+xml" {
+			t.Errorf("❌ %s Content-Type = %q, want image/svg+xml", path, ct)
+		}
+		if rr.Header().Get("Content-Security-Policy") == "" || rr.Header().Get("X-Content-Type-Options") != "nosniff" {
+			t.Errorf("❌ %s is missing its security headers: %v", path, rr.Header())
+		}
+		if body := rr.Body.String(); strings.Contains(body, "alert") || !strings.Contains(body, "<rect") {
+			t.Errorf("❌ %s was not sanitized: %s", path, body)
+		}
+	}
+	if rr := getImage(t, manager, "/vector.svg?w=100", nil); rr.Code != http.StatusBadRequest {
+		t.Errorf("❌ SVG with a size not allowed = %d, want 400", rr.Code)
+	}
+	if rr := getImage(t, manager, "/broken.svg", nil); rr.Code != http.StatusNotFound {
+		t.Errorf("❌ Broken SVG = %d, want 404", rr.Code)
+	}
+
+	all := allSponsors(t, manager)
+	if !strings.Contains(strings.Join(all, ","), "vector.svg") || strings.Contains(strings.Join(all, ","), "broken.svg") {
+		t.Errorf("❌ /sponsors/all = %v, want vector.svg and not broken.svg", all)
+	}
+
+	manifest, err := parseManifest([]byte(`{"tiers":[{"name":"gold","sponsors":["vector.svg","sizeless.svg","broken.svg"]}]}`))
+	if err != nil {
+		t.Fatalf("❌ parseManifest() unexpected error: %v", err)
+	}
+	st := manager.Check(manifest)
+	kinds := make(map[string]string)
+	for _, p := range st.Errors() {
+		kinds[p.Image] = p.Kind
+	}
+	if len(kinds) != 2 || kinds["sizeless.svg"] != ProblemWrongSize || kinds["broken.svg"] != ProblemUndecodable {
+		t.Errorf("❌ Check() errors = %v, want sizeless.svg wrong-size and broken.svg undecodable", kinds)
+	} else {
+		t.Logf("✅ SVGs served sanitized and checked for a size")
+	}
+}
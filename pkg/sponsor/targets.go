@@ -0,0 +1,104 @@
+package sponsor
+
+import (
+	"slices"
+	"strings"
+	"time"
+)
+
+// Placement is a sponsor tied to rooms, topics or a time window, shown next to
+// the sessions it matches as well as in its tier
+type Placement struct {
+	Name     string    `json:"name"`
+	Tier     string    `json:"tier"`
+	Image    string    `json:"image"`
+	ImageURL string    `json:"imageUrl"`
+	Website  string    `json:"website,omitempty"`
+	From     time.Time `json:"from,omitzero"`
+	Until    time.Time `json:"until,omitzero"`
+}
+
+// Targeted reports whether the sponsor is tied to rooms, topics or a time
+// window
+func (s Sponsor) Targeted() bool {
+	return len(s.Rooms) > 0 || len(s.Topics) > 0 || !s.From.IsZero() || !s.Until.IsZero()
+}
+
+// during reports whether the sponsor's time window overlaps start to end. A
+// sponsor without a window is always on.
+func (s Sponsor) during(start, end time.Time) bool {
+	if !s.From.IsZero() && !end.After(s.From) {
+		return false
+	}
+	if !s.Until.IsZero() && !start.Before(s.Until) {
+		return false
+	}
+	return true
+}
+
+// matchesSession reports whether the sponsor is tied to a session in room on
+// topic from start to end. A sponsor with rooms or topics matches sessions in
+// any of its rooms or on any of its topics, and one with only a time window
+// matches every session in it.
+func (s Sponsor) matchesSession(room, topic string, start, end time.Time) bool {
+	if !s.Targeted() || !s.during(start, end) {
+		return false
+	}
+	if len(s.Rooms) == 0 && len(s.Topics) == 0 {
+		return true
+	}
+	return containsFold(s.Rooms, room) || containsFold(s.Topics, topic)
+}
+
+// containsFold reports whether list has v, ignoring case and surrounding space
+func containsFold(list []string, v string) bool {
+	v = strings.TrimSpace(v)
+	return v != "" && slices.ContainsFunc(list, func(item string) bool {
+		return strings.EqualFold(strings.TrimSpace(item), v)
+	})
+}
+
+// placement returns the sponsor as shown next to sessions
+func (s Sponsor) placement(tier string) Placement {
+	return Placement{
+		Name:     s.DisplayName(),
+		Tier:     tier,
+		Image:    s.Image,
+		ImageURL: ImagesPath + "/" + s.Image,
+		Website:  s.Website,
+		From:     s.From,
+		Until:    s.Until,
+	}
+}
+
+// SessionSponsors returns the sponsors tied to a session in room on topic
+// from start to end, in tier order
+func (m *Manifest) SessionSponsors(room, topic string, start, end time.Time) []Placement {
+	var placements []Placement
+	seen := make(map[string]bool)
+	for _, t := range m.Tiers {
+		for _, s := range t.Sponsors {
+			if !seen[s.Image] && s.matchesSession(room, topic, start, end) {
+				seen[s.Image] = true
+				placements = append(placements, s.placement(t.Name))
+			}
+		}
+	}
+	return placements
+}
+
+// RoomSponsors returns the sponsors that have bought room, in tier order, with
+// their time windows so a door sign can show whichever is on now
+func (m *Manifest) RoomSponsors(room string) []Placement {
+	var placements []Placement
+	seen := make(map[string]bool)
+	for _, t := range m.Tiers {
+		for _, s := range t.Sponsors {
+			if !seen[s.Image] && containsFold(s.Rooms, room) {
+				seen[s.Image] = true
+				placements = append(placements, s.placement(t.Name))
+			}
+		}
+	}
+	return placements
+}
@@ -0,0 +1,76 @@
+package sponsor
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSessionSponsors(t *testing.T) {
+	manifest, err := parseManifest([]byte(`{"tiers":[
+		{"name":"diamond","sponsors":[
+			"meta.png",
+			{"image":"kube.png","rooms":["Room 101"],"topics":["Kubernetes"]},
+			{"image":"morning.png","from":"2026-03-07T08:00:00-08:00","until":"2026-03-07T12:00:00-08:00"}
+		]},
+		{"name":"gold","sponsors":[
+			{"image":"kube.png"},
+			{"image":"saturday.png","rooms":[" room 101 "],"from":"2026-03-07T00:00:00-08:00","until":"2026-03-08T00:00:00-08:00"}
+		]}
+	]}`))
+	if err != nil {
+		t.Fatalf("❌ parseManifest() unexpected error: %v", err)
+	}
+
+	at := func(s string) time.Time {
+		v, err := time.Parse(time.RFC3339, s)
+		if err != nil {
+			t.Fatalf("❌ Bad time %s: %v", s, err)
+		}
+		return v
+	}
+	tests := []struct {
+		name        string
+		room, topic string
+		start       string
+		want        []string
+	}{
+		{name: "room in the morning", room: "room 101", start: "2026-03-07T10:00:00-08:00", want: []string{"kube.png", "morning.png", "saturday.png"}},
+		{name: "topic elsewhere", room: "Room 202", topic: "kubernetes", start: "2026-03-07T14:00:00-08:00", want: []string{"kube.png"}},
+		{name: "ends as the window opens", room: "Room 202", start: "2026-03-07T07:00:00-08:00", want: nil},
+		{name: "room on Sunday", room: "Room 101", start: "2026-03-08T10:00:00-08:00", want: []string{"kube.png"}},
+		{name: "nothing tied", room: "Ballroom A", topic: "Security", start: "2026-03-08T10:00:00-08:00", want: nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			start := at(tt.start)
+			var got []string
+			for _, p := range manifest.SessionSponsors(tt.room, tt.topic, start, start.Add(time.Hour)) {
+				got = append(got, p.Image)
+			}
+			if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("❌ SessionSponsors() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	room := manifest.RoomSponsors("ROOM 101")
+	if len(room) != 2 || room[0].Tier != "diamond" || room[1].Until.IsZero() {
+		t.Errorf("❌ RoomSponsors() = %+v, want kube.png in diamond and saturday.png with its window", room)
+	} else {
+		t.Logf("✅ %d sponsors for Room 101", len(room))
+	}
+}
+
+func TestTargetsValidate(t *testing.T) {
+	for _, manifest := range []string{
+		`{"tiers":[{"name":"gold","sponsors":[{"image":"a.png","rooms":[""]}]}]}`,
+		`{"tiers":[{"name":"gold","sponsors":[{"image":"a.png","topics":["  "]}]}]}`,
+		`{"tiers":[{"name":"gold","sponsors":[{"image":"a.png","from":"2026-03-08T00:00:00Z","until":"2026-03-07T00:00:00Z"}]}]}`,
+		`{"tiers":[{"name":"gold","sponsors":[{"image":"a.png","from":"Saturday"}]}]}`,
+	} {
+		if _, err := parseManifest([]byte(manifest)); err == nil {
+			t.Errorf("❌ parseManifest(%s) expected error, got nil", manifest)
+		}
+	}
+}
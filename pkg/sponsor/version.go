@@ -0,0 +1,61 @@
+package sponsor
+
+import (
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"io/fs"
+	"net/http"
+	"time"
+)
+
+// VersionPath is where displays poll for changes to the sponsors
+const VersionPath = "/sponsors/version"
+
+// rescanImages picks up changes to the images directory now rather than at
+// the next check
+func (m *Manager) rescanImages() {
+	d, ok := m.images.(*imageDir)
+	if !ok {
+		return
+	}
+
+	d.mutex.Lock()
+	d.lastCheck = time.Now()
+	d.mutex.Unlock()
+	if err := d.scan(); err != nil {
+		logger().Error("unable to read sponsor images, keeping the last good set", "dir", d.dir, "err", err)
+	}
+}
+
+// Version returns a hash of the sponsor tiers and of the name, size and
+// modification time of every image, which changes whenever anything a
+// display shows does
+func (m *Manager) Version() string {
+	h := fnv.New64a()
+	json.NewEncoder(h).Encode(m.Manifest())
+
+	entries, err := fs.ReadDir(m.images, ".")
+	if err != nil {
+		logger().Error("unable to list sponsor images", "err", err)
+	}
+	for _, e := range entries {
+		info, err := e.Info()
+		if err != nil {
+			continue
+		}
+		fmt.Fprintf(h, "%s|%d|%d\n", e.Name(), info.Size(), info.ModTime().UnixNano())
+	}
+	return fmt.Sprintf("%x", h.Sum64())
+}
+
+// HandleVersion serves the current Version for displays to poll
+func (m *Manager) HandleVersion(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	if err := json.NewEncoder(w).Encode(struct {
+		Version string `json:"version"`
+	}{m.Version()}); err != nil {
+		logger().Error("unable to encode sponsor version", "err", err)
+	}
+}
@@ -1,42 +1,84 @@
 // react-display/src/App.tsx
 
 import { TimeProvider } from './contexts/TimeContext';
+import { ConfigProvider, useConfig } from './contexts/ConfigContext';
+import { BrandingProvider } from './contexts/BrandingContext';
 import { SponsorProvider } from './contexts/SponsorContext';
 import { ScheduleProvider } from './contexts/ScheduleContext';
 import { Header } from './components/Header';
 import { SponsorBanner } from './components/SponsorBanner';
 import { ScheduleCarousel } from './components/ScheduleCarousel';
+import { CommandListener } from './components/CommandListener';
+import { RoomSponsor } from './components/RoomSponsor';
+
+// Display lays out the sign according to its profile
+function Display() {
+	const { config } = useConfig();
+	const isRoomLayout = config.layout === 'room' && config.room !== '';
 
-function App() {
 	return (
-		<div className='flex flex-col h-screen w-full overflow-hidden'>
-			<TimeProvider>
-				{/* Header with logo, clock and wifi info */}
-				<Header />
+		<TimeProvider>
+			{/* Header with logo, clock and wifi info */}
+			<Header />
 
-				<div className='flex flex-1 bg-white overflow-hidden'>
+			{/* Sponsors for the banner, room and sessions, counted as shown */}
+			<SponsorProvider
+				tiers={config.sponsorTiers}
+				sign={config.signId}
+				rotationSeconds={config.sponsorRotationSeconds}
+				shown={3}
+			>
+				<div className='flex flex-1 bg-[var(--brand-background)] overflow-hidden'>
 					{/* Main content area - 80% width */}
-					<div className='w-4/5 p-2 overflow-y-auto'>
+					<div className='w-4/5 p-2 overflow-y-auto flex flex-col'>
+						{/* Room name for door signs pinned to one room */}
+						{isRoomLayout && (
+							<div className='text-4xl font-bold text-center text-[var(--brand-primary)] pb-2'>
+								{config.room}
+							</div>
+						)}
+						{isRoomLayout && <RoomSponsor room={config.room} />}
+
 						{/* Schedule Carousel showing current and upcoming sessions */}
-						<ScheduleProvider refreshInterval={60000}>
-							<ScheduleCarousel
-								maxDisplay={6}
-								rotationInterval={15000}
-							/>
-						</ScheduleProvider>
+						<div className='flex-1 overflow-hidden'>
+							<ScheduleProvider
+								refreshInterval={60000}
+								room={config.room}
+							>
+								<ScheduleCarousel
+									maxDisplay={isRoomLayout ? 4 : 6}
+									rotationInterval={config.scheduleRotationSeconds * 1000}
+								/>
+							</ScheduleProvider>
+						</div>
 					</div>
 
 					{/* Sponsor banner - 20% width, vertically aligned */}
 					<div className='w-1/5 p-2'>
-						<SponsorProvider>
-							<SponsorBanner
-								displayCount={3}
-								rotationInterval={10000}
-							/>
-						</SponsorProvider>
+						<SponsorBanner
+							displayCount={3}
+							rotationInterval={config.sponsorRotationSeconds * 1000}
+						/>
 					</div>
 				</div>
-			</TimeProvider>
+			</SponsorProvider>
+
+			{/* Commands from the fleet controller, e.g. the sign ID overlay */}
+			<CommandListener />
+		</TimeProvider>
+	);
+}
+
+function App() {
+	return (
+		<div className='flex flex-col h-screen w-full overflow-hidden'>
+			{/* Event logos, Wi-Fi and colors from /branding, kept up to date */}
+			<BrandingProvider>
+				{/* Per-sign profile from /config, read once at boot */}
+				<ConfigProvider>
+					<Display />
+				</ConfigProvider>
+			</BrandingProvider>
 		</div>
 	);
 }
@@ -0,0 +1,131 @@
+// react-display/src/components/CommandListener/CommandListener.tsx
+
+import { useState, useEffect, useCallback } from 'react';
+import { useConfig } from '../../contexts/ConfigContext';
+
+interface DisplayCommand {
+	id: string;
+	name: 'reload' | 'identify' | 'clear-cache';
+}
+
+interface CommandListenerProps {
+	pollInterval?: number; // in milliseconds, default: 5000
+	identifyDuration?: number; // in milliseconds, default: 30000
+}
+
+// Acknowledge a command to go-signs, which reports it to the fleet controller
+async function acknowledge(id: string, error?: string) {
+	await fetch(`/display/commands/${encodeURIComponent(id)}/ack`, {
+		method: 'POST',
+		headers: { 'Content-Type': 'application/json' },
+		body: JSON.stringify(
+			error === undefined ? { status: 'done' } : { status: 'failed', error }
+		),
+	});
+}
+
+// Drop everything the browser has cached for the display
+async function clearCaches() {
+	if ('caches' in window) {
+		const keys = await caches.keys();
+		await Promise.all(keys.map((key) => caches.delete(key)));
+	}
+	window.localStorage.clear();
+	window.sessionStorage.clear();
+}
+
+// CommandListener polls for commands from the fleet controller and carries
+// them out, showing the sign ID overlay when asked to identify
+export function CommandListener({
+	pollInterval = 5000,
+	identifyDuration = 30000,
+}: CommandListenerProps) {
+	const { config } = useConfig();
+	const [identifyUntil, setIdentifyUntil] = useState<number>(0);
+
+	const runCommand = useCallback(
+		async (command: DisplayCommand) => {
+			console.log(`Running command ${command.name} (${command.id})`);
+			switch (command.name) {
+				case 'reload':
+					await acknowledge(command.id);
+					window.location.reload();
+					break;
+				case 'identify':
+					setIdentifyUntil(Date.now() + identifyDuration);
+					await acknowledge(command.id);
+					break;
+				case 'clear-cache':
+					try {
+						await clearCaches();
+					} catch (err) {
+						await acknowledge(command.id, String(err));
+						return;
+					}
+					await acknowledge(command.id);
+					window.location.reload();
+					break;
+			}
+		},
+		[identifyDuration]
+	);
+
+	// Poll for commands
+	useEffect(() => {
+		const poll = async () => {
+			try {
+				const response = await fetch('/display/commands');
+				if (!response.ok) {
+					return;
+				}
+				const commands = (await response.json()) as DisplayCommand[];
+				for (const command of commands) {
+					await runCommand(command);
+				}
+			} catch (err) {
+				console.error('Error polling for commands:', err);
+			}
+		};
+
+		const intervalId = setInterval(() => {
+			void poll();
+		}, pollInterval);
+
+		return () => {
+			clearInterval(intervalId);
+		};
+	}, [runCommand, pollInterval]);
+
+	// Hide the overlay once the identify period is over
+	useEffect(() => {
+		if (identifyUntil === 0) {
+			return;
+		}
+
+		const timeoutId = setTimeout(() => {
+			setIdentifyUntil(0);
+		}, Math.max(0, identifyUntil - Date.now()));
+
+		return () => {
+			clearTimeout(timeoutId);
+		};
+	}, [identifyUntil]);
+
+	if (identifyUntil === 0) {
+		return null;
+	}
+
+	return (
+		<div className='fixed inset-0 z-50 flex items-center justify-center bg-black/70'>
+			<div className='rounded-lg bg-white px-16 py-12 text-center shadow-md'>
+				<div className='text-3xl text-[var(--brand-text)] mb-4'>Sign ID</div>
+				<div className='text-8xl font-bold text-[var(--brand-primary)]'>
+					{config.signId !== '' ? config.signId : 'unknown'}
+				</div>
+				{config.room !== '' && (
+					<div className='text-3xl text-[var(--brand-text)] mt-4'>{config.room}</div>
+				)}
+			</div>
+		</div>
+	);
+}
@@ -0,0 +1,3 @@
+// react-display/src/components/CommandListener/index.ts
+
+export { CommandListener } from './CommandListener';
@@ -1,28 +1,43 @@
 // react-display/src/components/Header/Header.tsx
 
 import { Clock } from '../Clock';
+import { useBranding } from '../../contexts/BrandingContext';
 import scaleLogo from '../../assets/logo.png';
 import scaleWifi from '../../assets/wifi.png';
 
 export function Header() {
+	const { branding } = useBranding();
+	const { wifi } = branding;
+
 	return (
-		<header className='w-full bg-white shadow-md flex justify-between items-center h-[10vh] py-2 px-2 p-2'>
+		<header className='w-full bg-[var(--brand-background)] shadow-md flex justify-between items-center h-[10vh] py-2 px-2 p-2'>
 			<div className='flex items-center'>
 				<img
-					src={scaleLogo}
+					src={branding.logoUrl ?? scaleLogo}
 					className='h-16 mr-4'
-					alt='SCaLE Logo'
+					alt={`${branding.eventName} Logo`}
 				/>
 			</div>
 
 			<Clock />
 
 			<div className='flex items-center'>
-				<img
-					src={scaleWifi}
-					className='h-16 ml-4'
-					alt='WiFi Information'
-				/>
+				{/* An image such as a QR code, else the network as text, else
+				    the image the display was built with */}
+				{wifi.imageUrl !== undefined || wifi.ssid === undefined ? (
+					<img
+						src={wifi.imageUrl ?? scaleWifi}
+						className='h-16 ml-4'
+						alt='WiFi Information'
+					/>
+				) : (
+					<div className='ml-4 text-right text-[var(--brand-text)]'>
+						<div className='text-2xl font-bold'>Wi-Fi: {wifi.ssid}</div>
+						{wifi.password !== undefined && (
+							<div className='text-lg'>Password: {wifi.password}</div>
+						)}
+					</div>
+				)}
 			</div>
 		</header>
 	);
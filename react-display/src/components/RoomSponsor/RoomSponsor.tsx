@@ -0,0 +1,97 @@
+// react-display/src/components/RoomSponsor/RoomSponsor.tsx
+
+import { useState, useEffect } from 'react';
+import { SessionSponsor } from '../../contexts/ScheduleContext/types';
+import { useTime } from '../../contexts/TimeContext';
+import { useSponsor } from '../../contexts/SponsorContext';
+
+// How often the room's sponsors are refetched
+const ROOM_REFRESH_INTERVAL = 300000;
+
+interface RoomSponsorProps {
+	room: string;
+}
+
+// RoomSponsor shows "this room brought to you by" on door signs for the
+// sponsors who bought the room, during their time window if they have one
+export function RoomSponsor({ room }: RoomSponsorProps) {
+	const { currentTime } = useTime();
+	const { versioned, recordImpressions } = useSponsor();
+	const [sponsors, setSponsors] = useState<SessionSponsor[]>([]);
+
+	useEffect(() => {
+		const fetchRoom = async () => {
+			try {
+				const response = await fetch(
+					`/schedule/rooms/${encodeURIComponent(room)}`
+				);
+				if (response.status === 404) {
+					setSponsors([]);
+					return;
+				}
+				if (!response.ok) {
+					throw new Error(
+						`Failed to fetch room: ${String(response.status)} ${
+							response.statusText
+						}`
+					);
+				}
+				const data = (await response.json()) as {
+					sponsors?: SessionSponsor[];
+				};
+				setSponsors(Array.isArray(data.sponsors) ? data.sponsors : []);
+			} catch (err) {
+				// Keep the sponsors we have until the server is back
+				console.error('Error fetching room sponsors:', err);
+			}
+		};
+
+		void fetchRoom();
+		const interval = setInterval(() => {
+			void fetchRoom();
+		}, ROOM_REFRESH_INTERVAL);
+		return () => {
+			clearInterval(interval);
+		};
+	}, [room]);
+
+	// Only sponsors whose window includes the (possibly overridden) time
+	const now = currentTime.getTime();
+	const current = sponsors.filter(
+		(sponsor) =>
+			(!sponsor.from || new Date(sponsor.from).getTime() <= now) &&
+			(!sponsor.until || now < new Date(sponsor.until).getTime())
+	);
+	const shown = current.map((sponsor) => sponsor.imageUrl).join('\n');
+
+	// Count the room's logos once they leave the screen
+	useEffect(() => {
+		const shownAt = Date.now();
+		return () => {
+			recordImpressions(
+				shown === '' ? [] : shown.split('\n'),
+				(Date.now() - shownAt) / 1000
+			);
+		};
+	}, [shown, recordImpressions]);
+
+	if (current.length === 0) {
+		return null;
+	}
+
+	return (
+		<div className='flex items-center justify-center gap-4 pb-2'>
+			<span className='text-2xl font-bold text-[var(--brand-text)]'>
+				This room brought to you by
+			</span>
+			{current.map((sponsor) => (
+				<img
+					key={sponsor.imageUrl}
+					src={versioned(`${sponsor.imageUrl}?h=110`)}
+					alt={sponsor.name}
+					className='h-16 object-contain'
+				/>
+			))}
+		</div>
+	);
+}
@@ -0,0 +1,3 @@
+// react-display/src/components/RoomSponsor/index.ts
+
+export { RoomSponsor } from './RoomSponsor';
@@ -1,7 +1,9 @@
 // react-display/src/components/ScheduleCarousel/ScheduleItem.tsx
 
 import { SessionWithStatus } from '../../contexts/ScheduleContext/types';
+import { useEffect } from 'react';
 import { useTime } from '../../contexts/TimeContext';
+import { useSponsor } from '../../contexts/SponsorContext';
 
 interface ScheduleItemProps {
 	session: SessionWithStatus;
@@ -10,6 +12,23 @@ interface ScheduleItemProps {
 
 export function ScheduleItem({ session, isEmpty = false }: ScheduleItemProps) {
 	const { currentTime } = useTime();
+	const { versioned, recordImpressions } = useSponsor();
+	const shown = isEmpty
+		? ''
+		: (session.Sponsors ?? [])
+				.map((sponsor) => sponsor.imageUrl)
+				.join('\n');
+
+	// Count the session's sponsor logos once they leave the screen
+	useEffect(() => {
+		const shownAt = Date.now();
+		return () => {
+			recordImpressions(
+				shown === '' ? [] : shown.split('\n'),
+				(Date.now() - shownAt) / 1000
+			);
+		};
+	}, [shown, recordImpressions]);
 
 	// Skip rendering details for empty placeholders
 	if (isEmpty || !session.Name) {
@@ -66,7 +85,7 @@ export function ScheduleItem({ session, isEmpty = false }: ScheduleItemProps) {
 					{/* Speakers and topic in a row */}
 					<div className='flex flex-wrap mt-1 text-xl px-2 py-2'>
 						{session.Topic && (
-							<span className='bg-[#02bfe7] font-bold text-[#212121] px-2 py-1 rounded-md mr-2 whitespace-nowrap flex-shrink-0'>
+							<span className='bg-[var(--brand-accent)] font-bold text-[var(--brand-text)] px-2 py-1 rounded-md mr-2 whitespace-nowrap flex-shrink-0'>
 								{session.Topic}
 							</span>
 						)}
@@ -75,6 +94,21 @@ export function ScheduleItem({ session, isEmpty = false }: ScheduleItemProps) {
 							{session.Speakers}
 						</span>
 					</div>
+
+					{/* Sponsors who bought this session's room, topic or time */}
+					{session.Sponsors && session.Sponsors.length > 0 && (
+						<div className='flex items-center gap-2 px-2 text-lg text-[#dce4ef]'>
+							<span className='whitespace-nowrap'>Sponsored by</span>
+							{session.Sponsors.map((sponsor) => (
+								<img
+									key={sponsor.imageUrl}
+									src={versioned(`${sponsor.imageUrl}?h=55`)}
+									alt={sponsor.name}
+									className='h-10 bg-white rounded-sm object-contain'
+								/>
+							))}
+						</div>
+					)}
 				</div>
 
 				{/* Right side - Room, status, and time */}
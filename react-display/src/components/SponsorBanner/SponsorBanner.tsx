@@ -13,16 +13,30 @@ export function SponsorBanner({
 	displayCount = 3,
 	rotationInterval = 10000,
 }: SponsorBannerProps) {
-	const { getRandomSponsorUrls, isLoading, error } = useSponsor();
+	const {
+		getNextSponsorUrls,
+		getSponsor,
+		recordImpressions,
+		isLoading,
+		error,
+	} = useSponsor();
 	const [sponsorUrls, setSponsorUrls] = useState<string[]>([]);
 	const rotationTimerRef = useRef<number | null>(null);
 
-	// Initialize with random sponsor images
+	// Initialize with the first logos of the rotation
 	useEffect(() => {
 		if (!isLoading && !error) {
-			setSponsorUrls(getRandomSponsorUrls(displayCount));
+			setSponsorUrls(getNextSponsorUrls(displayCount));
 		}
-	}, [isLoading, error, getRandomSponsorUrls, displayCount]);
+	}, [isLoading, error, getNextSponsorUrls, displayCount]);
+
+	// Count each set of logos once it leaves the screen
+	useEffect(() => {
+		const shownAt = Date.now();
+		return () => {
+			recordImpressions(sponsorUrls, (Date.now() - shownAt) / 1000);
+		};
+	}, [sponsorUrls, recordImpressions]);
 
 	// Rotate sponsors at the specified interval
 	useEffect(() => {
@@ -35,7 +49,7 @@ export function SponsorBanner({
 
 		// Set up new rotation timer
 		rotationTimerRef.current = window.setInterval(() => {
-			setSponsorUrls(getRandomSponsorUrls(displayCount));
+			setSponsorUrls(getNextSponsorUrls(displayCount));
 		}, rotationInterval);
 
 		// Cleanup on unmount
@@ -45,7 +59,7 @@ export function SponsorBanner({
 				rotationTimerRef.current = null;
 			}
 		};
-	}, [isLoading, error, getRandomSponsorUrls, displayCount, rotationInterval]);
+	}, [isLoading, error, getNextSponsorUrls, displayCount, rotationInterval]);
 
 	if (isLoading) {
 		return (
@@ -64,7 +78,7 @@ export function SponsorBanner({
 	}
 
 	return (
-		<div className='h-full w-full bg-[#aeb0b5] rounded-lg p-4 shadow-md'>
+		<div className='h-full w-full bg-[var(--brand-panel)] rounded-lg p-4 shadow-md'>
 			<div className='flex flex-col justify-around items-center h-full gap-4'>
 				{sponsorUrls.map((url, index) => (
 					<div
@@ -74,6 +88,7 @@ export function SponsorBanner({
 						<SponsorItem
 							url={url}
 							index={index}
+							alt={getSponsor(url)?.name}
 						/>
 					</div>
 				))}
@@ -6,9 +6,14 @@ import nocPenguin from '../../assets/noc-penguin.png';
 interface SponsorItemProps {
 	url: string;
 	index: number; // Added index to help with unique identification
+	alt?: string; // sponsor name from /sponsors
 }
 
-export function SponsorItem({ url, index }: SponsorItemProps) {
+export function SponsorItem({
+	url,
+	index,
+	alt = 'Sponsor',
+}: SponsorItemProps) {
 	const [currentUrl, setCurrentUrl] = useState(url);
 	const [prevUrl, setPrevUrl] = useState<string | null>(null);
 	const [loaded, setLoaded] = useState(false);
@@ -68,7 +73,7 @@ export function SponsorItem({ url, index }: SponsorItemProps) {
 			<img
 				key={imageKey}
 				src={currentUrl}
-				alt='Sponsor'
+				alt={alt}
 				className={`absolute inset-0 w-full h-full object-contain transition-opacity duration-800 ${
 					loaded ? 'opacity-100 z-20' : 'opacity-0'
 				}`}
@@ -0,0 +1,84 @@
+// react-display/src/contexts/BrandingContext/BrandingProvider.tsx
+
+import React, { useState, useEffect, useMemo } from 'react';
+import { BrandingContext } from './brandingContext';
+import { Branding } from './types';
+
+// Re-read the branding this often so a sign can be re-skinned without a reload
+const REFRESH_INTERVAL = 60000;
+
+// Used until /branding answers, matching the go-signs defaults and index.css
+const defaultBranding: Branding = {
+	eventName: 'SCaLE',
+	wifi: {},
+	colors: {
+		primary: '#205493',
+		text: '#212121',
+		panel: '#aeb0b5',
+		accent: '#02bfe7',
+		background: '#ffffff',
+	},
+};
+
+// Apply the colors as the CSS variables the components are styled with
+function applyColors(colors: Branding['colors']) {
+	const style = document.documentElement.style;
+	style.setProperty('--brand-primary', colors.primary);
+	style.setProperty('--brand-text', colors.text);
+	style.setProperty('--brand-panel', colors.panel);
+	style.setProperty('--brand-accent', colors.accent);
+	style.setProperty('--brand-background', colors.background);
+}
+
+export function BrandingProvider({ children }: { children: React.ReactNode }) {
+	const [branding, setBranding] = useState<Branding>(defaultBranding);
+
+	// Unlike the profile, the display renders straight away in the default
+	// branding rather than waiting on /branding
+	useEffect(() => {
+		const fetchBranding = async () => {
+			try {
+				const response = await fetch('/branding', { cache: 'no-cache' });
+				if (!response.ok) {
+					throw new Error(
+						`Failed to fetch branding: ${String(response.status)} ${
+							response.statusText
+						}`
+					);
+				}
+
+				const data = (await response.json()) as Partial<Branding>;
+				const next: Branding = {
+					...defaultBranding,
+					...data,
+					wifi: { ...data.wifi },
+					colors: { ...defaultBranding.colors, ...data.colors },
+				};
+				// Keep the same object when nothing changed so the display
+				// doesn't re-render every minute
+				setBranding((current) =>
+					JSON.stringify(current) === JSON.stringify(next) ? current : next
+				);
+			} catch (err) {
+				console.error('Error fetching branding, keeping current:', err);
+			}
+		};
+
+		void fetchBranding();
+		const timer = window.setInterval(() => {
+			void fetchBranding();
+		}, REFRESH_INTERVAL);
+		return () => {
+			clearInterval(timer);
+		};
+	}, []);
+
+	useEffect(() => {
+		applyColors(branding.colors);
+		document.title = `${branding.eventName} Signs`;
+	}, [branding]);
+
+	const contextValue = useMemo(() => ({ branding }), [branding]);
+
+	return <BrandingContext value={contextValue}>{children}</BrandingContext>;
+}
@@ -0,0 +1,10 @@
+// react-display/src/contexts/BrandingContext/brandingContext.ts
+
+import { createContext } from 'react';
+import { BrandingContextType } from './types';
+
+// Create a context with undefined as default value
+// The actual implementation will be provided by BrandingProvider
+export const BrandingContext = createContext<BrandingContextType | undefined>(
+	undefined
+);
@@ -0,0 +1,7 @@
+// react-display/src/contexts/BrandingContext/index.ts
+
+// Export everything from this module
+export * from './brandingContext';
+export * from './BrandingProvider';
+export * from './useBranding';
+export * from './types';
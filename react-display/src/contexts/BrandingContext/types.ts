@@ -0,0 +1,23 @@
+// react-display/src/contexts/BrandingContext/types.ts
+
+// Branding is the event the sign is dressed for, served by go-signs at /branding
+export interface Branding {
+	eventName: string;
+	logoUrl?: string; // Empty for the logo the display was built with
+	wifi: {
+		ssid?: string;
+		password?: string;
+		imageUrl?: string; // Shown instead of the SSID and password
+	};
+	colors: {
+		primary: string;
+		text: string;
+		panel: string;
+		accent: string;
+		background: string;
+	};
+}
+
+export interface BrandingContextType {
+	branding: Branding;
+}
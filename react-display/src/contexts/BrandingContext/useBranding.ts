@@ -0,0 +1,17 @@
+// react-display/src/contexts/BrandingContext/useBranding.ts
+
+import React from 'react';
+import { BrandingContext } from './brandingContext';
+import { BrandingContextType } from './types';
+
+// Custom hook to use the BrandingContext
+export function useBranding(): BrandingContextType {
+	// Using React 19's 'use' API for context
+	const context = React.use(BrandingContext);
+
+	if (context === undefined) {
+		throw new Error('useBranding must be used within a BrandingProvider');
+	}
+
+	return context;
+}
@@ -0,0 +1,109 @@
+// react-display/src/contexts/ConfigContext/ConfigProvider.tsx
+
+import React, { useState, useEffect, useMemo } from 'react';
+import { ConfigContext } from './configContext';
+import { SignConfig } from './types';
+import { Spinner } from '../../components/Spinner';
+
+// Used when /config can't be reached, matching the go-signs defaults
+const defaultConfig: SignConfig = {
+	signId: '',
+	room: '',
+	layout: 'hallway',
+	sponsorTiers: [],
+	scheduleRotationSeconds: 15,
+	sponsorRotationSeconds: 10,
+};
+
+// Apply overrides from URL parameters, e.g. ?room=Ballroom%20A&layout=room
+function applyURLOverrides(config: SignConfig): SignConfig {
+	const params = new URLSearchParams(window.location.search);
+	const result = { ...config };
+
+	const room = params.get('room');
+	if (room !== null) {
+		result.room = room;
+	}
+
+	const layout = params.get('layout');
+	if (layout === 'hallway' || layout === 'room') {
+		result.layout = layout;
+	}
+
+	const tiers = params.get('tiers');
+	if (tiers !== null) {
+		result.sponsorTiers = tiers.split(',').filter((tier) => tier !== '');
+	}
+
+	const scheduleRotation = parseInt(params.get('scheduleRotation') ?? '', 10);
+	if (!isNaN(scheduleRotation) && scheduleRotation > 0) {
+		result.scheduleRotationSeconds = scheduleRotation;
+	}
+
+	const sponsorRotation = parseInt(params.get('sponsorRotation') ?? '', 10);
+	if (!isNaN(sponsorRotation) && sponsorRotation > 0) {
+		result.sponsorRotationSeconds = sponsorRotation;
+	}
+
+	return result;
+}
+
+export function ConfigProvider({ children }: { children: React.ReactNode }) {
+	const [config, setConfig] = useState<SignConfig>(() =>
+		applyURLOverrides(defaultConfig)
+	);
+	const [isLoading, setIsLoading] = useState<boolean>(true);
+	const [error, setError] = useState<Error | null>(null);
+
+	// Read the profile once at boot
+	useEffect(() => {
+		const fetchConfig = async () => {
+			try {
+				const response = await fetch('/config');
+				if (!response.ok) {
+					throw new Error(
+						`Failed to fetch config: ${String(response.status)} ${
+							response.statusText
+						}`
+					);
+				}
+
+				const data = (await response.json()) as Partial<SignConfig>;
+				const merged = applyURLOverrides({ ...defaultConfig, ...data });
+				console.log(
+					`Loaded config for sign ${merged.signId}: layout ${merged.layout}, room ${
+						merged.room !== '' ? merged.room : 'all'
+					}`
+				);
+				setConfig(merged);
+			} catch (err) {
+				console.error('Error fetching config, using defaults:', err);
+				setError(err instanceof Error ? err : new Error(String(err)));
+			} finally {
+				setIsLoading(false);
+			}
+		};
+
+		void fetchConfig();
+	}, []);
+
+	const contextValue = useMemo(
+		() => ({
+			config,
+			isLoading,
+			error,
+		}),
+		[config, isLoading, error]
+	);
+
+	// Hold off rendering the display until the profile is known
+	if (isLoading) {
+		return (
+			<div className='flex h-screen w-full items-center justify-center'>
+				<Spinner />
+			</div>
+		);
+	}
+
+	return <ConfigContext value={contextValue}>{children}</ConfigContext>;
+}
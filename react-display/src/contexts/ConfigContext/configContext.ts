@@ -0,0 +1,10 @@
+// react-display/src/contexts/ConfigContext/configContext.ts
+
+import { createContext } from 'react';
+import { ConfigContextType } from './types';
+
+// Create a context with undefined as default value
+// The actual implementation will be provided by ConfigProvider
+export const ConfigContext = createContext<ConfigContextType | undefined>(
+	undefined
+);
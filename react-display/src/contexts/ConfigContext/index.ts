@@ -0,0 +1,7 @@
+// react-display/src/contexts/ConfigContext/index.ts
+
+// Export everything from this module
+export * from './configContext';
+export * from './ConfigProvider';
+export * from './useConfig';
+export * from './types';
@@ -0,0 +1,19 @@
+// react-display/src/contexts/ConfigContext/types.ts
+
+export type Layout = 'hallway' | 'room';
+
+// SignConfig is the profile served by go-signs at /config
+export interface SignConfig {
+	signId: string;
+	room: string; // Pin the schedule to this room, empty for all rooms
+	layout: Layout;
+	sponsorTiers: string[]; // Empty for every tier
+	scheduleRotationSeconds: number;
+	sponsorRotationSeconds: number;
+}
+
+export interface ConfigContextType {
+	config: SignConfig;
+	isLoading: boolean;
+	error: Error | null;
+}
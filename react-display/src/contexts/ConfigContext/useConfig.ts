@@ -0,0 +1,17 @@
+// react-display/src/contexts/ConfigContext/useConfig.ts
+
+import React from 'react';
+import { ConfigContext } from './configContext';
+import { ConfigContextType } from './types';
+
+// Custom hook to use the ConfigContext
+export function useConfig(): ConfigContextType {
+	// Using React 19's 'use' API for context
+	const context = React.use(ConfigContext);
+
+	if (context === undefined) {
+		throw new Error('useConfig must be used within a ConfigProvider');
+	}
+
+	return context;
+}
@@ -9,12 +9,14 @@ interface ScheduleProviderProps {
 	children: React.ReactNode;
 	refreshInterval?: number; // in milliseconds, default: 60000 (1 minute)
 	minSessionCount?: number; // minimum number of sessions to display, default: 6
+	room?: string; // only show sessions in this room, default: all rooms
 }
 
 export function ScheduleProvider({
 	children,
 	refreshInterval = 60000,
 	minSessionCount = 6,
+	room = '',
 }: ScheduleProviderProps) {
 	const { currentTime } = useTime();
 	const [schedule, setSchedule] = useState<ScheduleData | null>(null);
@@ -206,8 +208,10 @@ export function ScheduleProvider({
 
 		console.log(`Getting sessions at ${currentTime.toLocaleTimeString()}`);
 
-		// Process all sessions with their statuses
-		const allSessions = schedule.Presentations.map((session) => {
+		// Process all sessions with their statuses, keeping only the pinned room if set
+		const allSessions = schedule.Presentations.filter(
+			(session) => room === '' || session.Location === room
+		).map((session) => {
 			const status = getSessionStatus(session);
 			return { ...session, status } as SessionWithStatus;
 		});
@@ -443,6 +447,7 @@ export function ScheduleProvider({
 		minSessionCount,
 		currentTime,
 		isSameDay,
+		room,
 	]);
 
 	// Check if we're using stale data (data older than 5 minutes)
@@ -1,5 +1,15 @@
 // react-display/src/contexts/ScheduleContext/types.ts
 
+// SessionSponsor is a sponsor tied to a session's room, topic or time
+export interface SessionSponsor {
+	name: string;
+	tier: string;
+	imageUrl: string;
+	website?: string;
+	from?: string; // start of the sponsor's time window, if it has one
+	until?: string; // end of the sponsor's time window, if it has one
+}
+
 export interface Presentation {
 	Name: string;
 	Description: string;
@@ -8,6 +18,7 @@ export interface Presentation {
 	EndTime: string;
 	Speakers: string;
 	Topic: string;
+	Sponsors?: SessionSponsor[];
 }
 
 export interface ScheduleData {
@@ -2,36 +2,90 @@
 
 import React, { useState, useEffect, useCallback, useRef } from 'react';
 import { SponsorContext } from './sponsorContext';
+import { Impression, Sponsor } from './types';
 
-export function SponsorProvider({ children }: { children: React.ReactNode }) {
+// How often shown logos are reported to /sponsors/impressions
+const IMPRESSION_REPORT_INTERVAL = 60000;
+
+// Impressions kept while the server can't be reached
+const MAX_QUEUED_IMPRESSIONS = 5000;
+
+// How often /sponsors/version is checked for logos and tiers changed by an admin
+const VERSION_POLL_INTERVAL = 5000;
+
+// Strip the cache-busting query from a logo URL
+function baseUrl(url: string): string {
+	return url.split('?')[0];
+}
+
+interface SponsorProviderProps {
+	children: React.ReactNode;
+	tiers?: string[]; // sponsor tiers to show, default: all tiers
+	sign?: string; // sign ID, picks where this sign starts in the rotation plan
+	rotationSeconds?: number; // seconds each logo stays on screen
+	shown?: number; // logos on screen at once
+}
+
+export function SponsorProvider({
+	children,
+	tiers,
+	sign = '',
+	rotationSeconds = 10,
+	shown = 3,
+}: SponsorProviderProps) {
 	const [sponsorImages, setSponsorImages] = useState<string[]>([]);
+	const [rotationSlots, setRotationSlots] = useState<string[]>([]);
+	const [sponsorsByUrl, setSponsorsByUrl] = useState<Map<string, Sponsor>>(
+		new Map()
+	);
 	const [isLoading, setIsLoading] = useState<boolean>(true);
+	const [version, setVersion] = useState<string>('');
 	const [error, setError] = useState<Error | null>(null);
 
 	// Use a ref for used images instead of state
 	const usedImagesRef = useRef<Set<string>>(new Set());
 
+	// Position in the rotation plan, which loops
+	const rotationIndexRef = useRef<number>(0);
+
+	// Impressions waiting to be reported, and whether the server counts them
+	const impressionsRef = useRef<Impression[]>([]);
+	const reportingRef = useRef<boolean>(true);
+
+	// Compare tiers by value so a new array with the same tiers doesn't refetch
+	const tiersKey = tiers?.join(',') ?? '';
+
 	const fetchSponsorImages = useCallback(async () => {
 		setIsLoading(true);
 		setError(null);
 		try {
-			const response = await fetch('/sponsors/all');
-			if (!response.ok) {
-				throw new Error(
-					`Failed to fetch sponsors: ${String(response.status)} ${
-						response.statusText
-					}`
-				);
-			}
-			const data: unknown = await response.json();
-			if (!Array.isArray(data)) {
-				throw new Error(
-					'Expected an array of strings but received a different data structure'
+			const paths =
+				tiersKey !== ''
+					? tiersKey
+							.split(',')
+							.map((tier) => `/sponsors/${encodeURIComponent(tier)}`)
+					: ['/sponsors/all'];
+
+			const images: string[] = [];
+			for (const path of paths) {
+				const response = await fetch(path);
+				if (!response.ok) {
+					throw new Error(
+						`Failed to fetch sponsors: ${String(response.status)} ${
+							response.statusText
+						}`
+					);
+				}
+				const data: unknown = await response.json();
+				if (!Array.isArray(data)) {
+					throw new Error(
+						'Expected an array of strings but received a different data structure'
+					);
+				}
+				images.push(
+					...data.filter((item): item is string => typeof item === 'string')
 				);
 			}
-			const images = data.filter(
-				(item): item is string => typeof item === 'string'
-			);
 			setSponsorImages(images);
 			// Reset the used images ref when new data comes in
 			usedImagesRef.current = new Set();
@@ -41,12 +95,186 @@ export function SponsorProvider({ children }: { children: React.ReactNode }) {
 		} finally {
 			setIsLoading(false);
 		}
-	}, []);
+	}, [tiersKey]);
+
+	// The server plans a fair rotation; without it logos are picked at random
+	const fetchRotation = useCallback(async () => {
+		try {
+			const params = new URLSearchParams({
+				sign,
+				interval: String(rotationSeconds),
+				shown: String(shown),
+			});
+			if (tiersKey !== '') {
+				params.set('tiers', tiersKey);
+			}
+			const response = await fetch(`/sponsors/rotation?${params.toString()}`);
+			if (!response.ok) {
+				throw new Error(
+					`Failed to fetch sponsor rotation: ${String(response.status)} ${
+						response.statusText
+					}`
+				);
+			}
+			const data = (await response.json()) as { slots?: unknown };
+			setRotationSlots(
+				Array.isArray(data.slots)
+					? data.slots.filter((s): s is string => typeof s === 'string')
+					: []
+			);
+		} catch (err) {
+			console.error('Error fetching sponsor rotation:', err);
+			setRotationSlots([]);
+		}
+		rotationIndexRef.current = 0;
+	}, [sign, rotationSeconds, shown, tiersKey]);
+
+	const refreshSponsors = useCallback(async () => {
+		await Promise.all([fetchSponsorImages(), fetchRotation()]);
+	}, [fetchSponsorImages, fetchRotation]);
 
 	useEffect(() => {
 		void fetchSponsorImages();
 	}, [fetchSponsorImages]);
 
+	useEffect(() => {
+		void fetchRotation();
+	}, [fetchRotation]);
+
+	// Names and links are nice to have, so the logos show without them
+	const fetchSponsorMetadata = useCallback(async () => {
+		try {
+			const response = await fetch('/sponsors');
+			if (!response.ok) {
+				throw new Error(
+					`Failed to fetch sponsor metadata: ${String(response.status)} ${
+						response.statusText
+					}`
+				);
+			}
+			const data = (await response.json()) as { sponsors?: Sponsor[] };
+			setSponsorsByUrl(
+				new Map((data.sponsors ?? []).map((s) => [s.imageUrl, s]))
+			);
+		} catch (err) {
+			console.error('Error fetching sponsor metadata:', err);
+		}
+	}, []);
+
+	useEffect(() => {
+		void fetchSponsorMetadata();
+	}, [fetchSponsorMetadata]);
+
+	// Refetch everything when an admin changes a logo or tier, and load logos
+	// under a new URL so a replaced one isn't served from the browser cache
+	useEffect(() => {
+		let current = '';
+		const poll = async () => {
+			try {
+				const response = await fetch('/sponsors/version', {
+					cache: 'no-store',
+				});
+				if (!response.ok) {
+					return;
+				}
+				const data = (await response.json()) as { version?: unknown };
+				if (typeof data.version !== 'string' || data.version === current) {
+					return;
+				}
+				const changed = current !== '';
+				current = data.version;
+				if (changed) {
+					setVersion(data.version);
+					await Promise.all([refreshSponsors(), fetchSponsorMetadata()]);
+				}
+			} catch (err) {
+				console.error('Error checking the sponsor version:', err);
+			}
+		};
+		void poll();
+		const timer = window.setInterval(() => {
+			void poll();
+		}, VERSION_POLL_INTERVAL);
+		return () => {
+			clearInterval(timer);
+		};
+	}, [refreshSponsors, fetchSponsorMetadata]);
+
+	const versioned = useCallback(
+		(url: string): string =>
+			version === ''
+				? url
+				: `${url}${url.includes('?') ? '&' : '?'}v=${encodeURIComponent(
+						version
+				  )}`,
+		[version]
+	);
+
+	const recordImpressions = useCallback((urls: string[], seconds: number) => {
+		if (!reportingRef.current || seconds <= 0) {
+			return;
+		}
+		const at = new Date().toISOString();
+		for (const url of urls) {
+			const image = baseUrl(url).split('/').pop();
+			if (image) {
+				impressionsRef.current.push({ image, seconds, at });
+			}
+		}
+		impressionsRef.current = impressionsRef.current.slice(
+			-MAX_QUEUED_IMPRESSIONS
+		);
+	}, []);
+
+	// Report shown logos in batches; a 404 means the server doesn't count them
+	useEffect(() => {
+		if (sign === '') {
+			return;
+		}
+		const reportImpressions = async () => {
+			const impressions = impressionsRef.current;
+			if (!reportingRef.current || impressions.length === 0) {
+				return;
+			}
+			impressionsRef.current = [];
+			try {
+				const response = await fetch('/sponsors/impressions', {
+					method: 'POST',
+					headers: { 'Content-Type': 'application/json' },
+					body: JSON.stringify({ sign, impressions }),
+				});
+				if (response.status === 404) {
+					reportingRef.current = false;
+					return;
+				}
+				if (!response.ok && response.status !== 400) {
+					throw new Error(
+						`Failed to report impressions: ${String(response.status)} ${
+							response.statusText
+						}`
+					);
+				}
+			} catch (err) {
+				console.error('Error reporting sponsor impressions:', err);
+				impressionsRef.current = [
+					...impressions,
+					...impressionsRef.current,
+				].slice(-MAX_QUEUED_IMPRESSIONS);
+			}
+		};
+		const timer = window.setInterval(() => {
+			void reportImpressions();
+		}, IMPRESSION_REPORT_INTERVAL);
+		return () => {
+			clearInterval(timer);
+		};
+	}, [sign]);
+
+	const getSponsor = useCallback(
+		(url: string): Sponsor | undefined => sponsorsByUrl.get(baseUrl(url)),
+		[sponsorsByUrl]
+	);
+
 	const getRandomSponsorUrl = useCallback((): string => {
 		if (sponsorImages.length === 0) {
 			return ''; // No images available
@@ -71,8 +299,8 @@ export function SponsorProvider({ children }: { children: React.ReactNode }) {
 		// Update the ref with the selected image
 		usedImagesRef.current.add(selectedImage);
 
-		return `/sponsors/images/${selectedImage}`;
-	}, [sponsorImages]);
+		return versioned(`/sponsors/images/${selectedImage}`);
+	}, [sponsorImages, versioned]);
 
 	const getRandomSponsorUrls = useCallback(
 		(count: number): string[] => {
@@ -86,24 +314,48 @@ export function SponsorProvider({ children }: { children: React.ReactNode }) {
 		[getRandomSponsorUrl, sponsorImages.length]
 	);
 
+	const getNextSponsorUrls = useCallback(
+		(count: number): string[] => {
+			if (rotationSlots.length === 0) {
+				return getRandomSponsorUrls(count);
+			}
+			const urls: string[] = [];
+			for (let i = 0; i < count; i++) {
+				urls.push(versioned(rotationSlots[rotationIndexRef.current]));
+				rotationIndexRef.current =
+					(rotationIndexRef.current + 1) % rotationSlots.length;
+			}
+			return urls;
+		},
+		[rotationSlots, getRandomSponsorUrls, versioned]
+	);
+
 	const getAllSponsorUrls = useCallback((): string[] => {
-		return sponsorImages.map((img) => `/sponsors/images/${img}`);
-	}, [sponsorImages]);
+		return sponsorImages.map((img) => versioned(`/sponsors/images/${img}`));
+	}, [sponsorImages, versioned]);
 
 	const contextValue = React.useMemo(
 		() => ({
 			getRandomSponsorUrl,
 			getRandomSponsorUrls,
-			refreshSponsors: fetchSponsorImages,
+			getNextSponsorUrls,
+			refreshSponsors,
 			getAllSponsorUrls,
+			getSponsor,
+			versioned,
+			recordImpressions,
 			isLoading,
 			error,
 		}),
 		[
 			getRandomSponsorUrl,
 			getRandomSponsorUrls,
-			fetchSponsorImages,
+			getNextSponsorUrls,
+			refreshSponsors,
 			getAllSponsorUrls,
+			getSponsor,
+			versioned,
+			recordImpressions,
 			isLoading,
 			error,
 		]
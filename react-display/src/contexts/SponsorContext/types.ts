@@ -1,5 +1,23 @@
 // react-display/src/contexts/SponsorContext/types.ts
 
+// Sponsor metadata as served at /sponsors
+export interface Sponsor {
+	name: string; // also the alt text for the image
+	tier: string;
+	image: string;
+	imageUrl: string;
+	website?: string;
+	qr?: string;
+	weight: number;
+}
+
+// A logo shown on this sign, as reported to /sponsors/impressions
+export interface Impression {
+	image: string;
+	seconds: number;
+	at: string; // when it left the screen, RFC 3339
+}
+
 export interface SponsorContextType {
 	// Get a random sponsor image URL that hasn't been used in the current cycle
 	getRandomSponsorUrl: () => string;
@@ -7,12 +25,26 @@ export interface SponsorContextType {
 	// Get a specific number of random sponsor image URLs
 	getRandomSponsorUrls: (count: number) => string[];
 
+	// Get the next logos of this sign's rotation plan, or random ones when the
+	// plan is unavailable
+	getNextSponsorUrls: (count: number) => string[];
+
 	// Force a refresh of the sponsor list from the server
 	refreshSponsors: () => Promise<void>;
 
 	// Get all available sponsor URLs
 	getAllSponsorUrls: () => string[];
 
+	// Get the metadata of the sponsor at an image URL, if it is listed
+	getSponsor: (url: string) => Sponsor | undefined;
+
+	// Add the version of the sponsor images to an image URL, so logos are
+	// fetched again when they change
+	versioned: (url: string) => string;
+
+	// Count logos that were on screen for a number of seconds
+	recordImpressions: (urls: string[], seconds: number) => void;
+
 	// Check if sponsors are currently loading
 	isLoading: boolean;
 
@@ -10,6 +10,9 @@ export default defineConfig({
 		proxy: {
 			'/schedule': 'http://localhost:2017',
 			'/sponsors': 'http://localhost:2017',
+			'/config': 'http://localhost:2017',
+			'/display': 'http://localhost:2017',
+			'/branding': 'http://localhost:2017',
 		},
 	},
 	build: {